@@ -8,10 +8,16 @@ type User struct {
 	Name string
 }
 
-func GetUser(db *sql.DB, id int) (*User, error) {
+// Queryer is satisfied by both *sql.DB and *sql.Tx, so tests can run
+// GetUser inside a transaction they roll back.
+type Queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func GetUser(q Queryer, id int) (*User, error) {
 	var user User
-	err := db.
-		QueryRow("SELECT id, name FROM users WHERE id = ?", id).
+	err := q.
+		QueryRow("SELECT id, name FROM users WHERE id = $1", id).
 		Scan(&user.ID, &user.Name)
 	if err != nil {
 		return nil, err
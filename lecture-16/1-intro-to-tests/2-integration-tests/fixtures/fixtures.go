@@ -0,0 +1,291 @@
+// Package fixtures loads test data from YAML or JSON files. A file maps
+// table names to their rows:
+//
+//	users:
+//	  - id: 1
+//	    name: Alice
+//	orders:
+//	  - user_id: 1
+//	    total: 100
+//
+// Load empties the listed tables, inserts the rows parents first along the
+// foreign keys, and moves serial and identity sequences past the highest
+// value, so rows the test creates afterwards get fresh ids. Tables that are
+// not listed are left alone; if one of them references a listed table, the
+// delete fails rather than cascading into it.
+package fixtures
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/jackc/pgx/v5"
+)
+
+// DB is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Row maps column names to values. Nested objects and lists are stored as
+// JSON, for json and jsonb columns.
+type Row map[string]any
+
+// Set holds the rows of each table.
+type Set map[string][]Row
+
+// Load reads the files matching patterns and inserts them into db, failing
+// the test on error.
+func Load(tb testing.TB, db DB, patterns ...string) {
+	tb.Helper()
+	set, err := Read(patterns...)
+	if err != nil {
+		tb.Fatalf("fixtures: %v", err)
+	}
+	if err := set.Insert(context.Background(), db); err != nil {
+		tb.Fatalf("fixtures: %v", err)
+	}
+}
+
+// Read parses YAML (.yml, .yaml) and JSON (.json) files. A glob expands to
+// its matches; rows for a table found in several files are concatenated in
+// file order.
+func Read(patterns ...string) (Set, error) {
+	set := Set{}
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+		slices.Sort(paths)
+		for _, path := range paths {
+			file, err := readFile(path)
+			if err != nil {
+				return nil, err
+			}
+			for table, rows := range file {
+				set[table] = append(set[table], rows...)
+			}
+		}
+	}
+	return set, nil
+}
+
+func readFile(path string) (Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file Set
+	switch ext := filepath.Ext(path); ext {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("%s: unsupported fixture format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for table, rows := range file {
+		for i, row := range rows {
+			if err := row.normalize(); err != nil {
+				return nil, fmt.Errorf("%s: %s[%d]: %w", path, table, i, err)
+			}
+		}
+	}
+	return file, nil
+}
+
+// normalize turns decoded values into ones database/sql accepts.
+func (r Row) normalize() error {
+	for col, v := range r {
+		switch v := v.(type) {
+		case map[string]any, []any:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", col, err)
+			}
+			r[col] = string(b)
+		case uint64:
+			if v > math.MaxInt64 {
+				return fmt.Errorf("%s: %d overflows int64", col, v)
+			}
+			r[col] = int64(v)
+		case float64:
+			// encoding/json decodes every number as float64.
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				r[col] = int64(v)
+			}
+		}
+	}
+	return nil
+}
+
+// Insert replaces the contents of the tables in s with its rows.
+func (s Set) Insert(ctx context.Context, db DB) error {
+	tables := slices.Sorted(maps.Keys(s))
+	deps, err := foreignKeys(ctx, db)
+	if err != nil {
+		return err
+	}
+	ordered, err := order(tables, deps)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range slices.Backward(ordered) {
+		if _, err := db.ExecContext(ctx, "DELETE FROM "+quote(table)); err != nil {
+			return fmt.Errorf("empty %s: %w", table, err)
+		}
+	}
+	for _, table := range ordered {
+		for i, row := range s[table] {
+			if err := insert(ctx, db, table, row); err != nil {
+				return fmt.Errorf("%s[%d]: %w", table, i, err)
+			}
+		}
+		if err := resetSequences(ctx, db, table); err != nil {
+			return fmt.Errorf("%s: reset sequences: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func insert(ctx context.Context, db DB, table string, row Row) error {
+	cols := slices.Sorted(maps.Keys(row))
+	names := make([]string, len(cols))
+	params := make([]string, len(cols))
+	args := make([]any, len(cols))
+	for i, col := range cols {
+		names[i] = pgx.Identifier{col}.Sanitize()
+		params[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = row[col]
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quote(table), strings.Join(names, ", "), strings.Join(params, ", "))
+	if len(cols) == 0 {
+		query = "INSERT INTO " + quote(table) + " DEFAULT VALUES"
+	}
+	_, err := db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// foreignKeys lists (child, parent) pairs of tables visible on the search
+// path, named the way regclass prints them.
+func foreignKeys(ctx context.Context, db DB) ([][2]string, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT conrelid::regclass::text, confrelid::regclass::text
+		 FROM pg_constraint
+		 WHERE contype = 'f' AND conrelid <> confrelid`)
+	if err != nil {
+		return nil, fmt.Errorf("read foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	var deps [][2]string
+	for rows.Next() {
+		var dep [2]string
+		if err := rows.Scan(&dep[0], &dep[1]); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, rows.Err()
+}
+
+// order sorts tables so that every table comes after the ones it
+// references, otherwise keeping their given order. References to tables
+// outside the set and self-references are ignored.
+func order(tables []string, deps [][2]string) ([]string, error) {
+	parents := make(map[string][]string)
+	for _, d := range deps {
+		child, parent := d[0], d[1]
+		if child != parent && slices.Contains(tables, child) && slices.Contains(tables, parent) {
+			parents[child] = append(parents[child], parent)
+		}
+	}
+
+	ordered := make([]string, 0, len(tables))
+	done := make(map[string]bool)
+	for len(ordered) < len(tables) {
+		progress := false
+		for _, t := range tables {
+			if done[t] || slices.ContainsFunc(parents[t], func(p string) bool { return !done[p] }) {
+				continue
+			}
+			ordered = append(ordered, t)
+			done[t] = true
+			progress = true
+		}
+		if !progress {
+			var cycle []string
+			for _, t := range tables {
+				if !done[t] {
+					cycle = append(cycle, t)
+				}
+			}
+			return nil, fmt.Errorf("foreign keys form a cycle between %s", strings.Join(cycle, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+func resetSequences(ctx context.Context, db DB, table string) error {
+	rows, err := db.QueryContext(ctx,
+		`SELECT attname, pg_get_serial_sequence($1, attname)
+		 FROM pg_attribute
+		 WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped
+		   AND pg_get_serial_sequence($1, attname) IS NOT NULL`,
+		quote(table))
+	if err != nil {
+		return err
+	}
+	type serial struct{ col, seq string }
+	var serials []serial
+	for rows.Next() {
+		var s serial
+		if err := rows.Scan(&s.col, &s.seq); err != nil {
+			rows.Close()
+			return err
+		}
+		serials = append(serials, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	slices.SortFunc(serials, func(a, b serial) int { return cmp.Compare(a.col, b.col) })
+	for _, s := range serials {
+		col := pgx.Identifier{s.col}.Sanitize()
+		_, err := db.ExecContext(ctx, fmt.Sprintf(
+			"SELECT setval($1, COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)", col, quote(table)),
+			s.seq)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quote sanitizes a possibly schema-qualified table name.
+func quote(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
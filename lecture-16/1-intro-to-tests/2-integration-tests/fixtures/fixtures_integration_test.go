@@ -0,0 +1,65 @@
+//go:build integration
+
+package fixtures_test
+
+import (
+	"os"
+	"testing"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/fixtures"
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func TestLoad(t *testing.T) {
+	db := pgtest.Open(t, pgtest.Options{Migrations: "testdata/schema.sql"})
+	if _, err := db.Exec("INSERT INTO authors (name) VALUES ('stale')"); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures.Load(t, db, "testdata/*.yml", "testdata/*.json")
+
+	var authors, books int
+	if err := db.QueryRow("SELECT (SELECT count(*) FROM authors), (SELECT count(*) FROM books)").Scan(&authors, &books); err != nil {
+		t.Fatal(err)
+	}
+	if authors != 2 || books != 2 {
+		t.Errorf("loaded %d authors and %d books, want 2 and 2", authors, books)
+	}
+
+	var pages int
+	if err := db.QueryRow("SELECT (meta->>'pages')::int FROM books WHERE id = 1").Scan(&pages); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 1225 {
+		t.Errorf("pages = %d, want 1225", pages)
+	}
+
+	// Both the identity and the serial column continue after the fixtures.
+	var authorID, bookID int64
+	if err := db.QueryRow("INSERT INTO authors (name) VALUES ('Anton Chekhov') RETURNING id").Scan(&authorID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("INSERT INTO books (author_id, title) VALUES ($1, 'The Seagull') RETURNING id", authorID).Scan(&bookID); err != nil {
+		t.Fatal(err)
+	}
+	if authorID != 3 || bookID != 3 {
+		t.Errorf("new ids = %d, %d, want 3, 3", authorID, bookID)
+	}
+}
+
+func TestLoad_ThroughOpen(t *testing.T) {
+	db := pgtest.Open(t, pgtest.Options{
+		Migrations: "testdata/schema.sql",
+		Fixtures:   []string{"testdata/authors.json"},
+	})
+
+	var name string
+	if err := db.QueryRow("SELECT name FROM authors WHERE id = 2").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "Mikhail Bulgakov" {
+		t.Errorf("name = %q", name)
+	}
+}
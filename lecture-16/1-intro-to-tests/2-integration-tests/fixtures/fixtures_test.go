@@ -0,0 +1,58 @@
+package fixtures
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	set, err := Read("testdata/*.yml", "testdata/authors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(set["authors"]) != 2 || len(set["books"]) != 2 {
+		t.Fatalf("set = %v, want two authors and two books", set)
+	}
+	// JSON numbers are float64 until normalized.
+	if id := set["authors"][1]["id"]; id != int64(2) {
+		t.Errorf("author id = %#v, want int64(2)", id)
+	}
+	if id := set["books"][0]["author_id"]; id != int64(1) {
+		t.Errorf("book author_id = %#v, want int64(1)", id)
+	}
+	if meta := set["books"][0]["meta"]; meta != `{"pages":1225,"tags":["novel","history"]}` {
+		t.Errorf("book meta = %#v, want a JSON string", meta)
+	}
+
+	if _, err := Read("testdata/schema.sql"); err == nil {
+		t.Error("Read of an .sql file succeeded")
+	}
+	if _, err := Read("testdata/missing-*.yml"); err == nil {
+		t.Error("Read of a pattern without matches succeeded")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	deps := [][2]string{
+		{"books", "authors"},
+		{"reviews", "books"},
+		{"reviews", "users"},
+		{"users", "users"},         // self-reference
+		{"audit", "accounts"},      // not in the set
+		{"other.books", "authors"}, // another schema
+	}
+	got, err := order([]string{"authors", "books", "reviews", "users"}, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"authors", "books", "users", "reviews"}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	_, err = order([]string{"a", "b", "c"}, [][2]string{{"a", "b"}, {"b", "a"}})
+	if err == nil || !strings.Contains(err.Error(), "a, b") {
+		t.Errorf("cycle error = %v, want one naming a and b", err)
+	}
+}
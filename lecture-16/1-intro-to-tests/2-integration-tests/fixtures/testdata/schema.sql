@@ -0,0 +1,13 @@
+CREATE TABLE authors
+(
+    id   BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE books
+(
+    id        BIGSERIAL PRIMARY KEY,
+    author_id BIGINT NOT NULL REFERENCES authors (id),
+    title     TEXT   NOT NULL,
+    meta      JSONB  NOT NULL DEFAULT '{}'
+);
@@ -0,0 +1,38 @@
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type recorder struct{ queries []string }
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	return nil, nil
+}
+
+func TestLoad_OrdersGlobMatches(t *testing.T) {
+	dir := t.TempDir()
+	for name, query := range map[string]string{
+		"0002_b.sql": "B",
+		"0010_c.sql": "C",
+		"0001_a.sql": "A",
+		"seed.sql":   "SEED",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(query), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var r recorder
+	Load(t, &r, filepath.Join(dir, "0*.sql"), filepath.Join(dir, "seed.sql"))
+
+	if want := []string{"A", "B", "C", "SEED"}; !slices.Equal(r.queries, want) {
+		t.Errorf("queries = %v, want %v", r.queries, want)
+	}
+}
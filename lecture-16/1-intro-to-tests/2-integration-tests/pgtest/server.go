@@ -0,0 +1,147 @@
+package pgtest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+var (
+	once     sync.Once
+	admin    *sql.DB
+	connStr  string
+	cluster  *embedded
+	startErr error
+)
+
+// server returns a DB for creating schemas and the connection string the
+// tests connect with, starting an embedded cluster on first use.
+func server(tb testing.TB) (*sql.DB, string) {
+	tb.Helper()
+	once.Do(func() {
+		connStr = os.Getenv("TEST_DB_CONN_STR")
+		if connStr == "" {
+			bin := findBin()
+			if bin == "" {
+				return
+			}
+			cluster, startErr = startEmbedded(bin)
+			if startErr != nil {
+				return
+			}
+			connStr = cluster.connStr
+		}
+		admin, startErr = sql.Open("pgx", connStr)
+		if startErr == nil {
+			startErr = admin.Ping()
+		}
+	})
+	switch {
+	case startErr != nil:
+		tb.Fatalf("pgtest: %v", startErr)
+	case connStr == "":
+		tb.Skip("pgtest: TEST_DB_CONN_STR is not set and no Postgres binaries were found")
+	}
+	return admin, connStr
+}
+
+// Main runs the tests and then stops the embedded cluster, if one was
+// started:
+//
+//	func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+func Main(m *testing.M) int {
+	code := m.Run()
+	if admin != nil {
+		admin.Close()
+	}
+	if cluster != nil {
+		if err := cluster.stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "pgtest: %v\n", err)
+		}
+	}
+	return code
+}
+
+func findBin() string {
+	var dirs []string
+	if dir := os.Getenv("PGTEST_BIN"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+	// Debian and Ubuntu keep the server binaries off PATH. The glob sorts
+	// "16" after "9.6" but before "17", so take the last match.
+	if matches, _ := filepath.Glob("/usr/lib/postgresql/*/bin"); len(matches) > 0 {
+		dirs = append(dirs, matches[len(matches)-1])
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "pg_ctl")); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+type embedded struct {
+	bin, dir string
+	connStr  string
+}
+
+// startEmbedded initializes a cluster in a temporary directory and starts
+// it on a free local port. Durability is off: the data is thrown away.
+func startEmbedded(bin string) (*embedded, error) {
+	dir, err := os.MkdirTemp("", "pgtest")
+	if err != nil {
+		return nil, err
+	}
+	e := &embedded{bin: bin, dir: dir}
+	data := filepath.Join(dir, "data")
+
+	if err := e.run("initdb", "-D", data, "-U", "postgres", "-A", "trust", "--no-sync"); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c synchronous_commit=off -c full_page_writes=off", port, dir)
+	if err := e.run("pg_ctl", "-D", data, "-o", opts, "-l", filepath.Join(dir, "log"), "-w", "-t", "30", "start"); err != nil {
+		log, _ := os.ReadFile(filepath.Join(dir, "log"))
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("%w\n%s", err, log)
+	}
+	e.connStr = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+	return e, nil
+}
+
+func (e *embedded) stop() error {
+	err := e.run("pg_ctl", "-D", filepath.Join(e.dir, "data"), "-m", "immediate", "-w", "stop")
+	return errors.Join(err, os.RemoveAll(e.dir))
+}
+
+func (e *embedded) run(name string, args ...string) error {
+	cmd := exec.Command(filepath.Join(e.bin, name), args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w\n%s", name, err, out)
+	}
+	return nil
+}
+
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
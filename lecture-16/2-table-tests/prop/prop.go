@@ -0,0 +1,203 @@
+// Package prop is a small property-based testing helper. It generates
+// random values of any type, structs included, and checks that a property
+// holds for each of them. A failure reports the seed, so the same values
+// can be generated again:
+//
+//	go test -run TestCreateUser_Property -prop.seed=1234
+package prop
+
+import (
+	"flag"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var seedFlag = flag.Uint64("prop.seed", 0, "seed for generated values; 0 picks a random one")
+
+// Generator is implemented by types that generate their own values, for
+// fields that need more structure than random data, such as emails. The
+// method must have a pointer receiver.
+type Generator interface {
+	Generate(r *rand.Rand)
+}
+
+// Options configures Check. Zero fields take the defaults noted below.
+type Options struct {
+	// N is the number of values checked, 100 by default.
+	N int
+	// Seed fixes the generated values. It defaults to -prop.seed and,
+	// if that is not set either, to a random seed.
+	Seed uint64
+	// MaxLen bounds the length of strings, slices and maps, 8 by default.
+	MaxLen int
+}
+
+func (o *Options) setDefaults() {
+	if o.N <= 0 {
+		o.N = 100
+	}
+	if o.Seed == 0 {
+		o.Seed = *seedFlag
+	}
+	if o.Seed == 0 {
+		o.Seed = rand.Uint64()
+	}
+	if o.MaxLen <= 0 {
+		o.MaxLen = 8
+	}
+}
+
+// Check calls property with opts.N generated values of T and fails t at
+// the first value for which it returns an error.
+func Check[T any](t testing.TB, opts Options, property func(v T) error) {
+	t.Helper()
+	opts.setDefaults()
+	r := rand.New(rand.NewPCG(opts.Seed, 0))
+	for i := range opts.N {
+		v := Generate[T](r, opts.MaxLen)
+		if err := property(v); err != nil {
+			t.Fatalf("property failed for value #%d (rerun with -prop.seed=%d):\n%#v\n%v", i, opts.Seed, v, err)
+			return
+		}
+	}
+}
+
+// Generate returns a random value of type T with strings, slices and maps
+// no longer than maxLen. Exported struct fields are filled recursively and
+// unexported ones are left zero, as are channels, funcs and interfaces.
+// Floats are always finite, so values can be encoded as JSON.
+func Generate[T any](r *rand.Rand, maxLen int) T {
+	var v T
+	g := generator{r: r, maxLen: maxLen}
+	g.fill(reflect.ValueOf(&v).Elem(), 0)
+	return v
+}
+
+// maxDepth stops recursive types from growing without bound: deeper
+// pointers, slices and maps stay nil.
+const maxDepth = 5
+
+var (
+	generatorType = reflect.TypeFor[Generator]()
+	timeType      = reflect.TypeFor[time.Time]()
+)
+
+type generator struct {
+	r      *rand.Rand
+	maxLen int
+}
+
+func (g generator) fill(v reflect.Value, depth int) {
+	if v.CanAddr() && v.Addr().Type().Implements(generatorType) {
+		v.Addr().Interface().(Generator).Generate(g.r)
+		return
+	}
+	if v.Type() == timeType {
+		// Whole seconds between 1970 and 2100, so the value survives a
+		// round trip through JSON and most databases.
+		v.Set(reflect.ValueOf(time.Unix(g.r.Int64N(4102444800), 0).UTC()))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(g.r.IntN(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(g.int(v.Type().Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(g.int(v.Type().Bits())) & (math.MaxUint64 >> (64 - v.Type().Bits())))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(g.float())
+	case reflect.String:
+		v.SetString(g.string())
+	case reflect.Array:
+		for i := range v.Len() {
+			g.fill(v.Index(i), depth+1)
+		}
+	case reflect.Slice:
+		if depth >= maxDepth {
+			return
+		}
+		n := g.r.IntN(g.maxLen + 1)
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := range n {
+			g.fill(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		if depth >= maxDepth {
+			return
+		}
+		n := g.r.IntN(g.maxLen + 1)
+		v.Set(reflect.MakeMapWithSize(v.Type(), n))
+		for range n {
+			key := reflect.New(v.Type().Key()).Elem()
+			elem := reflect.New(v.Type().Elem()).Elem()
+			g.fill(key, depth+1)
+			g.fill(elem, depth+1)
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Pointer:
+		if depth >= maxDepth || g.r.IntN(4) == 0 {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		g.fill(v.Elem(), depth+1)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				g.fill(v.Field(i), depth+1)
+			}
+		}
+	}
+}
+
+// int favors the values that tend to break code: zero, ±1 and the limits
+// of the type.
+func (g generator) int(bits int) int64 {
+	hi := int64(math.MaxInt64 >> (64 - bits))
+	switch g.r.IntN(8) {
+	case 0:
+		return 0
+	case 1:
+		return []int64{-1, 1}[g.r.IntN(2)]
+	case 2:
+		return []int64{-hi - 1, hi}[g.r.IntN(2)]
+	case 3, 4:
+		return g.r.Int64N(201) - 100
+	default:
+		return int64(g.r.Uint64()) >> (64 - bits)
+	}
+}
+
+func (g generator) float() float64 {
+	switch g.r.IntN(4) {
+	case 0:
+		return 0
+	case 1:
+		return float64(g.r.IntN(201) - 100)
+	default:
+		return g.r.NormFloat64() * math.Pow(10, float64(g.r.IntN(13)-6))
+	}
+}
+
+// runes mixes letters with what parsers trip over: spaces, quotes, escapes,
+// separators, multi-byte characters and a NUL.
+var runes = []rune("abcxyzABCXYZ0189 \t\n\"'\\/<>&@.,:;%+-_=?#ёжЯ世界😀é\u200b\x00")
+
+func (g generator) string() string {
+	n := g.r.IntN(g.maxLen + 1)
+	b := make([]byte, 0, n)
+	for range n {
+		if g.r.IntN(32) == 0 {
+			// An invalid UTF-8 byte.
+			b = append(b, 0xff)
+			continue
+		}
+		b = utf8.AppendRune(b, runes[g.r.IntN(len(runes))])
+	}
+	return string(b)
+}
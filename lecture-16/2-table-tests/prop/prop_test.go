@@ -0,0 +1,155 @@
+package prop
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+type address struct {
+	City string
+	Zip  uint16
+}
+
+type person struct {
+	Name     string
+	Age      int8
+	Score    float64
+	Admin    bool
+	Tags     []string
+	Attrs    map[string]int
+	Home     *address
+	Born     time.Time
+	Parent   *person
+	internal string
+}
+
+type email string
+
+func (e *email) Generate(r *rand.Rand) {
+	*e = email(fmt.Sprintf("user%d@example.com", r.IntN(1000)))
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	a := Generate[person](rand.New(rand.NewPCG(1, 2)), 8)
+	b := Generate[person](rand.New(rand.NewPCG(1, 2)), 8)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed, different values:\n%#v\n%#v", a, b)
+	}
+}
+
+func TestGenerate_FillsExportedFields(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	seen := map[string]bool{}
+	for range 200 {
+		p := Generate[person](r, 4)
+		if p.internal != "" {
+			t.Fatalf("unexported field filled: %q", p.internal)
+		}
+		if utf8.RuneCountInString(p.Name) > 4 {
+			t.Fatalf("Name %q is longer than 4 characters", p.Name)
+		}
+		if len(p.Tags) > 4 || len(p.Attrs) > 4 {
+			t.Fatalf("Tags %d, Attrs %d, want at most 4", len(p.Tags), len(p.Attrs))
+		}
+		if !p.Born.IsZero() && p.Born.Location() != time.UTC {
+			t.Fatalf("Born %v is not in UTC", p.Born)
+		}
+		seen["Name"] = seen["Name"] || p.Name != ""
+		seen["Age"] = seen["Age"] || p.Age != 0
+		seen["Score"] = seen["Score"] || p.Score != 0
+		seen["Admin"] = seen["Admin"] || p.Admin
+		seen["Tags"] = seen["Tags"] || len(p.Tags) > 0
+		seen["Attrs"] = seen["Attrs"] || len(p.Attrs) > 0
+		seen["Home"] = seen["Home"] || p.Home != nil && p.Home.City != ""
+		seen["Born"] = seen["Born"] || !p.Born.IsZero()
+		seen["Parent"] = seen["Parent"] || p.Parent != nil
+	}
+	for _, f := range []string{"Name", "Age", "Score", "Admin", "Tags", "Attrs", "Home", "Born", "Parent"} {
+		if !seen[f] {
+			t.Errorf("%s was never filled", f)
+		}
+	}
+}
+
+func TestGenerate_Limits(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	var min8, max8 bool
+	for range 1000 {
+		switch Generate[int8](r, 8) {
+		case -128:
+			min8 = true
+		case 127:
+			max8 = true
+		}
+	}
+	if !min8 || !max8 {
+		t.Errorf("int8 limits generated: min %v, max %v", min8, max8)
+	}
+}
+
+func TestGenerate_Generator(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	for range 20 {
+		v := Generate[struct{ Emails []email }](r, 3)
+		for _, e := range v.Emails {
+			if !strings.HasSuffix(string(e), "@example.com") {
+				t.Fatalf("Generate was not used: %q", e)
+			}
+		}
+	}
+}
+
+// fakeT ловит Fatalf, чтобы проверить сообщение о провале.
+type fakeT struct {
+	testing.TB
+	failed string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.failed = fmt.Sprintf(format, args...)
+}
+
+func TestCheck(t *testing.T) {
+	calls := 0
+	Check(t, Options{N: 50}, func(p person) error {
+		calls++
+		return nil
+	})
+	if calls != 50 {
+		t.Errorf("property called %d times, want 50", calls)
+	}
+
+	ft := &fakeT{}
+	calls = 0
+	Check(ft, Options{Seed: 42}, func(n int) error {
+		calls++
+		if n > 1000 {
+			return errors.New("too big")
+		}
+		return nil
+	})
+	if !strings.Contains(ft.failed, "-prop.seed=42") || !strings.Contains(ft.failed, "too big") {
+		t.Errorf("failure message = %q", ft.failed)
+	}
+
+	// С тем же seed провал воспроизводится на том же значении.
+	again := 0
+	Check(&fakeT{}, Options{Seed: 42}, func(n int) error {
+		again++
+		if n > 1000 {
+			return errors.New("too big")
+		}
+		return nil
+	})
+	if again != calls {
+		t.Errorf("failed after %d values with the same seed, first run after %d", again, calls)
+	}
+}
@@ -1,10 +1,17 @@
 package table_tests
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 // Split slices s into all substrings separated by sep and
 // returns a slice of the substrings between those separators.
+// An empty sep splits s after each UTF-8 sequence, like strings.Split.
 func Split(s, sep string) []string {
+	if sep == "" {
+		return explode(s)
+	}
 	var result []string
 	i := strings.Index(s, sep)
 	for i > -1 {
@@ -14,3 +21,14 @@ func Split(s, sep string) []string {
 	}
 	return append(result, s)
 }
+
+// explode splits s into UTF-8 sequences; an invalid byte is a piece of its own.
+func explode(s string) []string {
+	result := make([]string, 0, utf8.RuneCountInString(s))
+	for s != "" {
+		_, size := utf8.DecodeRuneInString(s)
+		result = append(result, s[:size])
+		s = s[size:]
+	}
+	return result
+}
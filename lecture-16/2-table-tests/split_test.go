@@ -2,6 +2,7 @@ package table_tests
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -33,6 +34,7 @@ func TestSplit(t *testing.T) {
 		{input: "a/b/c", sep: "/", want: []string{"a", "b", "c"}},
 		{input: "a/b/c", sep: ",", want: []string{"a/b/c"}},
 		{input: "abc", sep: "/", want: []string{"abc"}},
+		{input: "abc", sep: "", want: []string{"a", "b", "c"}},
 	}
 
 	for _, tc := range tests {
@@ -42,3 +44,38 @@ func TestSplit(t *testing.T) {
 		}
 	}
 }
+
+// Фаззинг: вместо набора примеров проверяем свойства, которые верны
+// для любых входных данных. Запуск: go test -fuzz=FuzzSplit
+// Найденные падения сохраняются в testdata/fuzz/FuzzSplit и дальше
+// прогоняются обычным go test как регрессионные тесты.
+func FuzzSplit(f *testing.F) {
+	f.Add("a/b/c", "/")
+	f.Add("a/b/c", ",")
+	f.Add("", "/")
+	f.Add("a//b/", "/")
+	f.Add("aaaa", "aa")
+	f.Add("привет, мир", "")
+
+	f.Fuzz(func(t *testing.T, s, sep string) {
+		got := Split(s, sep)
+
+		// Склеивание обратно даёт исходную строку.
+		if joined := strings.Join(got, sep); joined != s {
+			t.Fatalf("Join(Split(%q, %q)) = %q", s, sep, joined)
+		}
+		if sep == "" {
+			return
+		}
+		// Разделителей столько же, сколько находит strings.Count,
+		// и ни одна часть его не содержит.
+		if want := strings.Count(s, sep) + 1; len(got) != want {
+			t.Fatalf("Split(%q, %q) has %d parts, want %d", s, sep, len(got), want)
+		}
+		for _, part := range got {
+			if strings.Contains(part, sep) {
+				t.Fatalf("Split(%q, %q): part %q contains the separator", s, sep, part)
+			}
+		}
+	})
+}
@@ -0,0 +1,150 @@
+// auth_handler.go
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type registerRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type userResponse struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// NewAuthHandler returns routes for registration, login, token refresh and logout:
+//
+//	POST /register {name, email, password}
+//	POST /login    {email, password}
+//	POST /refresh  {refresh_token}
+//	POST /logout   {refresh_token}
+func NewAuthHandler(users *UserService, auth *AuthService) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /register", func(w http.ResponseWriter, r *http.Request) {
+		var req registerRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Email == "" {
+			writeError(w, http.StatusBadRequest, "email is required")
+			return
+		}
+
+		user, err := users.RegisterUser(r.Context(), req.Name, req.Email, req.Password)
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, userResponse{ID: user.ID, Name: user.Name, Email: user.Email})
+	})
+
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		var req loginRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		pair, err := auth.Login(r.Context(), req.Email, req.Password)
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newTokenResponse(pair))
+	})
+
+	mux.HandleFunc("POST /refresh", func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		pair, err := auth.Refresh(r.Context(), req.RefreshToken)
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newTokenResponse(pair))
+	})
+
+	mux.HandleFunc("POST /logout", func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		if err := auth.Logout(r.Context(), req.RefreshToken); err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func newTokenResponse(pair *TokenPair) tokenResponse {
+	return tokenResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(pair.ExpiresIn.Seconds()),
+	}
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrEmailTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrWeakPassword), errors.Is(err, ErrPasswordTooLong):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrInvalidCredentials),
+		errors.Is(err, ErrInvalidToken),
+		errors.Is(err, ErrTokenExpired),
+		errors.Is(err, ErrTokenRevoked):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
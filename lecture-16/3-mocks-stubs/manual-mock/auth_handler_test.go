@@ -0,0 +1,57 @@
+// auth_handler_test.go
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doJSON(t *testing.T, h http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthHandler(t *testing.T) {
+	f := newAuthFixture()
+	h := NewAuthHandler(f.users, f.auth)
+
+	rec := doJSON(t, h, "/register", `{"name":"John","email":"john@test.com","password":"password123"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "password")
+
+	rec = doJSON(t, h, "/register", `{"name":"John","email":"john@test.com","password":"password123"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doJSON(t, h, "/register", `{"name":"Jane","email":"jane@test.com","password":"`+strings.Repeat("p", 73)+`"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "bcrypt cannot hash more than 72 bytes")
+
+	rec = doJSON(t, h, "/login", `{"email":"john@test.com","password":"wrong-password"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doJSON(t, h, "/login", `{"email":"john@test.com","password":"password123"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tokens tokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokens))
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, 15*60, tokens.ExpiresIn)
+
+	rec = doJSON(t, h, "/refresh", `{"refresh_token":"`+tokens.RefreshToken+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doJSON(t, h, "/refresh", `{"refresh_token":"`+tokens.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doJSON(t, h, "/login", `{`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,159 @@
+// auth_service.go
+package user
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const DefaultRefreshTTL = 30 * 24 * time.Hour
+
+type AuthService struct {
+	users      UserRepository
+	tokens     TokenRepository
+	hasher     PasswordHasher
+	issuer     *TokenIssuer
+	refreshTTL time.Duration
+	now        func() time.Time
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+func NewAuthService(
+	users UserRepository,
+	tokens TokenRepository,
+	hasher PasswordHasher,
+	issuer *TokenIssuer,
+) *AuthService {
+	return &AuthService{
+		users:      users,
+		tokens:     tokens,
+		hasher:     hasher,
+		issuer:     issuer,
+		refreshTTL: DefaultRefreshTTL,
+		now:        time.Now,
+	}
+}
+
+// Login проверяет пароль и выдаёт новую пару токенов.
+// Для неизвестного email и неверного пароля возвращается одна и та же ошибка,
+// и отвечает Login одинаково долго: иначе по времени ответа можно узнать,
+// какие email зарегистрированы.
+func (s *AuthService) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	user, err := s.users.GetByEmail(ctx, NormalizeEmail(email))
+	if errors.Is(err, ErrUserNotFound) {
+		s.hasher.Compare(s.dummy(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.hasher.Compare(user.PasswordHash, password); err != nil {
+		return nil, err
+	}
+
+	refresh, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, refresh)
+}
+
+// Refresh обменивает refresh-токен на новую пару (ротация).
+// Повторное предъявление уже использованного токена считается утечкой:
+// все токены пользователя отзываются.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	now := s.now()
+
+	stored, err := s.tokens.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.Revoked() {
+		if err := s.tokens.RevokeUserTokens(ctx, stored.UserID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenRevoked
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		// Пользователь удалён: его токены больше ничего не значат.
+		if err := s.tokens.RevokeUserTokens(ctx, stored.UserID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Отзыв до выпуска новой пары: из двух параллельных Refresh
+	// с одним токеном успешным будет только один.
+	err = s.tokens.RevokeRefreshToken(ctx, stored.Hash, hashToken(next), now)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, next)
+}
+
+// dummy возвращает хеш, с которым Login сравнивает пароль неизвестного
+// пользователя. Хеш считается тем же hasher, поэтому сравнение занимает
+// столько же, сколько для настоящего пользователя.
+func (s *AuthService) dummy() string {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash("dummy password for unknown users")
+	})
+	return s.dummyHash
+}
+
+// Logout отзывает refresh-токен. Неизвестный токен не считается ошибкой.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	err := s.tokens.RevokeRefreshToken(ctx, hashToken(refreshToken), "", s.now())
+	if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) {
+		return nil
+	}
+	return err
+}
+
+// Authenticate проверяет access-токен
+func (s *AuthService) Authenticate(accessToken string) (*Claims, error) {
+	return s.issuer.Parse(accessToken, s.now())
+}
+
+func (s *AuthService) issue(ctx context.Context, user *User, refresh string) (*TokenPair, error) {
+	now := s.now()
+
+	access, err := s.issuer.Issue(user, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokens.SaveRefreshToken(ctx, &RefreshToken{
+		Hash:      hashToken(refresh),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    s.issuer.ttl,
+	}, nil
+}
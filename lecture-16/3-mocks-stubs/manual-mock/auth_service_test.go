@@ -0,0 +1,237 @@
+// auth_service_test.go
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type authFixture struct {
+	repo    *InMemoryRepository
+	users   *UserService
+	auth    *AuthService
+	nowTime time.Time
+}
+
+func newAuthFixture() *authFixture {
+	f := &authFixture{
+		repo:    NewInMemoryRepository(),
+		nowTime: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+	f.users = NewUserServiceWithHasher(f.repo, hasher)
+	f.auth = NewAuthService(f.repo, f.repo, hasher, NewTokenIssuer([]byte("secret"), 15*time.Minute))
+	f.auth.now = func() time.Time { return f.nowTime }
+	return f
+}
+
+func TestUserService_RegisterUser_Password(t *testing.T) {
+	t.Run("password is hashed", func(t *testing.T) {
+		f := newAuthFixture()
+
+		user, err := f.users.RegisterUser(context.Background(), "John", "John@Test.com ", "password123")
+		require.NoError(t, err)
+
+		assert.Equal(t, "john@test.com", user.Email)
+		assert.NotEqual(t, "password123", user.PasswordHash)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("password123")))
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		f := newAuthFixture()
+
+		_, err := f.users.RegisterUser(context.Background(), "John", "john@test.com", "short")
+		assert.ErrorIs(t, err, ErrWeakPassword)
+	})
+
+	t.Run("password too long for bcrypt", func(t *testing.T) {
+		f := newAuthFixture()
+
+		_, err := f.users.RegisterUser(context.Background(), "John", "john@test.com", strings.Repeat("p", MaxPasswordLength+1))
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+
+		_, err = f.users.RegisterUser(context.Background(), "John", "john@test.com", strings.Repeat("p", MaxPasswordLength))
+		assert.NoError(t, err)
+	})
+
+	t.Run("email is unique", func(t *testing.T) {
+		f := newAuthFixture()
+
+		_, err := f.users.RegisterUser(context.Background(), "John", "john@test.com", "password123")
+		require.NoError(t, err)
+
+		_, err = f.users.RegisterUser(context.Background(), "Johnny", "JOHN@test.com", "password456")
+		assert.ErrorIs(t, err, ErrEmailTaken)
+	})
+}
+
+func TestAuthService_Login(t *testing.T) {
+	f := newAuthFixture()
+	ctx := context.Background()
+
+	registered, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		pair, err := f.auth.Login(ctx, "john@test.com", "password123")
+		require.NoError(t, err)
+		assert.NotEmpty(t, pair.RefreshToken)
+
+		claims, err := f.auth.Authenticate(pair.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, registered.ID, claims.UserID)
+		assert.Equal(t, "john@test.com", claims.Email)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := f.auth.Login(ctx, "john@test.com", "password124")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		_, err := f.auth.Login(ctx, "nobody@test.com", "password123")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("unknown email still compares a hash", func(t *testing.T) {
+		hasher := &countingHasher{PasswordHasher: NewBcryptHasher(bcrypt.MinCost)}
+		auth := NewAuthService(f.repo, f.repo, hasher, NewTokenIssuer([]byte("secret"), time.Minute))
+
+		_, err := auth.Login(ctx, "nobody@test.com", "password123")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		_, err = auth.Login(ctx, "john@test.com", "password124")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Equal(t, 2, hasher.compares, "both failures cost one bcrypt comparison")
+	})
+}
+
+// countingHasher считает сравнения, чтобы проверить, что Login
+// тратит одинаковое время на известный и неизвестный email.
+type countingHasher struct {
+	PasswordHasher
+	compares int
+}
+
+func (h *countingHasher) Compare(hash, password string) error {
+	h.compares++
+	return h.PasswordHasher.Compare(hash, password)
+}
+
+func TestAuthService_AccessTokenExpires(t *testing.T) {
+	f := newAuthFixture()
+	ctx := context.Background()
+
+	_, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+	require.NoError(t, err)
+	pair, err := f.auth.Login(ctx, "john@test.com", "password123")
+	require.NoError(t, err)
+
+	f.nowTime = f.nowTime.Add(16 * time.Minute)
+
+	_, err = f.auth.Authenticate(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = f.auth.Authenticate(pair.AccessToken + "x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	t.Run("rotation", func(t *testing.T) {
+		f := newAuthFixture()
+		ctx := context.Background()
+
+		_, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+		require.NoError(t, err)
+		first, err := f.auth.Login(ctx, "john@test.com", "password123")
+		require.NoError(t, err)
+
+		second, err := f.auth.Refresh(ctx, first.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		stored, err := f.repo.GetRefreshToken(ctx, hashToken(first.RefreshToken))
+		require.NoError(t, err)
+		assert.True(t, stored.Revoked())
+		assert.Equal(t, hashToken(second.RefreshToken), stored.ReplacedBy)
+	})
+
+	t.Run("reuse revokes all user tokens", func(t *testing.T) {
+		f := newAuthFixture()
+		ctx := context.Background()
+
+		_, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+		require.NoError(t, err)
+		first, err := f.auth.Login(ctx, "john@test.com", "password123")
+		require.NoError(t, err)
+		second, err := f.auth.Refresh(ctx, first.RefreshToken)
+		require.NoError(t, err)
+
+		_, err = f.auth.Refresh(ctx, first.RefreshToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		_, err = f.auth.Refresh(ctx, second.RefreshToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		f := newAuthFixture()
+		ctx := context.Background()
+
+		_, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+		require.NoError(t, err)
+		pair, err := f.auth.Login(ctx, "john@test.com", "password123")
+		require.NoError(t, err)
+
+		f.nowTime = f.nowTime.Add(DefaultRefreshTTL)
+
+		_, err = f.auth.Refresh(ctx, pair.RefreshToken)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		f := newAuthFixture()
+		ctx := context.Background()
+
+		user, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+		require.NoError(t, err)
+		pair, err := f.auth.Login(ctx, "john@test.com", "password123")
+		require.NoError(t, err)
+		require.NoError(t, f.repo.Delete(ctx, user.ID))
+
+		_, err = f.auth.Refresh(ctx, pair.RefreshToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		stored, err := f.repo.GetRefreshToken(ctx, hashToken(pair.RefreshToken))
+		require.NoError(t, err)
+		assert.True(t, stored.Revoked(), "the deleted user's tokens are revoked")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		f := newAuthFixture()
+
+		_, err := f.auth.Refresh(context.Background(), "garbage")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		f := newAuthFixture()
+		ctx := context.Background()
+
+		_, err := f.users.RegisterUser(ctx, "John", "john@test.com", "password123")
+		require.NoError(t, err)
+		pair, err := f.auth.Login(ctx, "john@test.com", "password123")
+		require.NoError(t, err)
+
+		require.NoError(t, f.auth.Logout(ctx, pair.RefreshToken))
+		require.NoError(t, f.auth.Logout(ctx, pair.RefreshToken))
+
+		_, err = f.auth.Refresh(ctx, pair.RefreshToken)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+}
@@ -0,0 +1,77 @@
+// contract_test.go
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runUserRepositoryContract проверяет поведение, общее для всех
+// реализаций UserRepository: и настоящих, и ручного мока. Иначе тесты
+// с моком проходят там, где настоящее хранилище вернуло бы ошибку.
+func runUserRepositoryContract(t *testing.T, newRepo func() UserRepository) {
+	ctx := context.Background()
+
+	t.Run("missing user", func(t *testing.T) {
+		repo := newRepo()
+		_, err := repo.GetByID(ctx, 404)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		_, err = repo.GetByEmail(ctx, "nobody@test.com")
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("create and get", func(t *testing.T) {
+		repo := newRepo()
+		alice := &User{Name: "Alice", Email: "alice@test.com"}
+		bob := &User{Name: "Bob", Email: "bob@test.com"}
+		require.NoError(t, repo.Create(ctx, alice))
+		require.NoError(t, repo.Create(ctx, bob))
+		assert.Positive(t, alice.ID)
+		assert.Greater(t, bob.ID, alice.ID)
+
+		got, err := repo.GetByID(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, alice, got)
+
+		got.Name = "changed"
+		again, err := repo.GetByID(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", again.Name, "возвращается копия")
+
+		got, err = repo.GetByEmail(ctx, " ALICE@test.com")
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, got.ID)
+	})
+
+	t.Run("email already taken", func(t *testing.T) {
+		repo := newRepo()
+		require.NoError(t, repo.Create(ctx, &User{Name: "Alice", Email: "alice@test.com"}))
+
+		dup := &User{Name: "Impostor", Email: "Alice@Test.com"}
+		assert.ErrorIs(t, repo.Create(ctx, dup), ErrEmailTaken)
+		assert.Zero(t, dup.ID, "неудачный Create не трогает пользователя")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo := newRepo()
+		alice := &User{Name: "Alice", Email: "alice@test.com"}
+		require.NoError(t, repo.Create(ctx, alice))
+		require.NoError(t, repo.Delete(ctx, alice.ID))
+
+		_, err := repo.GetByID(ctx, alice.ID)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		require.NoError(t, repo.Create(ctx, &User{Name: "Alice", Email: "alice@test.com"}),
+			"email освобождается")
+	})
+}
+
+func TestInMemoryRepository_Contract(t *testing.T) {
+	runUserRepositoryContract(t, func() UserRepository { return NewInMemoryRepository() })
+}
+
+func TestManualUserRepository_Contract(t *testing.T) {
+	runUserRepositoryContract(t, func() UserRepository { return NewManualUserRepository() })
+}
@@ -0,0 +1,132 @@
+// memory_repository.go
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemoryRepository - потокобезопасная реализация UserRepository и
+// TokenRepository. В отличие от моков, она сама следит за уникальностью email.
+type InMemoryRepository struct {
+	mu      sync.RWMutex
+	users   map[int]*User
+	byEmail map[string]int
+	tokens  map[string]*RefreshToken
+	nextID  int
+}
+
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		users:   make(map[int]*User),
+		byEmail: make(map[string]int),
+		tokens:  make(map[string]*RefreshToken),
+		nextID:  1,
+	}
+}
+
+func (r *InMemoryRepository) Create(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email := NormalizeEmail(user.Email)
+	if _, exists := r.byEmail[email]; exists {
+		return ErrEmailTaken
+	}
+
+	user.ID = r.nextID
+	r.nextID++
+
+	stored := *user
+	stored.Email = email
+	r.users[user.ID] = &stored
+	r.byEmail[email] = user.ID
+	return nil
+}
+
+func (r *InMemoryRepository) GetByID(ctx context.Context, id int) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	u := *user
+	return &u, nil
+}
+
+func (r *InMemoryRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.byEmail[NormalizeEmail(email)]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	u := *r.users[id]
+	return &u, nil
+}
+
+func (r *InMemoryRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil
+	}
+	delete(r.byEmail, user.Email)
+	delete(r.users, id)
+	return nil
+}
+
+func (r *InMemoryRepository) SaveRefreshToken(ctx context.Context, token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := *token
+	r.tokens[token.Hash] = &t
+	return nil
+}
+
+func (r *InMemoryRepository) GetRefreshToken(ctx context.Context, hash string) (*RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, exists := r.tokens[hash]
+	if !exists {
+		return nil, ErrInvalidToken
+	}
+	t := *token
+	return &t, nil
+}
+
+func (r *InMemoryRepository) RevokeRefreshToken(ctx context.Context, hash, replacedBy string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, exists := r.tokens[hash]
+	if !exists {
+		return ErrInvalidToken
+	}
+	if token.Revoked() {
+		return ErrTokenRevoked
+	}
+	token.RevokedAt = at
+	token.ReplacedBy = replacedBy
+	return nil
+}
+
+func (r *InMemoryRepository) RevokeUserTokens(ctx context.Context, userID int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && !token.Revoked() {
+			token.RevokedAt = at
+		}
+	}
+	return nil
+}
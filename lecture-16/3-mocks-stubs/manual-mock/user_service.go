@@ -1,32 +1,72 @@
 // user_service.go
 package user
 
-import "context"
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailTaken         = errors.New("email already registered")
+	ErrWeakPassword       = errors.New("password is too short")
+	ErrPasswordTooLong    = errors.New("password is too long")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+// MinPasswordLength - минимальная длина пароля при регистрации
+const MinPasswordLength = 8
+
+// MaxPasswordLength - максимальная длина пароля в байтах: bcrypt
+// не принимает пароли длиннее 72 байт.
+const MaxPasswordLength = 72
 
 type User struct {
-	ID    int
-	Name  string
-	Email string
+	ID           int
+	Name         string
+	Email        string
+	PasswordHash string
 }
 
-// UserRepository - интерфейс, который мы будем мокать
+// UserRepository - интерфейс, который мы будем мокать.
+// Create должен возвращать ErrEmailTaken, если email уже занят,
+// GetByEmail - ErrUserNotFound, если пользователя нет.
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id int) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
 	Delete(ctx context.Context, id int) error
 }
 
 type UserService struct {
-	repo UserRepository
+	repo   UserRepository
+	hasher PasswordHasher
 }
 
 func NewUserService(repo UserRepository) *UserService {
-	return &UserService{repo: repo}
+	return NewUserServiceWithHasher(repo, NewBcryptHasher(0))
 }
 
-func (s *UserService) RegisterUser(ctx context.Context, name, email string) (*User, error) {
-	user := &User{Name: name, Email: email}
-	err := s.repo.Create(ctx, user)
+func NewUserServiceWithHasher(repo UserRepository, hasher PasswordHasher) *UserService {
+	return &UserService{repo: repo, hasher: hasher}
+}
+
+func (s *UserService) RegisterUser(ctx context.Context, name, email, password string) (*User, error) {
+	if len(password) < MinPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	if len(password) > MaxPasswordLength {
+		return nil, ErrPasswordTooLong
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &User{Name: name, Email: NormalizeEmail(email), PasswordHash: hash}
+	err = s.repo.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
@@ -36,3 +76,8 @@ func (s *UserService) RegisterUser(ctx context.Context, name, email string) (*Us
 func (s *UserService) GetUser(ctx context.Context, id int) (*User, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+// NormalizeEmail приводит email к виду, в котором он хранится в репозитории
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
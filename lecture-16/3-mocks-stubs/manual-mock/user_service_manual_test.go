@@ -35,8 +35,15 @@ func (m *ManualUserRepository) Create(ctx context.Context, user *User) error {
 		return m.createErr
 	}
 
+	// Как и настоящее хранилище, мок не пускает второй такой же email
+	if _, err := m.GetByEmail(ctx, user.Email); err == nil {
+		return ErrEmailTaken
+	}
+
 	user.ID = m.nextID
-	m.users[user.ID] = user
+	stored := *user
+	stored.Email = NormalizeEmail(user.Email)
+	m.users[user.ID] = &stored
 	m.nextID++
 	return nil
 }
@@ -50,15 +57,23 @@ func (m *ManualUserRepository) GetByID(ctx context.Context, id int) (*User, erro
 
 	user, exists := m.users[id]
 	if !exists {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	// Возвращаем копию чтобы избежать модификации
-	return &User{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	u := *user
+	return &u, nil
+}
+
+func (m *ManualUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	email = NormalizeEmail(email)
+	for _, user := range m.users {
+		if user.Email == email {
+			u := *user
+			return &u, nil
+		}
+	}
+	return nil, ErrUserNotFound
 }
 
 func (m *ManualUserRepository) Delete(ctx context.Context, id int) error {
@@ -89,7 +104,7 @@ func TestUserService_ManualMock(t *testing.T) {
 		mockRepo := NewManualUserRepository()
 		service := NewUserService(mockRepo)
 
-		user, err := service.RegisterUser(context.Background(), "John", "john@test.com")
+		user, err := service.RegisterUser(context.Background(), "John", "john@test.com", "password123")
 
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
@@ -113,7 +128,7 @@ func TestUserService_ManualMock(t *testing.T) {
 			WithCreateError(errors.New("database error"))
 		service := NewUserService(mockRepo)
 
-		user, err := service.RegisterUser(context.Background(), "John", "john@test.com")
+		user, err := service.RegisterUser(context.Background(), "John", "john@test.com", "password123")
 
 		if err == nil {
 			t.Error("Expected error, got nil")
@@ -128,7 +143,7 @@ func TestUserService_ManualMock(t *testing.T) {
 		service := NewUserService(mockRepo)
 
 		// Сначала создаем пользователя
-		createdUser, _ := service.RegisterUser(context.Background(), "Jane", "jane@test.com")
+		createdUser, _ := service.RegisterUser(context.Background(), "Jane", "jane@test.com", "password123")
 
 		// Затем получаем его
 		foundUser, err := service.GetUser(context.Background(), createdUser.ID)
@@ -22,7 +22,14 @@ func (m *TestifyUserRepository) Create(ctx context.Context, user *User) error {
 
 func (m *TestifyUserRepository) GetByID(ctx context.Context, id int) (*User, error) {
 	args := m.Called(ctx, id)
-	return args.Get(0).(*User), args.Error(1)
+	user, _ := args.Get(0).(*User) // nil, если настроен только возврат ошибки
+	return user, args.Error(1)
+}
+
+func (m *TestifyUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	args := m.Called(ctx, email)
+	user, _ := args.Get(0).(*User)
+	return user, args.Error(1)
 }
 
 func (m *TestifyUserRepository) Delete(ctx context.Context, id int) error {
@@ -44,7 +51,7 @@ func TestUserService_TestifyMock(t *testing.T) {
 				user.ID = 1 // Симулируем присвоение ID
 			})
 
-		user, err := service.RegisterUser(context.Background(), "John", "john@test.com")
+		user, err := service.RegisterUser(context.Background(), "John", "john@test.com", "password123")
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, user.ID)
@@ -62,7 +69,7 @@ func TestUserService_TestifyMock(t *testing.T) {
 			Return(errors.New("database error"))
 
 		user, err := service.
-			RegisterUser(context.Background(), "John", "john@test.com")
+			RegisterUser(context.Background(), "John", "john@test.com", "password123")
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -94,12 +101,28 @@ func TestUserService_TestifyMock(t *testing.T) {
 		mockRepo := new(TestifyUserRepository)
 		service := NewUserService(mockRepo)
 
+		// Мок возвращает ту же ошибку, что и настоящее хранилище
 		mockRepo.On("GetByID", mock.Anything, 999).
-			Return((*User)(nil), errors.New("user not found"))
+			Return(nil, ErrUserNotFound)
 
 		user, err := service.GetUser(context.Background(), 999)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		assert.Nil(t, user)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("email already taken", func(t *testing.T) {
+		mockRepo := new(TestifyUserRepository)
+		service := NewUserService(mockRepo)
+
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*user.User")).
+			Return(ErrEmailTaken)
+
+		user, err := service.RegisterUser(context.Background(), "John", "JOHN@test.com", "password123")
+
+		assert.ErrorIs(t, err, ErrEmailTaken)
 		assert.Nil(t, user)
 
 		mockRepo.AssertExpectations(t)
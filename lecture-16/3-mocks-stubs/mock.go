@@ -1,14 +1,26 @@
 package mocks_stubs
 
-import "github.com/stretchr/testify/mock"
+import (
+	"errors"
 
-type User struct{}
+	"github.com/stretchr/testify/mock"
+)
+
+// ErrUserNotFound - ошибка настоящего хранилища для несуществующего id.
+// Моки и стабы возвращают её же, а не nil-пользователя без ошибки.
+var ErrUserNotFound = errors.New("user not found")
+
+type User struct {
+	ID   int
+	Name string
+}
 
 type UserRepositoryMock struct {
 	mock.Mock
 }
 
 func (u *UserRepositoryMock) GetUser(id int) (*User, error) {
-	args := u.Called(id)                      // Фиксируем факт вызова
-	return args.Get(0).(*User), args.Error(1) // Возвращаем то, что настроили в тесте
+	args := u.Called(id)           // Фиксируем факт вызова
+	user, _ := args.Get(0).(*User) // nil, если в тесте настроена только ошибка
+	return user, args.Error(1)     // Возвращаем то, что настроили в тесте
 }
@@ -3,5 +3,8 @@ package mocks_stubs
 type UserRepositoryStub struct{}
 
 func (u *UserRepositoryStub) GetUser(id int) (*User, error) {
+	if id != 1 {
+		return nil, ErrUserNotFound // Как и настоящее хранилище, не выдумывает чужих пользователей
+	}
 	return &User{ID: 1, Name: "Test User"}, nil // Всегда возвращает одного и того же пользователя
 }
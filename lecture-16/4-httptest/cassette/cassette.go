@@ -0,0 +1,321 @@
+// Package cassette records HTTP interactions to a file and replays them,
+// so clients can be tested offline, deterministically and without touching
+// http.DefaultClient:
+//
+//	rec := cassette.Open(t, "user_info", cassette.Options{})
+//	client := &http.Client{Transport: rec}
+//
+// Tests replay testdata/cassettes/<name>.json; run them with -record to
+// call the real services and rewrite the files. Latency and failures can be
+// injected on top of either mode.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+var record = flag.Bool("record", false, "call real services and rewrite cassettes")
+
+// ErrNoMatch is returned in replay mode for a request that matches no
+// recorded interaction.
+var ErrNoMatch = errors.New("cassette: no recorded interaction matches the request")
+
+type Mode int
+
+const (
+	// ModeReplay serves recorded responses and never goes to the network.
+	ModeReplay Mode = iota
+	// ModeRecord passes requests to Options.Transport and records them.
+	ModeRecord
+)
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Options configures a Recorder. Zero fields take the defaults noted below.
+type Options struct {
+	Mode Mode
+	// Transport makes the real calls in ModeRecord. Default
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// Match decides which recorded interaction answers a request.
+	// Default MatchMethod and MatchURL.
+	Match Matcher
+	// Redact lists request and response headers that are not written to
+	// the file. Default Authorization, Cookie and Set-Cookie.
+	Redact []string
+	// Latency delays every response. The wait ends early with the
+	// request context's error, so client timeouts can be tested.
+	Latency time.Duration
+	// Fail, if set, sees every request first; a non-nil error is returned
+	// in place of the response and nothing is recorded.
+	Fail func(req *http.Request) error
+	// Clock times Latency. Default clock.Real().
+	Clock clock.Clock
+}
+
+func (o *Options) setDefaults() {
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+	if o.Match == nil {
+		o.Match = Match(MatchMethod, MatchURL)
+	}
+	if o.Redact == nil {
+		o.Redact = []string{"Authorization", "Cookie", "Set-Cookie"}
+	}
+	if o.Clock == nil {
+		o.Clock = clock.Real()
+	}
+}
+
+// Recorder is an http.RoundTripper; it is safe for concurrent use.
+type Recorder struct {
+	path string
+	opts Options
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New loads the cassette at path for replay, or starts an empty one in
+// ModeRecord.
+func New(path string, opts Options) (*Recorder, error) {
+	opts.setDefaults()
+	r := &Recorder{path: path, opts: opts}
+	if opts.Mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// Open is New for tests: the cassette is testdata/cassettes/<name>.json,
+// the -record flag selects ModeRecord, and a recording is saved when the
+// test ends. A recording with no interactions, e.g. from a test whose
+// requests all timed out, leaves the file as it was.
+func Open(tb testing.TB, name string, opts Options) *Recorder {
+	tb.Helper()
+	if *record {
+		opts.Mode = ModeRecord
+	}
+	path := filepath.Join("testdata", "cassettes", filepath.FromSlash(name)+".json")
+	r, err := New(path, opts)
+	if errors.Is(err, os.ErrNotExist) {
+		tb.Fatalf("cassette: %s does not exist; run the test with -record to create it", path)
+	}
+	if err != nil {
+		tb.Fatalf("cassette: %v", err)
+	}
+	if r.opts.Mode == ModeRecord {
+		tb.Cleanup(func() {
+			if len(r.Interactions()) == 0 {
+				tb.Logf("cassette: nothing recorded, %s is left as is", path)
+				return
+			}
+			if err := r.Save(); err != nil {
+				tb.Errorf("cassette: %v", err)
+			}
+		})
+	}
+	return r
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Interactions returns a copy of the recorded or loaded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.Fail != nil {
+		if err := r.opts.Fail(req); err != nil {
+			return nil, err
+		}
+	}
+	if err := clock.SleepContext(req.Context(), r.opts.Clock, r.opts.Latency); err != nil {
+		return nil, err
+	}
+
+	if r.opts.Mode == ModeRecord {
+		return r.recordCall(req, body)
+	}
+	rec, ok := r.find(req, body)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, req.Method, req.URL)
+	}
+	return rec.Response.toHTTP(req), nil
+}
+
+// find returns the first unused interaction that matches. Once all matches
+// are used, the last one answers again, so a polled endpoint needs only
+// one recording.
+func (r *Recorder) find(req *http.Request, body []byte) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, in := range r.interactions {
+		if !r.opts.Match(req, body, in.Request) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return in, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return r.interactions[last], true
+}
+
+func (r *Recorder) recordCall(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.opts.Transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redact(req.Header),
+			Body:   string(body),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: r.redact(resp.Header),
+			Body:   string(respBody),
+		},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) redact(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range r.opts.Redact {
+		h.Del(name)
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
+
+func (resp Response) toHTTP(req *http.Request) *http.Response {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
+
+// readBody reads req's body and puts it back, so it can be matched and
+// still be sent.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// FailFirst fails the first n requests with err and lets the rest through,
+// like a service that recovers.
+func FailFirst(n int, err error) func(*http.Request) error {
+	var mu sync.Mutex
+	return func(*http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if n > 0 {
+			n--
+			return err
+		}
+		return nil
+	}
+}
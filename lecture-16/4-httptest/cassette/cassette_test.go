@@ -0,0 +1,215 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Set-Cookie", "session=secret")
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "hello "+r.URL.Query().Get("name"))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "greet.json")
+
+	rec, err := New(path, Options{Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/greet?name=alice", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if resp, err := client.Do(req); err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("first recorded call = %v, %v", resp, err)
+	}
+	if code, body := get(t, client, server.URL+"/greet?name=alice"); code != http.StatusOK || body != "hello alice" {
+		t.Fatalf("second recorded call = %d %q", code, body)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range rec.Interactions() {
+		if in.Request.Header.Get("Authorization") != "" || in.Response.Header.Get("Set-Cookie") != "" {
+			t.Errorf("secrets were recorded: %+v", in)
+		}
+	}
+	server.Close()
+
+	// Replay without the server: responses come back in recorded order,
+	// then the last one repeats.
+	replay, err := New(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replay}
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		if code, _ := get(t, client, server.URL+"/greet?name=alice"); code != want {
+			t.Errorf("replay %d: status %d, want %d", i, code, want)
+		}
+	}
+
+	_, err = client.Get(server.URL + "/greet?name=bob")
+	if !errors.Is(err, ErrNoMatch) {
+		t.Errorf("unrecorded request: err = %v, want ErrNoMatch", err)
+	}
+}
+
+func TestOpen_EmptyRecordingKeepsCassette(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := filepath.Join("testdata", "cassettes", "kept.json")
+	want := []byte(`[{"request":{"method":"GET","url":"https://example.com"},"response":{"status":200}}]`)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, want, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	*record = true
+	defer func() { *record = false }()
+	t.Run("record", func(t *testing.T) {
+		rec := Open(t, "kept", Options{Fail: func(*http.Request) error { return errors.New("down") }})
+		if _, err := (&http.Client{Transport: rec}).Get("https://example.com"); err == nil {
+			t.Fatal("injected failure was not returned")
+		}
+	})
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("cassette was overwritten with %s", got)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	rec := Request{
+		Method: http.MethodPost,
+		URL:    "http://127.0.0.1:1234/users?b=2&a=1",
+		Header: http.Header{"X-Tenant": {"acme"}},
+		Body:   `{"name":"Alice","age":30}`,
+	}
+	newReq := func(url, body, tenant string) (*http.Request, []byte) {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("X-Tenant", tenant)
+		return req, []byte(body)
+	}
+
+	tests := []struct {
+		name  string
+		match Matcher
+		url   string
+		body  string
+		want  bool
+	}{
+		{"query order", MatchURL, "http://127.0.0.1:1234/users?a=1&b=2", "", true},
+		{"other host", MatchURL, "http://127.0.0.1:5678/users?a=1&b=2", "", false},
+		{"path ignores host", MatchPath, "http://127.0.0.1:5678/users", "", true},
+		{"json body", MatchBody, "http://x/users", `{"age": 30, "name": "Alice"}`, true},
+		{"other body", MatchBody, "http://x/users", `{"name":"Bob"}`, false},
+		{"combined", Match(MatchMethod, MatchPath, MatchHeader("X-Tenant")), "http://x/users", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, body := newReq(tt.url, tt.body, "acme")
+			if got := tt.match(req, body, rec); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	req, body := newReq("http://x/users", "", "other")
+	if MatchHeader("X-Tenant")(req, body, rec) {
+		t.Error("MatchHeader matched a different tenant")
+	}
+}
+
+func replayer(t *testing.T, opts Options) *Recorder {
+	t.Helper()
+	opts.Match = MatchPath
+	r := &Recorder{opts: opts}
+	r.opts.setDefaults()
+	r.interactions = []Interaction{{
+		Request:  Request{Method: http.MethodGet, URL: "http://api/users/1"},
+		Response: Response{Status: http.StatusOK, Body: `{"name":"Alice"}`},
+	}}
+	r.used = make([]bool, 1)
+	return r
+}
+
+func TestLatency(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := &http.Client{Transport: replayer(t, Options{Latency: time.Second, Clock: fake})}
+
+	done := make(chan int)
+	go func() {
+		code, _ := get(t, client, "http://api/users/1")
+		done <- code
+	}()
+	fake.BlockUntil(1)
+	fake.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("response arrived before the latency passed")
+	default:
+	}
+	fake.Advance(time.Millisecond)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("status = %d", code)
+	}
+
+	// A client that gives up first sees its context error.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://api/users/1", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestFail(t *testing.T) {
+	errDown := errors.New("connection refused")
+	client := &http.Client{Transport: replayer(t, Options{Fail: FailFirst(2, errDown)})}
+
+	for range 2 {
+		if _, err := client.Get("http://api/users/1"); !errors.Is(err, errDown) {
+			t.Errorf("err = %v, want the injected error", err)
+		}
+	}
+	if code, body := get(t, client, "http://api/users/1"); code != http.StatusOK || body != `{"name":"Alice"}` {
+		t.Errorf("after failures: %d %q", code, body)
+	}
+}
@@ -0,0 +1,68 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// Matcher reports whether a live request, with its body already read,
+// matches a recorded one.
+type Matcher func(req *http.Request, body []byte, rec Request) bool
+
+// Match combines matchers; all of them have to agree.
+func Match(ms ...Matcher) Matcher {
+	return func(req *http.Request, body []byte, rec Request) bool {
+		for _, m := range ms {
+			if !m(req, body, rec) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func MatchMethod(req *http.Request, body []byte, rec Request) bool {
+	return req.Method == rec.Method
+}
+
+// MatchURL compares the whole URL, with query parameters in any order.
+func MatchURL(req *http.Request, body []byte, rec Request) bool {
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	return req.URL.Scheme == u.Scheme && req.URL.Host == u.Host && req.URL.Path == u.Path &&
+		reflect.DeepEqual(req.URL.Query(), u.Query())
+}
+
+// MatchPath ignores the host and the query, for recordings made against a
+// server whose address changes, such as httptest.Server.
+func MatchPath(req *http.Request, body []byte, rec Request) bool {
+	u, err := url.Parse(rec.URL)
+	return err == nil && req.URL.Path == u.Path
+}
+
+// MatchBody compares bodies byte for byte, or as values if both are JSON.
+func MatchBody(req *http.Request, body []byte, rec Request) bool {
+	if bytes.Equal(body, []byte(rec.Body)) {
+		return true
+	}
+	var a, b any
+	return json.Unmarshal(body, &a) == nil && json.Unmarshal([]byte(rec.Body), &b) == nil &&
+		reflect.DeepEqual(a, b)
+}
+
+// MatchHeader compares the given request headers.
+func MatchHeader(names ...string) Matcher {
+	return func(req *http.Request, body []byte, rec Request) bool {
+		for _, name := range names {
+			if !reflect.DeepEqual(req.Header.Values(name), rec.Header.Values(name)) {
+				return false
+			}
+		}
+		return true
+	}
+}
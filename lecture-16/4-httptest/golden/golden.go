@@ -0,0 +1,202 @@
+// Package golden compares HTTP responses with snapshots kept in testdata.
+// A snapshot holds the status line, selected headers and the body; JSON
+// bodies are pretty-printed with sorted keys and volatile values replaced
+// by placeholders, so the file stays stable from run to run.
+//
+// Run the tests with -update to write or rewrite the snapshots, and review
+// the change like any other diff:
+//
+//	go test ./... -run TestGreet -update
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files instead of comparing with them")
+
+// Placeholders that scrubbed values are replaced with.
+const (
+	ScrubbedTime = "<time>"
+	ScrubbedUUID = "<uuid>"
+	Scrubbed     = "<scrubbed>"
+)
+
+// Options configures a snapshot. Zero fields take the defaults noted below.
+type Options struct {
+	// Dir defaults to "testdata".
+	Dir string
+	// Headers are recorded besides Content-Type. Headers that change from
+	// run to run, such as Date, should not be listed.
+	Headers []string
+	// Scrub names JSON object keys whose values are replaced with
+	// Scrubbed wherever they occur, for volatile fields such as
+	// generated ids. RFC 3339 timestamps and UUIDs are always replaced.
+	Scrub []string
+}
+
+// AssertResponse compares resp with testdata/<name>.golden, reading and
+// closing its body. name may contain slashes, such as t.Name() of a subtest.
+func AssertResponse(t testing.TB, name string, resp *http.Response) {
+	t.Helper()
+	Options{}.AssertResponse(t, name, resp)
+}
+
+// AssertRecorder is AssertResponse for an httptest.ResponseRecorder.
+func AssertRecorder(t testing.TB, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	Options{}.AssertResponse(t, name, rec.Result())
+}
+
+func (o Options) AssertRecorder(t testing.TB, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	o.AssertResponse(t, name, rec.Result())
+}
+
+func (o Options) AssertResponse(t testing.TB, name string, resp *http.Response) {
+	t.Helper()
+	got, err := o.Snapshot(resp)
+	if err != nil {
+		t.Fatalf("golden: %v", err)
+	}
+
+	dir := o.Dir
+	if dir == "" {
+		dir = "testdata"
+	}
+	path := filepath.Join(dir, filepath.FromSlash(name)+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden: %s does not exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("golden: %v", err)
+	}
+	if string(want) != got {
+		t.Errorf("golden: response differs from %s (run with -update to accept it):\n%s", path, Diff(string(want), got, path))
+	}
+}
+
+// Snapshot renders resp the way it is stored in a golden file.
+func (o Options) Snapshot(resp *http.Response) (string, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HTTP %s\n", statusLine(resp))
+	headers := append([]string{"Content-Type"}, o.Headers...)
+	for _, h := range headers {
+		for _, v := range resp.Header.Values(h) {
+			fmt.Fprintf(&b, "%s: %s\n", http.CanonicalHeaderKey(h), v)
+		}
+	}
+	b.WriteString("\n")
+
+	if pretty, ok := o.prettyJSON(body); ok {
+		b.Write(pretty)
+	} else {
+		b.Write(body)
+	}
+	if s := b.String(); !strings.HasSuffix(s, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+func statusLine(resp *http.Response) string {
+	if resp.Status != "" {
+		return resp.Status
+	}
+	// Recorder results carry only the code.
+	return fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+}
+
+// prettyJSON reports false for bodies that are not JSON.
+func (o Options) prettyJSON(body []byte) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false) // keep placeholders such as <time> readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(o.scrub(v, "")); err != nil {
+		return nil, false
+	}
+	return out.Bytes(), true
+}
+
+var (
+	timeRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})$`)
+	uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+func (o Options) scrub(v any, key string) any {
+	if key != "" && slices.Contains(o.Scrub, key) {
+		return Scrubbed
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = o.scrub(child, k)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = o.scrub(child, "")
+		}
+	case string:
+		switch {
+		case timeRe.MatchString(v):
+			return ScrubbedTime
+		case uuidRe.MatchString(v):
+			return ScrubbedUUID
+		}
+	}
+	return v
+}
+
+// Diff returns a unified diff from want to got.
+func Diff(want, got, name string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(want),
+		B:        difflib.SplitLines(got),
+		FromFile: name,
+		ToFile:   "got",
+		Context:  3,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return diff
+}
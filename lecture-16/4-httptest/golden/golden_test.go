@@ -0,0 +1,119 @@
+package golden
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingTB captures failures instead of failing the real test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func jsonRecorder(body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.Header().Set("X-Request-Id", "abc")
+	rec.Header().Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	rec.WriteHeader(http.StatusCreated)
+	rec.WriteString(body)
+	return rec
+}
+
+func TestSnapshot(t *testing.T) {
+	rec := jsonRecorder(`{"user":{"id":17,"name":"Alice","created_at":"2025-01-02T03:04:05.123+03:00"},` +
+		`"trace":"6f1c2a2e-5b1d-4c8a-9f0e-2d3c4b5a6978","tags":["a","2025-01-02T03:04:05Z"],"html":"<b>"}`)
+
+	got, err := Options{Headers: []string{"X-Request-Id"}, Scrub: []string{"id"}}.Snapshot(rec.Result())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `HTTP 201 Created
+Content-Type: application/json
+X-Request-Id: abc
+
+{
+  "html": "<b>",
+  "tags": [
+    "a",
+    "<time>"
+  ],
+  "trace": "<uuid>",
+  "user": {
+    "created_at": "<time>",
+    "id": "<scrubbed>",
+    "name": "Alice"
+  }
+}
+`
+	if got != want {
+		t.Errorf("snapshot differs:\n%s", Diff(want, got, "want"))
+	}
+}
+
+func TestSnapshot_PlainText(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.WriteString("not json {")
+
+	got, err := Options{}.Snapshot(rec.Result())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "HTTP 200 OK\nContent-Type: text/plain; charset=utf-8\n\nnot json {\n"; got != want {
+		t.Errorf("snapshot = %q, want %q", got, want)
+	}
+}
+
+func TestAssertResponse(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir}
+
+	missing := &recordingTB{TB: t}
+	opts.AssertRecorder(missing, "sub/case", jsonRecorder(`{"name":"Alice"}`))
+	if !missing.fatal || !strings.Contains(missing.errors[0], "-update") {
+		t.Errorf("missing file: errors = %q, want a hint about -update", missing.errors)
+	}
+
+	*update = true
+	opts.AssertRecorder(t, "sub/case", jsonRecorder(`{"name":"Alice"}`))
+	*update = false
+	if _, err := os.Stat(filepath.Join(dir, "sub", "case.golden")); err != nil {
+		t.Fatalf("-update did not write the file: %v", err)
+	}
+
+	same := &recordingTB{TB: t}
+	opts.AssertRecorder(same, "sub/case", jsonRecorder(`{"name": "Alice"}`))
+	if len(same.errors) != 0 {
+		t.Errorf("matching response failed: %q", same.errors)
+	}
+
+	changed := &recordingTB{TB: t}
+	opts.AssertRecorder(changed, "sub/case", jsonRecorder(`{"name":"Bob"}`))
+	if len(changed.errors) != 1 {
+		t.Fatalf("changed response: errors = %q, want one", changed.errors)
+	}
+	for _, line := range []string{`-  "name": "Alice"`, `+  "name": "Bob"`} {
+		if !strings.Contains(changed.errors[0], line) {
+			t.Errorf("diff does not contain %q:\n%s", line, changed.errors[0])
+		}
+	}
+}
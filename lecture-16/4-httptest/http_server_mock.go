@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
+
+	"ITMO-students/lecture-16/4-httptest/resilient"
 )
 
 func GetUserInfo(apiURL string) (string, error) {
-	resp, err := http.Get(apiURL)
+	return FetchUserInfo(context.Background(), http.DefaultClient, apiURL)
+}
+
+// FetchUserInfo is GetUserInfo with an explicit client, so retries and
+// timeouts come from the client's transport (see NewResilientClient).
+func FetchUserInfo(ctx context.Context, client *http.Client, apiURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -24,3 +39,18 @@ func GetUserInfo(apiURL string) (string, error) {
 
 	return string(body), nil
 }
+
+// NewResilientClient retries transient failures, stops calling a host that
+// keeps failing and bounds each attempt by attemptTimeout.
+func NewResilientClient(attemptTimeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: resilient.Chain(http.DefaultTransport,
+			resilient.Retry(resilient.RetryPolicy{
+				MaxAttempts: 3,
+				Budget:      resilient.NewBudget(0.2, 10),
+			}),
+			resilient.CircuitBreaker(resilient.BreakerConfig{}),
+			resilient.Timeout(attemptTimeout),
+		),
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"ITMO-students/lecture-16/4-httptest/cassette"
+	"ITMO-students/lecture-16/4-httptest/resilient"
 )
 
 func TestGetUserInfo(t *testing.T) {
@@ -49,3 +55,67 @@ func TestGetUserInfo_Timeout(t *testing.T) {
 		t.Error("Expected timeout error, got nil")
 	}
 }
+
+func TestFetchUserInfo_CassetteTimeout(t *testing.T) {
+	// Ответ берётся из записанной кассеты и приходит через 2 секунды.
+	// Глобальный http.DefaultClient не трогаем: клиент с таймаутом
+	// передаётся в FetchUserInfo явно.
+	rec := cassette.Open(t, "user_info_timeout", cassette.Options{Latency: 2 * time.Second})
+	client := &http.Client{Transport: rec, Timeout: 50 * time.Millisecond}
+
+	_, err := FetchUserInfo(context.Background(), client, "https://api.example.com/users/1")
+	if err == nil {
+		t.Error("Expected timeout error, got nil")
+	}
+}
+
+func TestFetchUserInfo_Cassette(t *testing.T) {
+	rec := cassette.Open(t, "user_info", cassette.Options{})
+
+	result, err := FetchUserInfo(context.Background(), &http.Client{Transport: rec}, "https://api.example.com/users/1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != `{"name": "Alice", "age": 30}` {
+		t.Errorf("Unexpected body %q", result)
+	}
+}
+
+func TestFetchUserInfo_RetriesInjectedFailure(t *testing.T) {
+	// Первый запрос «падает» до сети, второй отвечает из кассеты.
+	rec := cassette.Open(t, "user_info_retry", cassette.Options{
+		Fail: cassette.FailFirst(1, errors.New("connection reset")),
+	})
+	client := &http.Client{Transport: resilient.Chain(rec, resilient.Retry(resilient.RetryPolicy{MaxAttempts: 2}))}
+
+	result, err := FetchUserInfo(context.Background(), client, "https://api.example.com/users/1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != `{"name": "Alice", "age": 30}` {
+		t.Errorf("Unexpected body %q", result)
+	}
+}
+
+func TestFetchUserInfo_RetriesUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"name": "Alice", "age": 30}`))
+	}))
+	defer server.Close()
+
+	result, err := FetchUserInfo(context.Background(), NewResilientClient(time.Second), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != `{"name": "Alice", "age": 30}` {
+		t.Errorf("Unexpected body %q", result)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,218 @@
+package resilient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// BreakerConfig configures CircuitBreaker. Zero fields take the defaults noted below.
+type BreakerConfig struct {
+	// FailureThreshold consecutive failures open the circuit. Default 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing. Default 30s.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many requests may probe at once in the
+	// half-open state; that many consecutive successes close the circuit.
+	// Default 1.
+	HalfOpenProbes int
+	// IsFailure classifies an attempt. Default: a transport error or a 5xx.
+	IsFailure func(*http.Response, error) bool
+	// OnStateChange, if set, is called with the breaker's lock released.
+	OnStateChange func(host string, from, to State)
+	// Clock defaults to clock.Real().
+	Clock clock.Clock
+}
+
+func (c *BreakerConfig) setDefaults() {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = 1
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= 500
+		}
+	}
+	if c.Clock == nil {
+		c.Clock = clock.Real()
+	}
+}
+
+// Breakers holds one circuit per request host. Requests to a host whose
+// circuit is open fail fast with an error wrapping ErrCircuitOpen.
+type Breakers struct {
+	cfg  BreakerConfig
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state     State
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+	// generation changes on every state transition so that results from
+	// requests admitted in an earlier state are ignored.
+	generation uint64
+}
+
+// CircuitBreaker returns a Middleware with a fresh set of per-host circuits.
+func CircuitBreaker(cfg BreakerConfig) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return NewBreakers(cfg, next)
+	}
+}
+
+// NewBreakers is CircuitBreaker for callers that want to inspect State.
+func NewBreakers(cfg BreakerConfig, next http.RoundTripper) *Breakers {
+	cfg.setDefaults()
+	return &Breakers{cfg: cfg, next: next, circuits: make(map[string]*circuit)}
+}
+
+// State reports the circuit state for host, as seen in req.URL.Host.
+func (b *Breakers) State(host string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[host]
+	if !ok {
+		return StateClosed
+	}
+	if c.state == StateOpen && !b.cfg.Clock.Now().Before(c.openedAt.Add(b.cfg.OpenTimeout)) {
+		return StateHalfOpen
+	}
+	return c.state
+}
+
+func (b *Breakers) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	gen, err := b.acquire(host)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.next.RoundTrip(req)
+	b.release(host, gen, b.cfg.IsFailure(resp, err))
+	return resp, err
+}
+
+func (b *Breakers) acquire(host string) (uint64, error) {
+	b.mu.Lock()
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{}
+		b.circuits[host] = c
+	}
+	from := c.state
+
+	switch c.state {
+	case StateOpen:
+		if b.cfg.Clock.Now().Before(c.openedAt.Add(b.cfg.OpenTimeout)) {
+			b.mu.Unlock()
+			return 0, fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		c.state = StateHalfOpen
+		c.successes = 0
+		c.inFlight = 0
+		c.generation++
+		fallthrough
+	case StateHalfOpen:
+		if c.inFlight >= b.cfg.HalfOpenProbes {
+			to := c.state
+			b.mu.Unlock()
+			b.notify(host, from, to)
+			return 0, fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		c.inFlight++
+	}
+	to, gen := c.state, c.generation
+	b.mu.Unlock()
+
+	b.notify(host, from, to)
+	return gen, nil
+}
+
+func (b *Breakers) release(host string, gen uint64, failed bool) {
+	b.mu.Lock()
+	c := b.circuits[host]
+	if c.generation != gen {
+		b.mu.Unlock()
+		return
+	}
+	from := c.state
+
+	switch c.state {
+	case StateClosed:
+		if !failed {
+			c.failures = 0
+			break
+		}
+		c.failures++
+		if c.failures >= b.cfg.FailureThreshold {
+			b.open(c)
+		}
+	case StateHalfOpen:
+		c.inFlight--
+		if failed {
+			b.open(c)
+			break
+		}
+		c.successes++
+		if c.successes >= b.cfg.HalfOpenProbes {
+			*c = circuit{state: StateClosed, generation: c.generation + 1}
+		}
+	}
+	to := c.state
+	b.mu.Unlock()
+
+	b.notify(host, from, to)
+}
+
+func (b *Breakers) open(c *circuit) {
+	c.state = StateOpen
+	c.openedAt = b.cfg.Clock.Now()
+	c.failures = 0
+	c.successes = 0
+	c.inFlight = 0
+	c.generation++
+}
+
+func (b *Breakers) notify(host string, from, to State) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(host, from, to)
+	}
+}
@@ -0,0 +1,44 @@
+package resilient
+
+import "sync"
+
+// Budget caps retries to a fraction of overall traffic, so that a
+// struggling backend is not hit with MaxAttempts times its normal load.
+// Every request deposits Ratio tokens and every retry spends one;
+// the balance never exceeds Max.
+type Budget struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewBudget allows on average ratio retries per request (e.g. 0.1 for 10%)
+// and at most max retries in a burst. The budget starts full.
+func NewBudget(ratio float64, max int) *Budget {
+	return &Budget{ratio: ratio, max: float64(max), tokens: float64(max)}
+}
+
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.ratio, b.max)
+	b.mu.Unlock()
+}
+
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Available reports how many retries can be made right now.
+func (b *Budget) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int(b.tokens)
+}
@@ -0,0 +1,53 @@
+// Package resilient provides http.RoundTripper middleware for outbound calls:
+// retries with backoff, per-host circuit breaking and per-attempt timeouts.
+//
+// Middleware compose with Chain; the first one listed is the outermost:
+//
+//	client := &http.Client{Transport: resilient.Chain(http.DefaultTransport,
+//		resilient.Retry(resilient.RetryPolicy{MaxAttempts: 4}),
+//		resilient.CircuitBreaker(resilient.BreakerConfig{}),
+//		resilient.Timeout(2*time.Second),
+//	)}
+package resilient
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// Chain wraps base with mws so that mws[0] sees the request first.
+// A nil base means http.DefaultTransport.
+func Chain(base http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		base = mws[i](base)
+	}
+	return base
+}
+
+// Sleeper waits between retries. Sleep returns early with ctx.Err()
+// when the context is done.
+type Sleeper interface {
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// ClockSleeper sleeps on Clock, so a *clock.Fake controls the waits too.
+type ClockSleeper struct {
+	Clock clock.Clock
+}
+
+func (s ClockSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	return clock.SleepContext(ctx, s.Clock, d)
+}
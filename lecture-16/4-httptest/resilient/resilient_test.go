@@ -0,0 +1,420 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+func newFakeClock() *clock.Fake {
+	return clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// spySleeper records requested delays and advances the clock instead of sleeping.
+type spySleeper struct {
+	clock     *clock.Fake
+	mu        sync.Mutex
+	durations []time.Duration
+}
+
+func (s *spySleeper) Sleep(ctx context.Context, d time.Duration) error {
+	s.mu.Lock()
+	s.durations = append(s.durations, d)
+	s.mu.Unlock()
+	s.clock.Advance(d)
+	return ctx.Err()
+}
+
+// scriptedServer answers with statuses in order, repeating the last one.
+func scriptedServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "2")
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newPolicy(clk *clock.Fake, sleeper *spySleeper) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Clock:       clk,
+		Sleeper:     sleeper,
+		Rand:        func() float64 { return 0.5 },
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		statuses  []int
+		wantCode  int
+		wantCalls int32
+		wantSleep []time.Duration
+	}{
+		{"success", http.MethodGet, []int{200}, 200, 1, nil},
+		{"503 then success", http.MethodGet, []int{503, 503, 200}, 200, 3,
+			[]time.Duration{50 * time.Millisecond, 100 * time.Millisecond}},
+		{"retry-after honored", http.MethodGet, []int{429, 200}, 200, 2,
+			[]time.Duration{2 * time.Second}},
+		{"gives up after max attempts", http.MethodGet, []int{503}, 503, 4,
+			[]time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond}},
+		{"500 is not retried", http.MethodGet, []int{500, 200}, 500, 1, nil},
+		{"post is not retried", http.MethodPost, []int{503, 200}, 503, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := scriptedServer(t, tt.statuses...)
+			clock := newFakeClock()
+			sleeper := &spySleeper{clock: clock}
+			policy := newPolicy(clock, sleeper)
+			policy.MaxRetryAfter = 5 * time.Second
+			client := &http.Client{Transport: Chain(nil, Retry(policy))}
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("payload"))
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			assert.Equal(t, "payload", string(body), "body must be replayed on every attempt")
+			assert.Equal(t, tt.wantCalls, calls.Load())
+			assert.Equal(t, tt.wantSleep, sleeper.durations)
+		})
+	}
+}
+
+// Without a Sleeper the policy waits on its Clock; the test releases each
+// wait once the request goroutine is parked on it.
+func TestRetry_WaitsOnClock(t *testing.T) {
+	srv, calls := scriptedServer(t, 503, 503, 200)
+	clk := newFakeClock()
+	policy := newPolicy(clk, nil)
+	policy.Sleeper = nil
+	client := &http.Client{Transport: Chain(nil, Retry(policy))}
+
+	done := make(chan int, 1)
+	go func() {
+		resp, err := client.Get(srv.URL)
+		if !assert.NoError(t, err) {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	for _, backoff := range []time.Duration{50 * time.Millisecond, 100 * time.Millisecond} {
+		clk.BlockUntil(1)
+		clk.Advance(backoff)
+	}
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_IdempotencyKey(t *testing.T) {
+	srv, calls := scriptedServer(t, 503, 201)
+	clock := newFakeClock()
+	client := &http.Client{Transport: Chain(nil, Retry(newPolicy(clock, &spySleeper{clock: clock})))}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("{}"))
+	req.Header.Set("Idempotency-Key", "abc")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetry_RetryAfterTooLong(t *testing.T) {
+	srv, calls := scriptedServer(t, 429, 200)
+	clock := newFakeClock()
+	policy := newPolicy(clock, &spySleeper{clock: clock})
+	policy.MaxRetryAfter = time.Second
+	client := &http.Client{Transport: Chain(nil, Retry(policy))}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetry_MaxElapsed(t *testing.T) {
+	srv, calls := scriptedServer(t, 503)
+	clock := newFakeClock()
+	policy := newPolicy(clock, &spySleeper{clock: clock})
+	policy.MaxAttempts = 10
+	policy.MaxElapsed = 200 * time.Millisecond
+	client := &http.Client{Transport: Chain(nil, Retry(policy))}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// 50ms + 100ms fit into the budget, the next 200ms wait does not.
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_Budget(t *testing.T) {
+	srv, calls := scriptedServer(t, 503)
+	clock := newFakeClock()
+	policy := newPolicy(clock, &spySleeper{clock: clock})
+	policy.Budget = NewBudget(0.5, 2)
+	client := &http.Client{Transport: Chain(nil, Retry(policy))}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	// Starts with 2 tokens, the request adds 0.5 (capped at 2): 2 retries.
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 0, policy.Budget.Available())
+
+	calls.Store(0)
+	resp, err = client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), calls.Load(), "exhausted budget allows no retries")
+}
+
+func TestRetry_TransportError(t *testing.T) {
+	var calls atomic.Int32
+	base := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if calls.Add(1) < 3 {
+			return nil, errors.New("connection reset")
+		}
+		return &http.Response{StatusCode: 200, Body: http.NoBody, Request: r}, nil
+	})
+	clock := newFakeClock()
+	client := &http.Client{Transport: Chain(base, Retry(newPolicy(clock, &spySleeper{clock: clock})))}
+
+	resp, err := client.Get("http://example.test/")
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	srv, calls := scriptedServer(t, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := newFakeClock()
+	policy := newPolicy(clock, &spySleeper{clock: clock})
+	// The attempt succeeds with 503 but the caller cancels before the retry.
+	client := &http.Client{Transport: Chain(nil, Retry(policy), Middleware(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(r)
+			cancel()
+			return resp, err
+		})
+	}))}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	_, err := client.Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	p.Rand = func() float64 { return 0.999 }
+	assert.InDelta(t, 400*time.Millisecond, p.Backoff(3), float64(time.Millisecond))
+	assert.InDelta(t, time.Second, p.Backoff(10), float64(time.Millisecond), "capped at MaxDelay")
+
+	p.Rand = func() float64 { return 0 }
+	assert.Equal(t, time.Duration(0), p.Backoff(3))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, d)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("-1", now)
+	assert.False(t, ok)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	clock := newFakeClock()
+	var transitions []string
+	breakers := NewBreakers(BreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      10 * time.Second,
+		Clock:            clock,
+		OnStateChange: func(_ string, from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	}, http.DefaultTransport)
+	client := &http.Client{Transport: breakers}
+
+	get := func() (int, error) {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	for range 3 {
+		code, err := get()
+		require.NoError(t, err)
+		assert.Equal(t, 500, code)
+	}
+	assert.Equal(t, StateOpen, breakers.State(host))
+
+	_, err := get()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), calls.Load(), "open circuit must not reach the server")
+
+	// A failed probe re-opens the circuit.
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, StateHalfOpen, breakers.State(host))
+	code, err := get()
+	require.NoError(t, err)
+	assert.Equal(t, 500, code)
+	assert.Equal(t, StateOpen, breakers.State(host))
+
+	// A successful probe closes it.
+	clock.Advance(10 * time.Second)
+	fail.Store(false)
+	code, err = get()
+	require.NoError(t, err)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, StateClosed, breakers.State(host))
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open", "half-open->open",
+		"open->half-open", "half-open->closed",
+	}, transitions)
+}
+
+func TestCircuitBreaker_HalfOpenAdmitsLimitedProbes(t *testing.T) {
+	release := make(chan struct{})
+	base := RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("X-Block") != "" {
+			<-release
+		}
+		return &http.Response{StatusCode: 500, Body: http.NoBody, Request: r}, nil
+	})
+	clock := newFakeClock()
+	breakers := NewBreakers(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, Clock: clock}, base)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test/", nil)
+	_, err := breakers.RoundTrip(req)
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+
+	probe := req.Clone(context.Background())
+	probe.Header.Set("X-Block", "1")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		breakers.RoundTrip(probe)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := breakers.RoundTrip(req)
+		return errors.Is(err, ErrCircuitOpen)
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	<-done
+	assert.Equal(t, StateOpen, breakers.State("example.test"))
+}
+
+func TestRetry_DoesNotRetryOpenCircuit(t *testing.T) {
+	srv, calls := scriptedServer(t, 500)
+	clock := newFakeClock()
+	sleeper := &spySleeper{clock: clock}
+	policy := newPolicy(clock, sleeper)
+	policy.RetryStatuses = []int{500}
+	client := &http.Client{Transport: Chain(nil,
+		Retry(policy),
+		CircuitBreaker(BreakerConfig{FailureThreshold: 2, Clock: clock}),
+	)}
+
+	_, err := client.Get(srv.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Len(t, sleeper.durations, 2)
+}
+
+func TestTimeout(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	clock := newFakeClock()
+	client := &http.Client{Transport: Chain(nil,
+		Retry(newPolicy(clock, &spySleeper{clock: clock})),
+		Timeout(50*time.Millisecond),
+	)}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err, "the timed out attempt is retried")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(2), calls.Load())
+}
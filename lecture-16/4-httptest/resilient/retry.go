@@ -0,0 +1,184 @@
+package resilient
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+// RetryPolicy configures Retry. Zero fields take the defaults noted below.
+type RetryPolicy struct {
+	// MaxAttempts includes the first try. Default 3.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt. Default 100ms.
+	BaseDelay time.Duration
+	// MaxDelay caps a single backoff. Default 10s.
+	MaxDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After the transport will wait;
+	// a longer one is returned to the caller as is. Default MaxDelay.
+	MaxRetryAfter time.Duration
+	// MaxElapsed bounds the whole call including waits; zero means no bound.
+	MaxElapsed time.Duration
+	// RetryStatuses are retried for idempotent requests. Default 429 and 503.
+	RetryStatuses []int
+	// Budget limits retries across all requests; nil means unlimited.
+	Budget *Budget
+
+	// Clock defaults to clock.Real().
+	Clock clock.Clock
+	// Sleeper defaults to ClockSleeper on Clock.
+	Sleeper Sleeper
+	// Rand returns values in [0, 1) for jitter. Default math/rand/v2.
+	Rand func() float64
+}
+
+func (p *RetryPolicy) setDefaults() {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 100 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 10 * time.Second
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = p.MaxDelay
+	}
+	if p.RetryStatuses == nil {
+		p.RetryStatuses = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+	}
+	if p.Clock == nil {
+		p.Clock = clock.Real()
+	}
+	if p.Sleeper == nil {
+		p.Sleeper = ClockSleeper{Clock: p.Clock}
+	}
+	if p.Rand == nil {
+		p.Rand = rand.Float64
+	}
+}
+
+// Backoff returns the full-jitter delay before retry number attempt (1-based):
+// a random duration in [0, min(MaxDelay, BaseDelay*2^(attempt-1))).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	ceiling := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	ceiling = math.Min(ceiling, float64(p.MaxDelay))
+	return time.Duration(p.Rand() * ceiling)
+}
+
+// Retry re-sends idempotent requests that failed with a transport error or
+// one of RetryStatuses. A request is idempotent if its method is
+// GET, HEAD, OPTIONS, TRACE, PUT or DELETE, or if it carries an
+// Idempotency-Key header. Requests with a body are retried only when
+// req.GetBody is set, which http.NewRequest does for common body types.
+func Retry(policy RetryPolicy) Middleware {
+	policy.setDefaults()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &retryTransport{next: next, policy: policy}
+	}
+}
+
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.policy
+	ctx := req.Context()
+	start := p.Clock.Now()
+
+	if p.Budget != nil {
+		p.Budget.deposit()
+	}
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(req)
+
+		if !retryable || attempt >= p.MaxAttempts || !t.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := p.Backoff(attempt)
+		if resp != nil {
+			if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After"), p.Clock.Now()); ok {
+				if ra > p.MaxRetryAfter {
+					return resp, err
+				}
+				delay = ra
+			}
+		}
+		if p.MaxElapsed > 0 && p.Clock.Now().Add(delay).Sub(start) > p.MaxElapsed {
+			return resp, err
+		}
+		if p.Budget != nil && !p.Budget.withdraw() {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain so the connection can be reused.
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		if sleepErr := p.Sleeper.Sleep(ctx, delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// The caller gave up or the breaker refused: retrying cannot help.
+		// A per-attempt Timeout below us does not cancel req's context.
+		return req.Context().Err() == nil && !errors.Is(err, ErrCircuitOpen)
+	}
+	for _, s := range t.policy.RetryStatuses {
+		if resp.StatusCode == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date forms.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 func HelloHandler(w http.ResponseWriter, r *http.Request) {
@@ -19,3 +23,33 @@ func GreetHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Hello, " + name + "!"))
 }
+
+type Status struct {
+	Status    string    `json:"status"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at"`
+	RequestID string    `json:"request_id"`
+}
+
+var startedAt = time.Now()
+
+// StatusHandler answers with JSON that differs on every run: the start
+// time and a random request id. Golden tests scrub both.
+func StatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Status{
+		Status:    "ok",
+		Version:   "1.0.0",
+		StartedAt: startedAt,
+		RequestID: newRequestID(),
+	})
+}
+
+// newRequestID returns a random UUID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
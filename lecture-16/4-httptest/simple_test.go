@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"ITMO-students/lecture-16/4-httptest/golden"
 )
 
 func TestHelloHandler(t *testing.T) {
@@ -50,3 +52,39 @@ func TestGreetHandler(t *testing.T) {
 		})
 	}
 }
+
+// Golden-тесты сравнивают ответ целиком (статус, заголовки, тело) с файлом
+// в testdata. После намеренного изменения ответа файлы обновляются так:
+//
+//	go test -run Golden -update
+func TestGreetHandler_Golden(t *testing.T) {
+	tests := []struct {
+		golden string
+		url    string
+	}{
+		{"greet_ok", "/greet?name=Alice"},
+		{"greet_no_name", "/greet"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		rec := httptest.NewRecorder()
+
+		GreetHandler(rec, req)
+
+		golden.AssertRecorder(t, tt.golden, rec)
+	}
+}
+
+func TestStatusHandler_Golden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(StatusHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// started_at и request_id меняются от запуска к запуску, поэтому в
+	// снимке они заменены на <time> и <uuid>.
+	golden.AssertResponse(t, "status", resp)
+}
@@ -1,13 +1,29 @@
 package concurrency
 
+import (
+	"context"
+
+	"ITMO-students/lecture-16/5-concurrency/stats"
+	"ITMO-students/lecture-16/5-concurrency/workerpool"
+)
+
 func ProcessAsync(data int, resultChan chan int) {
 	go func() {
 		resultChan <- data * 2
 	}()
 }
 
-var counter int
+// ProcessAll doubles every item on a bounded pool: at most workers
+// goroutines, results in input order, and nothing left running on return.
+func ProcessAll(ctx context.Context, data []int, workers int) ([]int, error) {
+	return workerpool.Map(ctx, data, func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	}, workerpool.Options{Workers: workers})
+}
+
+// counter was a bare int, and concurrent Increments raced on it.
+var counter stats.Counter
 
 func Increment() {
-	counter++
+	counter.Inc()
 }
@@ -1,6 +1,7 @@
 package concurrency
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
@@ -32,13 +33,29 @@ func TestProcessAsync_Good(t *testing.T) {
 	}
 }
 
+func TestProcessAll(t *testing.T) {
+	result, err := ProcessAll(context.Background(), []int{1, 2, 3, 4, 5}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []int{2, 4, 6, 8, 10}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
 func TestIncrement_Race(t *testing.T) {
+	// counter атомарный, поэтому результат предсказуем; дожидаемся
+	// горутин через WaitGroup, а не через time.Sleep.
+	before := counter.Load()
+	var wg sync.WaitGroup
 	for i := 0; i < 1000; i++ {
-		go Increment()
+		wg.Go(Increment)
 	}
+	wg.Wait()
 
-	time.Sleep(1 * time.Second)    // Наивная попытка дождаться завершения
-	t.Logf("Counter: %d", counter) // Результат непредсказуем!
+	if got := counter.Load() - before; got != 1000 {
+		t.Errorf("Expected 1000 increments, got %d", got)
+	}
 }
 
 func ProcessBatch(data []int, resultChan chan int) {
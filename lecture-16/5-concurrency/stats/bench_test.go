@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type mutexCounter struct {
+	mu sync.Mutex
+	n  int64
+}
+
+func (c *mutexCounter) Inc() {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+// BenchmarkCounter compares the ways to count from many goroutines. Run
+// with -race as well: every variant must stay silent, and the gap between
+// them changes under the detector.
+func BenchmarkCounter(b *testing.B) {
+	counters := []struct {
+		name string
+		inc  func()
+	}{
+		{"mutex", new(mutexCounter).Inc},
+		{"atomic", new(Counter).Inc},
+		{"sharded", NewShardedCounter(0).Inc},
+	}
+	for _, c := range counters {
+		b.Run(c.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.inc()
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkRollingCounter(b *testing.B) {
+	r := NewRollingCounter(time.Minute, 60, nil)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Inc()
+		}
+	})
+}
+
+func BenchmarkHistogram_Observe(b *testing.B) {
+	h := NewHistogram(ExponentialBuckets(0.001, 2, 20))
+	b.RunParallel(func(pb *testing.PB) {
+		v := 0.001
+		for pb.Next() {
+			h.Observe(v)
+			v *= 1.1
+			if v > 1000 {
+				v = 0.001
+			}
+		}
+	})
+}
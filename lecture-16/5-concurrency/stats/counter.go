@@ -0,0 +1,74 @@
+// Package stats has counters and histograms that are safe for concurrent
+// use without a caller-side mutex. Counter is a single atomic integer;
+// ShardedCounter spreads writes over several cache lines for hot counters
+// that many goroutines bump at once; RollingCounter counts events in a
+// sliding time window; Histogram answers percentile queries.
+//
+// Compare the approaches with
+//
+//	go test -race -bench . ./stats
+package stats
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+)
+
+// Counter is an atomic counter. The zero value is ready to use.
+type Counter struct {
+	v atomic.Int64
+}
+
+func (c *Counter) Inc()          { c.v.Add(1) }
+func (c *Counter) Add(n int64)   { c.v.Add(n) }
+func (c *Counter) Load() int64   { return c.v.Load() }
+func (c *Counter) Reset() int64  { return c.v.Swap(0) }
+func (c *Counter) Store(n int64) { c.v.Store(n) }
+
+// cacheLine is large enough for the common 64- and 128-byte lines.
+const cacheLine = 128
+
+type shard struct {
+	v atomic.Int64
+	_ [cacheLine - 8]byte
+}
+
+// ShardedCounter trades a slower Load for Add that rarely contends: each
+// Add picks a random shard, and shards sit on separate cache lines.
+type ShardedCounter struct {
+	shards []shard
+}
+
+// NewShardedCounter uses n shards; n <= 0 means one per GOMAXPROCS.
+func NewShardedCounter(n int) *ShardedCounter {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return &ShardedCounter{shards: make([]shard, n)}
+}
+
+func (c *ShardedCounter) Inc() { c.Add(1) }
+
+func (c *ShardedCounter) Add(n int64) {
+	c.shards[rand.IntN(len(c.shards))].v.Add(n)
+}
+
+// Load sums the shards. Adds running at the same time may or may not be
+// included.
+func (c *ShardedCounter) Load() int64 {
+	var sum int64
+	for i := range c.shards {
+		sum += c.shards[i].v.Load()
+	}
+	return sum
+}
+
+// Reset zeroes the shards and returns the sum they held.
+func (c *ShardedCounter) Reset() int64 {
+	var sum int64
+	for i := range c.shards {
+		sum += c.shards[i].v.Swap(0)
+	}
+	return sum
+}
@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"math"
+	"slices"
+	"sync/atomic"
+)
+
+// Histogram counts observations in fixed buckets with atomic operations,
+// so Observe never blocks. Quantiles are interpolated inside a bucket;
+// their error is bounded by the bucket width.
+type Histogram struct {
+	bounds []float64       // upper bounds, ascending
+	counts []atomic.Uint64 // len(bounds)+1; the last one is the overflow
+	count  atomic.Uint64
+	sum    atomicFloat
+	min    atomicFloat
+	max    atomicFloat
+}
+
+// NewHistogram panics unless bounds are ascending and non-empty.
+func NewHistogram(bounds []float64) *Histogram {
+	if len(bounds) == 0 || !slices.IsSorted(bounds) {
+		panic("stats: histogram bounds must be ascending and non-empty")
+	}
+	h := &Histogram{
+		bounds: slices.Clone(bounds),
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+	h.min.store(math.Inf(1))
+	h.max.store(math.Inf(-1))
+	return h
+}
+
+// ExponentialBuckets returns count bounds starting at start, each factor
+// times the previous one.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+// LinearBuckets returns count bounds starting at start, width apart.
+func LinearBuckets(start, width float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start + float64(i)*width
+	}
+	return bounds
+}
+
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.add(v)
+	h.min.update(v, func(cur float64) bool { return v < cur })
+	h.max.update(v, func(cur float64) bool { return v > cur })
+}
+
+// HistogramSnapshot is a copy of a Histogram's state. Observations that
+// race with Snapshot may be partly included.
+type HistogramSnapshot struct {
+	Bounds []float64
+	// Counts has one entry per bound plus one for values above the last.
+	Counts   []uint64
+	Count    uint64
+	Sum      float64
+	Min, Max float64
+}
+
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Bounds: h.bounds,
+		Counts: make([]uint64, len(h.counts)),
+		Sum:    h.sum.load(),
+		Min:    h.min.load(),
+		Max:    h.max.load(),
+	}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+		s.Count += s.Counts[i]
+	}
+	return s
+}
+
+// Quantile is Snapshot().Quantile(q).
+func (h *Histogram) Quantile(q float64) float64 {
+	return h.Snapshot().Quantile(q)
+}
+
+func (s HistogramSnapshot) Mean() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Quantile estimates the q-quantile, 0 <= q <= 1, by linear interpolation
+// in the bucket holding it. The result is clamped to [Min, Max], so the
+// first bucket starts at Min and the overflow bucket ends at Max. It is
+// NaN if there are no observations.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	q = min(max(q, 0), 1)
+
+	rank := q * float64(s.Count)
+	var seen float64
+	for i, n := range s.Counts {
+		if n == 0 {
+			continue
+		}
+		if seen+float64(n) < rank {
+			seen += float64(n)
+			continue
+		}
+
+		lo, hi := s.Min, s.Max
+		if i > 0 {
+			lo = max(lo, s.Bounds[i-1])
+		}
+		if i < len(s.Bounds) {
+			hi = min(hi, s.Bounds[i])
+		}
+		v := lo + (hi-lo)*(rank-seen)/float64(n)
+		return min(max(v, s.Min), s.Max)
+	}
+	return s.Max
+}
+
+// atomicFloat is a float64 updated with compare-and-swap.
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64   { return math.Float64frombits(f.bits.Load()) }
+func (f *atomicFloat) store(v float64) { f.bits.Store(math.Float64bits(v)) }
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// update stores v while better reports that v should replace the current value.
+func (f *atomicFloat) update(v float64, better func(cur float64) bool) {
+	for {
+		old := f.bits.Load()
+		if !better(math.Float64frombits(old)) || f.bits.CompareAndSwap(old, math.Float64bits(v)) {
+			return
+		}
+	}
+}
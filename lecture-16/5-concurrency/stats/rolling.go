@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// RollingCounter counts events over the last window, in buckets of
+// window/buckets: an event leaves the count once its whole bucket is older
+// than the window.
+type RollingCounter struct {
+	width time.Duration
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets []int64
+	head    int       // bucket that receives Adds
+	start   time.Time // start of the head bucket
+}
+
+// NewRollingCounter panics unless window and buckets are positive and a
+// bucket is at least a nanosecond wide. A nil now means time.Now.
+func NewRollingCounter(window time.Duration, buckets int, now func() time.Time) *RollingCounter {
+	if window <= 0 || buckets <= 0 {
+		panic("stats: NewRollingCounter needs a positive window and bucket count")
+	}
+	width := window / time.Duration(buckets)
+	if width == 0 {
+		panic("stats: NewRollingCounter window is shorter than one nanosecond per bucket")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &RollingCounter{
+		width:   width,
+		now:     now,
+		buckets: make([]int64, buckets),
+		start:   now().Truncate(width),
+	}
+}
+
+func (r *RollingCounter) Inc() { r.Add(1) }
+
+func (r *RollingCounter) Add(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance()
+	r.buckets[r.head] += n
+}
+
+// Sum is the number of events in the window.
+func (r *RollingCounter) Sum() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance()
+	var sum int64
+	for _, n := range r.buckets {
+		sum += n
+	}
+	return sum
+}
+
+// Rate is Sum per second of window.
+func (r *RollingCounter) Rate() float64 {
+	window := r.width * time.Duration(len(r.buckets))
+	return float64(r.Sum()) / window.Seconds()
+}
+
+// advance rotates the ring up to now, clearing the buckets it passes.
+func (r *RollingCounter) advance() {
+	elapsed := int(r.now().Sub(r.start) / r.width)
+	if elapsed <= 0 {
+		return
+	}
+	for i := range min(elapsed, len(r.buckets)) {
+		r.buckets[(r.head+1+i)%len(r.buckets)] = 0
+	}
+	r.head = (r.head + elapsed) % len(r.buckets)
+	r.start = r.start.Add(time.Duration(elapsed) * r.width)
+}
@@ -0,0 +1,184 @@
+package stats
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCounters_Concurrent(t *testing.T) {
+	var c Counter
+	sc := NewShardedCounter(0)
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				c.Inc()
+				sc.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.Load(); got != 100_000 {
+		t.Errorf("Counter = %d, want 100000", got)
+	}
+	if got := sc.Load(); got != 100_000 {
+		t.Errorf("ShardedCounter = %d, want 100000", got)
+	}
+	if got := sc.Reset(); got != 100_000 || sc.Load() != 0 {
+		t.Errorf("Reset = %d, then Load = %d", got, sc.Load())
+	}
+}
+
+type fakeNow struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeNow) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeNow) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+func TestRollingCounter(t *testing.T) {
+	clock := &fakeNow{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r := NewRollingCounter(10*time.Second, 10, clock.Now)
+
+	r.Add(5)
+	clock.Advance(3 * time.Second)
+	r.Add(3)
+	if got := r.Sum(); got != 8 {
+		t.Errorf("Sum = %d, want 8", got)
+	}
+
+	clock.Advance(7 * time.Second)
+	if got := r.Sum(); got != 3 {
+		t.Errorf("Sum after the first bucket left the window = %d, want 3", got)
+	}
+	if got := r.Rate(); got != 0.3 {
+		t.Errorf("Rate = %v, want 0.3", got)
+	}
+
+	clock.Advance(time.Hour)
+	if got := r.Sum(); got != 0 {
+		t.Errorf("Sum after an idle hour = %d, want 0", got)
+	}
+	r.Inc()
+	if got := r.Sum(); got != 1 {
+		t.Errorf("Sum = %d, want 1", got)
+	}
+}
+
+func TestNewRollingCounter_TooNarrow(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewRollingCounter(5ns, 10) did not panic")
+		}
+	}()
+	NewRollingCounter(5*time.Nanosecond, 10, nil)
+}
+
+func TestRollingCounter_Concurrent(t *testing.T) {
+	r := NewRollingCounter(time.Minute, 60, nil)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				r.Inc()
+				r.Sum()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := r.Sum(); got != 1000 {
+		t.Errorf("Sum = %d, want 1000", got)
+	}
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	h := NewHistogram(LinearBuckets(10, 10, 10)) // 10, 20, ..., 100
+	for v := 1; v <= 100; v++ {
+		h.Observe(float64(v))
+	}
+
+	s := h.Snapshot()
+	if s.Count != 100 || s.Sum != 5050 || s.Min != 1 || s.Max != 100 {
+		t.Fatalf("snapshot = %+v", s)
+	}
+	if got := s.Mean(); got != 50.5 {
+		t.Errorf("Mean = %v, want 50.5", got)
+	}
+
+	tests := []struct {
+		q, want float64
+	}{
+		{0, 1},
+		{0.5, 50},
+		{0.9, 90},
+		{0.99, 99},
+		{1, 100},
+	}
+	for _, tt := range tests {
+		// One bucket is 10 wide.
+		if got := h.Quantile(tt.q); math.Abs(got-tt.want) > 10 {
+			t.Errorf("Quantile(%v) = %v, want %v ± 10", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestHistogram_Overflow(t *testing.T) {
+	h := NewHistogram(ExponentialBuckets(1, 2, 4)) // 1, 2, 4, 8
+	if !math.IsNaN(h.Quantile(0.5)) {
+		t.Error("Quantile of an empty histogram is not NaN")
+	}
+
+	h.Observe(1000)
+	h.Observe(3000)
+	if got := h.Quantile(1); got != 3000 {
+		t.Errorf("Quantile(1) = %v, want the max 3000", got)
+	}
+	if got := h.Quantile(0); got != 1000 {
+		t.Errorf("Quantile(0) = %v, want the min 1000", got)
+	}
+	if got := h.Snapshot().Counts; got[len(got)-1] != 2 {
+		t.Errorf("Counts = %v, want both in the overflow bucket", got)
+	}
+}
+
+func TestHistogram_Concurrent(t *testing.T) {
+	h := NewHistogram(ExponentialBuckets(1, 2, 10))
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				h.Observe(float64(g*1000 + i))
+				h.Quantile(0.99)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := h.Snapshot()
+	if s.Count != 8000 || s.Min != 0 || s.Max != 7999 {
+		t.Errorf("Count, Min, Max = %d, %v, %v", s.Count, s.Min, s.Max)
+	}
+	if want := float64(8000*7999) / 2; s.Sum != want {
+		t.Errorf("Sum = %v, want %v", s.Sum, want)
+	}
+}
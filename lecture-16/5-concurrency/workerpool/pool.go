@@ -0,0 +1,200 @@
+// Package workerpool runs tasks on a fixed number of goroutines fed by a
+// bounded queue. Submit blocks while the queue is full, so a fast producer
+// is slowed down to the pace of the workers instead of piling up
+// goroutines the way ProcessAsync does.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClosed    = errors.New("workerpool: pool is closed")
+	ErrQueueFull = errors.New("workerpool: queue is full")
+)
+
+// PanicError is the error of a task that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("workerpool: task panicked: %v", e.Value)
+}
+
+// Task is a unit of work. Its context is the pool's.
+type Task func(ctx context.Context) error
+
+// Options configures a Pool. Zero fields take the defaults noted below.
+type Options struct {
+	// Workers is the number of goroutines running tasks. Default GOMAXPROCS.
+	Workers int
+	// QueueSize is how many submitted tasks may wait for a worker. Default Workers.
+	QueueSize int
+}
+
+func (o *Options) setDefaults() {
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = o.Workers
+	}
+}
+
+type queued struct {
+	task Task
+	at   time.Time
+}
+
+// Pool runs submitted tasks until Close. When its context is canceled,
+// tasks still in the queue are dropped and Submit fails.
+type Pool struct {
+	ctx   context.Context
+	opts  Options
+	tasks chan queued
+	wg    sync.WaitGroup
+
+	// mu guards closed against sends on tasks: submitters hold it for
+	// reading while they send, Close takes it for writing. Close first
+	// closes closing, so that a Submit blocked on a full queue, possibly
+	// from inside a task, gives up the read lock instead of waiting for
+	// a worker that Close is waiting for.
+	mu        sync.RWMutex
+	closed    bool
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	errMu sync.Mutex
+	errs  []error
+
+	stats counters
+}
+
+func New(ctx context.Context, opts Options) *Pool {
+	opts.setDefaults()
+	p := &Pool{
+		ctx:     ctx,
+		opts:    opts,
+		tasks:   make(chan queued, opts.QueueSize),
+		closing: make(chan struct{}),
+	}
+	for range opts.Workers {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues task, blocking while the queue is full. It fails if ctx or
+// the pool's context is done, or with ErrClosed once Close is called, even
+// while it is blocked; tasks may therefore submit more tasks.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.tasks <- queued{task: task, at: time.Now()}:
+		p.stats.submitted.Add(1)
+		return nil
+	case <-p.closing:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// TrySubmit queues task or returns ErrQueueFull at once.
+func (p *Pool) TrySubmit(task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case p.tasks <- queued{task: task, at: time.Now()}:
+		p.stats.submitted.Add(1)
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting tasks, waits for the queued ones and returns the
+// errors of all failed tasks joined with errors.Join. If tasks were dropped
+// because the pool's context was canceled, its error is included once.
+// Close may be called more than once.
+func (p *Pool) Close() error {
+	p.closeOnce.Do(func() { close(p.closing) })
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	errs := p.errs
+	if p.stats.dropped.Load() > 0 {
+		errs = append(errs[:len(errs):len(errs)], context.Cause(p.ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for q := range p.tasks {
+		if p.ctx.Err() != nil {
+			p.stats.dropped.Add(1)
+			continue
+		}
+
+		start := time.Now()
+		p.stats.wait.observe(start.Sub(q.at))
+		p.stats.running.Add(1)
+		err := Safe(p.ctx, q.task)
+		p.stats.running.Add(-1)
+		p.stats.run.observe(time.Since(start))
+
+		if err == nil {
+			p.stats.completed.Add(1)
+			continue
+		}
+		p.stats.failed.Add(1)
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			p.stats.panics.Add(1)
+		}
+		p.errMu.Lock()
+		p.errs = append(p.errs, err)
+		p.errMu.Unlock()
+	}
+}
+
+// Safe runs task and turns a panic into a *PanicError.
+func Safe(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return task(ctx)
+}
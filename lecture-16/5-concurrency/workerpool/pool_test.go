@@ -0,0 +1,340 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_RunsAllTasks(t *testing.T) {
+	pool := New(context.Background(), Options{Workers: 4})
+
+	var sum atomic.Int64
+	for i := 1; i <= 100; i++ {
+		err := pool.Submit(context.Background(), func(ctx context.Context) error {
+			sum.Add(int64(i))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := sum.Load(); got != 5050 {
+		t.Errorf("sum = %d, want 5050", got)
+	}
+	stats := pool.Stats()
+	if stats.Submitted != 100 || stats.Completed != 100 || stats.Run.Count != 100 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if err := pool.Submit(context.Background(), func(context.Context) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	const workers = 3
+	pool := New(context.Background(), Options{Workers: workers})
+
+	var running, peak atomic.Int32
+	for range 30 {
+		pool.Submit(context.Background(), func(ctx context.Context) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+	pool.Close()
+
+	if got := peak.Load(); got > workers {
+		t.Errorf("peak concurrency = %d, want at most %d", got, workers)
+	}
+}
+
+func TestPool_Backpressure(t *testing.T) {
+	pool := New(context.Background(), Options{Workers: 1, QueueSize: 1})
+	release := make(chan struct{})
+	block := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+
+	// One task runs, one waits in the queue.
+	if err := pool.Submit(context.Background(), block); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Submit(context.Background(), block); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return pool.Stats().Running == 1 })
+
+	if err := pool.TrySubmit(block); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TrySubmit = %v, want ErrQueueFull", err)
+	}
+	if depth := pool.Stats().QueueDepth; depth != 1 {
+		t.Errorf("QueueDepth = %d, want 1", depth)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Submit(ctx, block); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit on a full queue = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if wait := pool.Stats().Wait; wait.Count != 2 || wait.Max <= 0 {
+		t.Errorf("Wait = %+v, want two observations with a positive max", wait)
+	}
+}
+
+func TestPool_JoinsErrorsAndRecoversPanics(t *testing.T) {
+	pool := New(context.Background(), Options{Workers: 2})
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+
+	pool.Submit(context.Background(), func(context.Context) error { return errA })
+	pool.Submit(context.Background(), func(context.Context) error { panic("boom") })
+	pool.Submit(context.Background(), func(context.Context) error { return errB })
+	pool.Submit(context.Background(), func(context.Context) error { return nil })
+	err := pool.Close()
+
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Close = %v, want both task errors", err)
+	}
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("Close = %v, want a PanicError with a stack", err)
+	}
+	stats := pool.Stats()
+	if stats.Failed != 3 || stats.Panics != 1 || stats.Completed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestPool_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := New(ctx, Options{Workers: 1, QueueSize: 10})
+
+	started := make(chan struct{})
+	pool.Submit(ctx, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+	var ran atomic.Int32
+	for range 5 {
+		pool.Submit(ctx, func(context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	<-started
+	cancel()
+
+	if err := pool.Close(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Close = %v, want context.Canceled", err)
+	}
+	if ran.Load() != 0 {
+		t.Errorf("%d queued tasks ran after cancel", ran.Load())
+	}
+	if dropped := pool.Stats().Dropped; dropped != 5 {
+		t.Errorf("Dropped = %d, want 5", dropped)
+	}
+	if err := pool.TrySubmit(func(context.Context) error { return nil }); err == nil {
+		t.Error("TrySubmit after cancel succeeded")
+	}
+}
+
+func TestPool_ConcurrentSubmitAndClose(t *testing.T) {
+	pool := New(context.Background(), Options{Workers: 2})
+
+	var wg sync.WaitGroup
+	var accepted, ran atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				err := pool.Submit(context.Background(), func(context.Context) error {
+					ran.Add(1)
+					return nil
+				})
+				if errors.Is(err, ErrClosed) {
+					return
+				}
+				accepted.Add(1)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	pool.Close()
+	wg.Wait()
+
+	if accepted.Load() != ran.Load() {
+		t.Errorf("accepted %d tasks but ran %d", accepted.Load(), ran.Load())
+	}
+}
+
+func TestPool_SubmitFromTaskDuringClose(t *testing.T) {
+	pool := New(context.Background(), Options{Workers: 1, QueueSize: 1})
+
+	submitting := make(chan struct{})
+	pool.Submit(context.Background(), func(context.Context) error {
+		close(submitting)
+		// The only worker is busy here, so this blocks once the queue is full.
+		return pool.Submit(context.Background(), func(context.Context) error { return nil })
+	})
+	pool.Submit(context.Background(), func(context.Context) error { return nil })
+	<-submitting
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- pool.Close() }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Close() = %v, want the task's ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close deadlocked with a task blocked in Submit")
+	}
+}
+
+func TestStream(t *testing.T) {
+	double := func(ctx context.Context, n int) (int, error) {
+		// Later inputs finish first, so ordering has to be restored.
+		time.Sleep(time.Duration(10-n) * 100 * time.Microsecond)
+		if n == 7 {
+			return 0, fmt.Errorf("bad input %d", n)
+		}
+		return n * 2, nil
+	}
+
+	for _, ordered := range []bool{true, false} {
+		t.Run(fmt.Sprintf("ordered=%v", ordered), func(t *testing.T) {
+			in := make(chan int)
+			go func() {
+				defer close(in)
+				for i := range 10 {
+					in <- i
+				}
+			}()
+
+			var indexes, values []int
+			errs := 0
+			opts := StreamOptions{Options: Options{Workers: 3, QueueSize: 2}, Ordered: ordered}
+			for r := range Stream(context.Background(), in, double, opts) {
+				indexes = append(indexes, r.Index)
+				if r.Err != nil {
+					errs++
+					continue
+				}
+				if r.Value != r.Index*2 {
+					t.Errorf("result %d = %d", r.Index, r.Value)
+				}
+				values = append(values, r.Value)
+			}
+
+			if len(indexes) != 10 || errs != 1 {
+				t.Fatalf("got %d results with %d errors, want 10 and 1", len(indexes), errs)
+			}
+			if ordered && !slices.IsSorted(indexes) {
+				t.Errorf("indexes = %v, want input order", indexes)
+			}
+		})
+	}
+}
+
+func TestStream_PanicAndCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; ; i++ {
+			select {
+			case in <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fn := func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			panic("zero")
+		}
+		return n, nil
+	}
+	out := Stream(ctx, in, fn, StreamOptions{Options: Options{Workers: 2}, Ordered: true})
+
+	first := <-out
+	var pe *PanicError
+	if !errors.As(first.Err, &pe) {
+		t.Errorf("first result = %+v, want a PanicError", first)
+	}
+	<-out
+	cancel()
+
+	// The endless input is abandoned and out is closed.
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("out was not closed after cancel")
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	items := []string{"a", "bb", "", "dddd"}
+	errEmpty := errors.New("empty")
+
+	lengths, err := Map(context.Background(), items, func(ctx context.Context, s string) (int, error) {
+		if s == "" {
+			return 0, errEmpty
+		}
+		return len(s), nil
+	}, Options{Workers: 2})
+
+	if !errors.Is(err, errEmpty) {
+		t.Errorf("err = %v, want errEmpty", err)
+	}
+	if want := []int{1, 2, 0, 4}; !slices.Equal(lengths, want) {
+		t.Errorf("lengths = %v, want %v", lengths, want)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,88 @@
+package workerpool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of a pool's counters.
+type Stats struct {
+	Workers int
+	// QueueDepth is the number of tasks waiting for a worker.
+	QueueDepth int
+	Running    int
+	Submitted  uint64
+	Completed  uint64
+	// Failed counts tasks that returned an error or panicked.
+	Failed uint64
+	Panics uint64
+	// Dropped counts queued tasks skipped after the context was canceled.
+	Dropped uint64
+	// Wait is the time tasks spent in the queue, Run the time they ran.
+	Wait Latency
+	Run  Latency
+}
+
+type Latency struct {
+	Count uint64
+	Total time.Duration
+	Max   time.Duration
+}
+
+func (l Latency) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Total / time.Duration(l.Count)
+}
+
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:    p.opts.Workers,
+		QueueDepth: len(p.tasks),
+		Running:    int(p.stats.running.Load()),
+		Submitted:  p.stats.submitted.Load(),
+		Completed:  p.stats.completed.Load(),
+		Failed:     p.stats.failed.Load(),
+		Panics:     p.stats.panics.Load(),
+		Dropped:    p.stats.dropped.Load(),
+		Wait:       p.stats.wait.snapshot(),
+		Run:        p.stats.run.snapshot(),
+	}
+}
+
+type counters struct {
+	running   atomic.Int64
+	submitted atomic.Uint64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+	panics    atomic.Uint64
+	dropped   atomic.Uint64
+	wait      latency
+	run       latency
+}
+
+type latency struct {
+	count atomic.Uint64
+	total atomic.Int64
+	max   atomic.Int64
+}
+
+func (l *latency) observe(d time.Duration) {
+	l.count.Add(1)
+	l.total.Add(int64(d))
+	for {
+		cur := l.max.Load()
+		if int64(d) <= cur || l.max.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+func (l *latency) snapshot() Latency {
+	return Latency{
+		Count: l.count.Load(),
+		Total: time.Duration(l.total.Load()),
+		Max:   time.Duration(l.max.Load()),
+	}
+}
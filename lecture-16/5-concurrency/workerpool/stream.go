@@ -0,0 +1,138 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+)
+
+// Result is the outcome of fn for the Index-th input of Stream.
+type Result[R any] struct {
+	Index int
+	Value R
+	Err   error
+}
+
+// StreamOptions configures Stream.
+type StreamOptions struct {
+	Options
+	// Ordered delivers results in input order. Results that finish early
+	// wait in a buffer of at most Workers+QueueSize entries; a slow input
+	// holds back the ones behind it.
+	Ordered bool
+}
+
+// Stream applies fn to every value from in on a pool and sends the results
+// on the returned channel, which is closed once in is closed and drained or
+// ctx is done. At most Workers+QueueSize results are in flight, so a
+// consumer that stops reading stops the producer too; it must cancel ctx
+// to release the goroutines. A panic in fn becomes the result's
+// *PanicError.
+func Stream[T, R any](ctx context.Context, in <-chan T, fn func(context.Context, T) (R, error), opts StreamOptions) <-chan Result[R] {
+	opts.setDefaults()
+	window := opts.Workers + opts.QueueSize
+	// Both buffers hold a whole window, so workers never block on them.
+	slots := make(chan struct{}, window)
+	results := make(chan Result[R], window)
+	out := make(chan Result[R])
+
+	go func() {
+		defer close(results)
+		pool := New(ctx, opts.Options)
+		defer pool.Close()
+
+		for i := 0; ; i++ {
+			var v T
+			select {
+			case x, ok := <-in:
+				if !ok {
+					return
+				}
+				v = x
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			err := pool.Submit(ctx, func(ctx context.Context) error {
+				r := Result[R]{Index: i}
+				r.Err = Safe(ctx, func(ctx context.Context) (err error) {
+					r.Value, err = fn(ctx, v)
+					return err
+				})
+				results <- r
+				return nil
+			})
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		send := func(r Result[R]) bool {
+			<-slots
+			select {
+			case out <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if !opts.Ordered {
+			for r := range results {
+				if !send(r) {
+					return
+				}
+			}
+			return
+		}
+
+		pending := make(map[int]Result[R])
+		next := 0
+		for r := range results {
+			pending[r.Index] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if !send(r) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Map applies fn to every item on a pool and returns the values in item
+// order along with the errors of all failed items joined with errors.Join.
+// Failed items leave the zero value in their slot.
+func Map[T, R any](ctx context.Context, items []T, fn func(context.Context, T) (R, error), opts Options) ([]R, error) {
+	pool := New(ctx, opts)
+	values := make([]R, len(items))
+	for i, item := range items {
+		err := pool.Submit(ctx, func(ctx context.Context) error {
+			var err error
+			values[i], err = fn(ctx, item)
+			return err
+		})
+		if err != nil {
+			closeErr := pool.Close()
+			if errors.Is(closeErr, err) {
+				return values, closeErr
+			}
+			return values, errors.Join(closeErr, err)
+		}
+	}
+	return values, pool.Close()
+}
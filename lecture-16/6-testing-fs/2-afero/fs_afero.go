@@ -1,22 +1,15 @@
 package fs
 
 import (
-	"bufio"
-
 	"github.com/spf13/afero"
+
+	"ITMO-students/lecture-16/6-testing-fs/textio"
 )
 
+// CountLines counts lines as bufio.Scanner sees them: the last one counts
+// even without "\n". Lines of any length are accepted; see textio for
+// words, runes and the other counts.
 func CountLines(fs afero.Fs, filename string) (int, error) {
-	file, err := fs.Open(filename)
-	if err != nil {
-		return 0, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	count := 0
-	for scanner.Scan() {
-		count++
-	}
-	return count, scanner.Err()
+	c, err := textio.CountFile(fs, filename, textio.Options{})
+	return int(c.Lines), err
 }
@@ -1,9 +1,13 @@
 package fs
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
+
+	"ITMO-students/lecture-16/6-testing-fs/chaos"
 )
 
 func TestCountLinesWithAfero(t *testing.T) {
@@ -26,6 +30,21 @@ func TestCountLinesWithAfero(t *testing.T) {
 	}
 }
 
+// Строка длиннее 64 КБ, на которой bufio.Scanner падал с ErrTooLong.
+func TestCountLines_LongLine(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	long := strings.Repeat("x", 100_000)
+	afero.WriteFile(fs, "test.txt", []byte("line1\n"+long+"\nline3\n"), 0644)
+
+	count, err := CountLines(fs, "test.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 lines, got %d", count)
+	}
+}
+
 func TestCountLines_FileNotExists(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	_, err := CountLines(fs, "nonexistent.txt")
@@ -33,3 +52,29 @@ func TestCountLines_FileNotExists(t *testing.T) {
 		t.Error("Expected error for nonexistent file")
 	}
 }
+
+func TestCountLines_Failures(t *testing.T) {
+	errIO := errors.New("input/output error")
+	tests := []struct {
+		name string
+		rule chaos.Rule
+	}{
+		// Файл есть, но открыть его нельзя.
+		{"open fails", chaos.Rule{Op: "Open", Fault: chaos.Fault{Err: errIO}}},
+		// Диск «отвалился» посреди чтения.
+		{"read fails", chaos.Rule{Op: "File.Read", Fault: chaos.Fault{Err: errIO}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := afero.NewMemMapFs()
+			afero.WriteFile(base, "test.txt", []byte("line1\nline2\nline3"), 0644)
+			fs := chaos.Fs(base, chaos.New(chaos.Options{Rules: []chaos.Rule{tt.rule}}))
+
+			_, err := CountLines(fs, "test.txt")
+			if !errors.Is(err, errIO) {
+				t.Errorf("Expected the injected error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package chaos wraps dependencies so that they fail on purpose: an
+// afero.Fs, a repository.UserRepository and an http.RoundTripper can be
+// made to return errors, slow down, write only part of the data or panic,
+// on chosen calls or at random. Randomness comes from a seed, so a failing
+// run can be repeated exactly.
+//
+//	inj := chaos.New(chaos.Options{Seed: 1, Rules: []chaos.Rule{
+//		{Op: "File.Write", Probability: 0.3, Fault: chaos.Fault{Partial: true}},
+//		{Op: "Open", After: 2, Times: 1, Fault: chaos.Fault{Err: os.ErrPermission}},
+//	}})
+//	fs := chaos.Fs(afero.NewMemMapFs(), inj)
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"path"
+	"sync"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+// ErrInjected is the error of a Fault that names none.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Fault is what happens to a call when a rule fires. Latency comes first;
+// then a Panic value panics, Partial does half the work, and Err is
+// returned, ErrInjected if it is nil. A Fault with only Latency delays the
+// call but lets it through.
+type Fault struct {
+	Err     error
+	Latency time.Duration
+	Panic   any
+	// Partial makes writes store only the first half of the data and
+	// HTTP responses break off halfway through the body. Err defaults to
+	// io.ErrShortWrite and io.ErrUnexpectedEOF respectively. Other
+	// operations fail outright.
+	Partial bool
+}
+
+// Rule picks the calls a Fault is injected into. Calls to the operation
+// are counted per rule; the rule fires on a call if all of its conditions
+// hold. A rule with no conditions fires on every call.
+type Rule struct {
+	// Op is a path.Match pattern for the operation name: the method name
+	// for Fs ("Open", "Rename") and UserRepository ("FindByID"), with a
+	// "File." prefix for methods of opened files ("File.Write"), and the
+	// request method for RoundTripper ("GET"). Empty matches everything.
+	Op string
+	// After skips the first After calls.
+	After int
+	// Every fires on every Every-th call after those, e.g. 2 for every
+	// other one.
+	Every int
+	// Probability fires on a call with this chance; zero means always.
+	Probability float64
+	// Times caps how often the rule fires; zero means no cap.
+	Times int
+	Fault Fault
+}
+
+// Options configures an Injector. Zero fields take the defaults noted below.
+type Options struct {
+	// Seed makes Probability rules repeatable. Calls from several
+	// goroutines interleave differently between runs, so only a
+	// sequential test replays exactly.
+	Seed  uint64
+	Rules []Rule
+	// Clock times Latency. Default clock.Real().
+	Clock clock.Clock
+}
+
+// Injector decides which calls fail. One Injector can be shared by
+// several wrappers; it is safe for concurrent use.
+type Injector struct {
+	clock clock.Clock
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	rules    []ruleState
+	injected map[string]int
+}
+
+type ruleState struct {
+	Rule
+	calls, fired int
+}
+
+func New(opts Options) *Injector {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	in := &Injector{
+		clock:    opts.Clock,
+		rng:      rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+		injected: make(map[string]int),
+	}
+	for _, r := range opts.Rules {
+		in.rules = append(in.rules, ruleState{Rule: r})
+	}
+	return in
+}
+
+// Injected reports how many faults were injected into op.
+func (in *Injector) Injected(op string) int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.injected[op]
+}
+
+// pick returns the fault of the first rule that fires for op. Every
+// matching rule counts the call, whether or not an earlier one fired.
+func (in *Injector) pick(op string) (Fault, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var fault Fault
+	found := false
+	for i := range in.rules {
+		r := &in.rules[i]
+		if r.Op != "" {
+			if ok, _ := path.Match(r.Op, op); !ok {
+				continue
+			}
+		}
+		r.calls++
+		if found || !r.fires(in.rng) {
+			continue
+		}
+		r.fired++
+		fault, found = r.Fault, true
+	}
+	if found {
+		in.injected[op]++
+	}
+	return fault, found
+}
+
+func (r *ruleState) fires(rng *rand.Rand) bool {
+	n := r.calls - r.After
+	switch {
+	case n <= 0:
+		return false
+	case r.Every > 0 && n%r.Every != 0:
+		return false
+	case r.Times > 0 && r.fired >= r.Times:
+		return false
+	case r.Probability > 0 && rng.Float64() >= r.Probability:
+		return false
+	}
+	return true
+}
+
+// before runs the fault's latency and panic for op. It returns the fault
+// to apply, if any; a non-nil error means the call should fail outright
+// without reaching the wrapped value.
+func (in *Injector) before(ctx context.Context, op string) (Fault, bool, error) {
+	f, ok := in.pick(op)
+	if !ok {
+		return Fault{}, false, nil
+	}
+	if err := clock.SleepContext(ctx, in.clock, f.Latency); err != nil {
+		return f, true, err
+	}
+	if f.Panic != nil {
+		panic(f.Panic)
+	}
+	if f.Partial || (f.Err == nil && f.Latency > 0) {
+		return f, true, nil
+	}
+	return f, true, f.err(ErrInjected)
+}
+
+// call is before for operations that have nothing to do halfway.
+func (in *Injector) call(ctx context.Context, op string) error {
+	f, ok, err := in.before(ctx, op)
+	if err == nil && ok && f.Partial {
+		return f.err(ErrInjected)
+	}
+	return err
+}
+
+func (f Fault) err(def error) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	return def
+}
@@ -0,0 +1,227 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"ITMO-students/lecture-16/4-httptest/resilient"
+	"ITMO-students/lecture-8/myapp/clock"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+// pattern calls op n times and records which calls failed.
+func pattern(in *Injector, op string, n int) []bool {
+	failed := make([]bool, n)
+	for i := range failed {
+		failed[i] = in.call(context.Background(), op) != nil
+	}
+	return failed
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want []bool
+	}{
+		{"always", Rule{}, []bool{true, true, true, true, true, true}},
+		{"nth call", Rule{After: 2, Times: 1}, []bool{false, false, true, false, false, false}},
+		{"every other", Rule{Every: 2}, []bool{false, true, false, true, false, true}},
+		{"after then every", Rule{After: 1, Every: 2, Times: 2}, []bool{false, false, true, false, true, false}},
+		{"other op", Rule{Op: "Create"}, []bool{false, false, false, false, false, false}},
+		{"glob", Rule{Op: "File.*"}, []bool{true, true, true, true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := New(Options{Rules: []Rule{tt.rule}})
+			if got := pattern(in, "File.Read", len(tt.want)); !slices.Equal(got, tt.want) {
+				t.Errorf("failures = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeed(t *testing.T) {
+	rules := []Rule{{Probability: 0.5}}
+	a := pattern(New(Options{Seed: 42, Rules: rules}), "Open", 64)
+	b := pattern(New(Options{Seed: 42, Rules: rules}), "Open", 64)
+	c := pattern(New(Options{Seed: 7, Rules: rules}), "Open", 64)
+
+	if !slices.Equal(a, b) {
+		t.Error("the same seed gave different failures")
+	}
+	if slices.Equal(a, c) {
+		t.Error("different seeds gave the same failures")
+	}
+	if n := len(slices.DeleteFunc(slices.Clone(a), func(f bool) bool { return !f })); n < 16 || n > 48 {
+		t.Errorf("%d of 64 calls failed at probability 0.5", n)
+	}
+}
+
+func TestFs(t *testing.T) {
+	errDisk := errors.New("disk on fire")
+	in := New(Options{Rules: []Rule{
+		{Op: "File.Write", After: 1, Times: 1, Fault: Fault{Partial: true}},
+		{Op: "Rename", Fault: Fault{Err: errDisk}},
+		{Op: "Remove", Fault: Fault{Panic: "boom"}},
+	}})
+	fs := Fs(afero.NewMemMapFs(), in)
+
+	f, err := fs.Create("log.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("first\n")); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	n, err := f.Write([]byte("second\n"))
+	if !errors.Is(err, io.ErrShortWrite) || n != 3 {
+		t.Errorf("partial write = %d, %v; want 3, ErrShortWrite", n, err)
+	}
+	f.Close()
+
+	data, _ := afero.ReadFile(fs, "log.txt")
+	if string(data) != "first\nsec" {
+		t.Errorf("file = %q, want the torn write", data)
+	}
+	if err := fs.Rename("log.txt", "old.txt"); !errors.Is(err, errDisk) {
+		t.Errorf("Rename = %v, want errDisk", err)
+	}
+	if _, err := fs.Stat("log.txt"); err != nil {
+		t.Errorf("the failed rename moved the file: %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recover() = %v, want boom", r)
+		}
+		if in.Injected("Remove") != 1 || in.Injected("File.Write") != 1 {
+			t.Errorf("Injected counts are wrong")
+		}
+	}()
+	fs.Remove("log.txt")
+}
+
+func TestFs_Latency(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	in := New(Options{Clock: fake, Rules: []Rule{{Op: "Open", Fault: Fault{Latency: time.Second}}}})
+	fs := Fs(afero.NewMemMapFs(), in)
+	afero.WriteFile(fs, "a.txt", []byte("a"), 0o644)
+
+	done := make(chan error)
+	go func() {
+		_, err := fs.Open("a.txt")
+		done <- err
+	}()
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("a slow Open failed: %v", err)
+	}
+}
+
+func TestUserRepository(t *testing.T) {
+	in := New(Options{Rules: []Rule{
+		{Op: "Create", Times: 1, Fault: Fault{Err: repository.ErrEmailTaken}},
+		{Op: "FindByID", Fault: Fault{Latency: time.Hour}},
+	}})
+	repo := UserRepository(repository.New(), in)
+	ctx := context.Background()
+
+	user := model.User{Name: "Alice", Email: "alice@example.com"}
+	if err := repo.Create(ctx, &user); !errors.Is(err, repository.ErrEmailTaken) {
+		t.Fatalf("first Create = %v, want the injected error", err)
+	}
+	if err := repo.Create(ctx, &user); err != nil {
+		t.Fatalf("second Create = %v", err)
+	}
+
+	// A caller with a deadline gives up on a slow repository.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := repo.FindByID(ctx, user.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FindByID = %v, want DeadlineExceeded", err)
+	}
+}
+
+type noSleep struct{}
+
+func (noSleep) Sleep(ctx context.Context, d time.Duration) error { return ctx.Err() }
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"name":"Alice","age":30}`)
+	}))
+	defer server.Close()
+
+	t.Run("retry rides out failures", func(t *testing.T) {
+		in := New(Options{Seed: 1, Rules: []Rule{{Op: "GET", Times: 2}}})
+		client := &http.Client{Transport: resilient.Chain(Transport(nil, in),
+			resilient.Retry(resilient.RetryPolicy{MaxAttempts: 3, Sleeper: noSleep{}}),
+		)}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if in.Injected("GET") != 2 {
+			t.Errorf("Injected = %d, want 2", in.Injected("GET"))
+		}
+	})
+
+	t.Run("breaker opens on a dead host", func(t *testing.T) {
+		in := New(Options{Rules: []Rule{{Op: "GET"}}})
+		client := &http.Client{Transport: resilient.Chain(Transport(nil, in),
+			resilient.CircuitBreaker(resilient.BreakerConfig{FailureThreshold: 3}),
+		)}
+		for range 3 {
+			client.Get(server.URL)
+		}
+		_, err := client.Get(server.URL)
+		if !errors.Is(err, resilient.ErrCircuitOpen) {
+			t.Errorf("err = %v, want ErrCircuitOpen", err)
+		}
+		if in.Injected("GET") != 3 {
+			t.Errorf("the open breaker let %d requests through, want 3", in.Injected("GET"))
+		}
+	})
+
+	t.Run("truncated body", func(t *testing.T) {
+		in := New(Options{Rules: []Rule{{Fault: Fault{Partial: true}}}})
+		client := &http.Client{Transport: Transport(nil, in)}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if !errors.Is(err, io.ErrUnexpectedEOF) || string(body) != `{"name":"Ali` {
+			t.Errorf("body = %q, %v; want half of it and ErrUnexpectedEOF", body, err)
+		}
+	})
+}
+
+func TestFile_CloseOnError(t *testing.T) {
+	base := afero.NewMemMapFs()
+	in := New(Options{Rules: []Rule{{Op: "File.Close", Fault: Fault{Err: os.ErrClosed}}}})
+	f, err := Fs(base, in).Create("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Close = %v", err)
+	}
+	if _, err := f.(*chaosFile).File.Write([]byte("x")); err == nil {
+		t.Error("the underlying file is still open")
+	}
+}
@@ -0,0 +1,217 @@
+package chaos
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Fs wraps base so that in decides the fate of every call, including the
+// calls on files it opens.
+func Fs(base afero.Fs, in *Injector) afero.Fs {
+	return &chaosFs{Fs: base, in: in}
+}
+
+type chaosFs struct {
+	afero.Fs
+	in *Injector
+}
+
+func (c *chaosFs) call(op string) error { return c.in.call(context.Background(), op) }
+
+func (c *chaosFs) wrap(f afero.File, err error) (afero.File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &chaosFile{File: f, in: c.in}, nil
+}
+
+func (c *chaosFs) Create(name string) (afero.File, error) {
+	if err := c.call("Create"); err != nil {
+		return nil, err
+	}
+	return c.wrap(c.Fs.Create(name))
+}
+
+func (c *chaosFs) Open(name string) (afero.File, error) {
+	if err := c.call("Open"); err != nil {
+		return nil, err
+	}
+	return c.wrap(c.Fs.Open(name))
+}
+
+func (c *chaosFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if err := c.call("OpenFile"); err != nil {
+		return nil, err
+	}
+	return c.wrap(c.Fs.OpenFile(name, flag, perm))
+}
+
+func (c *chaosFs) Mkdir(name string, perm os.FileMode) error {
+	if err := c.call("Mkdir"); err != nil {
+		return err
+	}
+	return c.Fs.Mkdir(name, perm)
+}
+
+func (c *chaosFs) MkdirAll(path string, perm os.FileMode) error {
+	if err := c.call("MkdirAll"); err != nil {
+		return err
+	}
+	return c.Fs.MkdirAll(path, perm)
+}
+
+func (c *chaosFs) Remove(name string) error {
+	if err := c.call("Remove"); err != nil {
+		return err
+	}
+	return c.Fs.Remove(name)
+}
+
+func (c *chaosFs) RemoveAll(path string) error {
+	if err := c.call("RemoveAll"); err != nil {
+		return err
+	}
+	return c.Fs.RemoveAll(path)
+}
+
+func (c *chaosFs) Rename(oldname, newname string) error {
+	if err := c.call("Rename"); err != nil {
+		return err
+	}
+	return c.Fs.Rename(oldname, newname)
+}
+
+func (c *chaosFs) Stat(name string) (os.FileInfo, error) {
+	if err := c.call("Stat"); err != nil {
+		return nil, err
+	}
+	return c.Fs.Stat(name)
+}
+
+func (c *chaosFs) Chmod(name string, mode os.FileMode) error {
+	if err := c.call("Chmod"); err != nil {
+		return err
+	}
+	return c.Fs.Chmod(name, mode)
+}
+
+func (c *chaosFs) Chown(name string, uid, gid int) error {
+	if err := c.call("Chown"); err != nil {
+		return err
+	}
+	return c.Fs.Chown(name, uid, gid)
+}
+
+func (c *chaosFs) Chtimes(name string, atime, mtime time.Time) error {
+	if err := c.call("Chtimes"); err != nil {
+		return err
+	}
+	return c.Fs.Chtimes(name, atime, mtime)
+}
+
+func (c *chaosFs) Name() string { return "chaos(" + c.Fs.Name() + ")" }
+
+type chaosFile struct {
+	afero.File
+	in *Injector
+}
+
+func (f *chaosFile) call(op string) error { return f.in.call(context.Background(), op) }
+
+// write applies a fault to a write of n bytes; do performs the write.
+func (f *chaosFile) write(op string, n int, do func(n int) (int, error)) (int, error) {
+	fault, ok, err := f.in.before(context.Background(), op)
+	if err != nil {
+		return 0, err
+	}
+	if ok && fault.Partial {
+		written, err := do(n / 2)
+		if err != nil {
+			return written, err
+		}
+		return written, fault.err(io.ErrShortWrite)
+	}
+	return do(n)
+}
+
+func (f *chaosFile) Write(p []byte) (int, error) {
+	return f.write("File.Write", len(p), func(n int) (int, error) { return f.File.Write(p[:n]) })
+}
+
+func (f *chaosFile) WriteAt(p []byte, off int64) (int, error) {
+	return f.write("File.WriteAt", len(p), func(n int) (int, error) { return f.File.WriteAt(p[:n], off) })
+}
+
+func (f *chaosFile) WriteString(s string) (int, error) {
+	return f.write("File.WriteString", len(s), func(n int) (int, error) { return f.File.WriteString(s[:n]) })
+}
+
+func (f *chaosFile) Read(p []byte) (int, error) {
+	if err := f.call("File.Read"); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
+
+func (f *chaosFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.call("File.ReadAt"); err != nil {
+		return 0, err
+	}
+	return f.File.ReadAt(p, off)
+}
+
+func (f *chaosFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.call("File.Seek"); err != nil {
+		return 0, err
+	}
+	return f.File.Seek(offset, whence)
+}
+
+func (f *chaosFile) Readdir(count int) ([]os.FileInfo, error) {
+	if err := f.call("File.Readdir"); err != nil {
+		return nil, err
+	}
+	return f.File.Readdir(count)
+}
+
+func (f *chaosFile) Readdirnames(n int) ([]string, error) {
+	if err := f.call("File.Readdirnames"); err != nil {
+		return nil, err
+	}
+	return f.File.Readdirnames(n)
+}
+
+func (f *chaosFile) Stat() (os.FileInfo, error) {
+	if err := f.call("File.Stat"); err != nil {
+		return nil, err
+	}
+	return f.File.Stat()
+}
+
+func (f *chaosFile) Sync() error {
+	if err := f.call("File.Sync"); err != nil {
+		return err
+	}
+	return f.File.Sync()
+}
+
+func (f *chaosFile) Truncate(size int64) error {
+	if err := f.call("File.Truncate"); err != nil {
+		return err
+	}
+	return f.File.Truncate(size)
+}
+
+// Close always closes the underlying file, so an injected error does not
+// leak it.
+func (f *chaosFile) Close() error {
+	err := f.call("File.Close")
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
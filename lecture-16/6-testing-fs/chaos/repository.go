@@ -0,0 +1,47 @@
+package chaos
+
+import (
+	"context"
+
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+// UserRepository wraps repo; the operations are named after its methods.
+// Latency honors the call's context.
+func UserRepository(repo repository.UserRepository, in *Injector) repository.UserRepository {
+	return &chaosRepo{repo: repo, in: in}
+}
+
+type chaosRepo struct {
+	repo repository.UserRepository
+	in   *Injector
+}
+
+func (c *chaosRepo) FindByID(ctx context.Context, id int64) (model.User, error) {
+	if err := c.in.call(ctx, "FindByID"); err != nil {
+		return model.User{}, err
+	}
+	return c.repo.FindByID(ctx, id)
+}
+
+func (c *chaosRepo) Create(ctx context.Context, user *model.User) error {
+	if err := c.in.call(ctx, "Create"); err != nil {
+		return err
+	}
+	return c.repo.Create(ctx, user)
+}
+
+func (c *chaosRepo) Update(ctx context.Context, user *model.User) error {
+	if err := c.in.call(ctx, "Update"); err != nil {
+		return err
+	}
+	return c.repo.Update(ctx, user)
+}
+
+func (c *chaosRepo) List(ctx context.Context, afterID int64, limit int) ([]model.User, error) {
+	if err := c.in.call(ctx, "List"); err != nil {
+		return nil, err
+	}
+	return c.repo.List(ctx, afterID, limit)
+}
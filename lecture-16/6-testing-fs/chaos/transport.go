@@ -0,0 +1,60 @@
+package chaos
+
+import (
+	"io"
+	"net/http"
+)
+
+// Transport wraps base, http.DefaultTransport if nil; the operation is the
+// request method. An error fault looks like a connection failure to the
+// client. Latency honors the request's context.
+func Transport(base http.RoundTripper, in *Injector) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &chaosTransport{base: base, in: in}
+}
+
+type chaosTransport struct {
+	base http.RoundTripper
+	in   *Injector
+}
+
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, ok, err := t.in.before(req.Context(), req.Method)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !ok || !fault.Partial {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &brokenBody{data: body[:len(body)/2], err: fault.err(io.ErrUnexpectedEOF)}
+	return resp, nil
+}
+
+// brokenBody yields data and then err, like a connection that dropped.
+type brokenBody struct {
+	data []byte
+	err  error
+}
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, b.err
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+func (b *brokenBody) Close() error { return nil }
@@ -0,0 +1,225 @@
+// Package textio counts and reads lines of text from an io.Reader or a
+// file on an afero.Fs. Unlike bufio.Scanner it has no limit on the line
+// length unless one is asked for, treats "\r\n" as a single terminator and
+// reads gzip-compressed input transparently.
+package textio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrLineTooLong is returned for a line longer than Options.MaxLineLength.
+var ErrLineTooLong = errors.New("textio: line too long")
+
+// Counts describes a text. A line is terminated by "\n" or "\r\n"; a lone
+// "\r" is an ordinary character. Lengths are in bytes and do not include
+// the terminator.
+type Counts struct {
+	// Lines counts the last line even without a terminator, so "a\nb"
+	// has two lines, as bufio.Scanner sees it.
+	Lines int64
+	// Newlines counts "\n" characters, as wc -l does: "a\nb" has one.
+	Newlines int64
+	// CRLF counts the lines terminated by "\r\n".
+	CRLF int64
+	// Words are runs of runes that are not unicode.IsSpace.
+	Words int64
+	// Runes are UTF-8 sequences; every byte of an invalid one is a rune
+	// of its own, as utf8.DecodeRune decodes it.
+	Runes int64
+	// Bytes of the text, after decompression for gzip input.
+	Bytes int64
+	// LongestLine is the length of the longest line.
+	LongestLine int64
+}
+
+func (c *Counts) add(o Counts) {
+	c.Lines += o.Lines
+	c.Newlines += o.Newlines
+	c.CRLF += o.CRLF
+	c.Words += o.Words
+	c.Runes += o.Runes
+	c.Bytes += o.Bytes
+	c.LongestLine = max(c.LongestLine, o.LongestLine)
+}
+
+// Options configures counting and reading. Zero fields take the defaults
+// noted below.
+type Options struct {
+	// MaxLineLength makes a longer line fail with ErrLineTooLong. Zero
+	// means no limit.
+	MaxLineLength int64
+	// Workers is the number of goroutines CountFile uses for a large
+	// uncompressed file, GOMAXPROCS by default. 1 counts sequentially.
+	Workers int
+	// ChunkSize is the least number of bytes a worker gets, 1 MiB by
+	// default; smaller files are counted sequentially.
+	ChunkSize int64
+}
+
+func (o *Options) setDefaults() {
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = 1 << 20
+	}
+}
+
+// Count reads r to the end and counts its text. Input that starts with
+// the gzip header is decompressed first.
+func Count(r io.Reader, opts Options) (Counts, error) {
+	src, closeSrc, err := decompress(r)
+	if err != nil {
+		return Counts{}, err
+	}
+	defer closeSrc()
+
+	k := counter{max: opts.MaxLineLength}
+	if _, err := io.Copy(&k, src); err != nil {
+		return Counts{}, k.wrap(err, 0)
+	}
+	if err := k.finish(); err != nil {
+		return Counts{}, k.wrap(err, 0)
+	}
+	return k.c, nil
+}
+
+// gzipMagic starts every gzip stream: ID1, ID2 and the deflate method.
+var gzipMagic = []byte{0x1f, 0x8b, 8}
+
+// decompress returns r itself or, if it holds gzip, its decompressed
+// content. Multiple concatenated gzip members are read as one stream.
+func decompress(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	if head, _ := br.Peek(len(gzipMagic)); !bytes.Equal(head, gzipMagic) {
+		return br, func() {}, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, nil, fmt.Errorf("textio: %w", err)
+	}
+	return zr, func() { zr.Close() }, nil
+}
+
+// counter counts the text written to it. A chunk may end in the middle of
+// a UTF-8 sequence; its bytes wait in partial for the next Write.
+type counter struct {
+	max int64
+
+	c       Counts
+	lineLen int64
+	cr      bool // the last rune was '\r'
+	inWord  bool
+	partial [utf8.UTFMax]byte
+	np      int
+}
+
+func (k *counter) Write(p []byte) (int, error) {
+	n := len(p)
+	for k.np > 0 && len(p) > 0 {
+		var tmp [2 * utf8.UTFMax]byte
+		buf := append(tmp[:0], k.partial[:k.np]...)
+		buf = append(buf, p[:min(len(p), utf8.UTFMax)]...)
+		if !utf8.FullRune(buf) {
+			k.np += copy(k.partial[k.np:], p)
+			return n, nil
+		}
+		r, size := utf8.DecodeRune(buf)
+		if err := k.rune(r, size); err != nil {
+			return 0, err
+		}
+		if size >= k.np {
+			p = p[size-k.np:]
+			k.np = 0
+		} else {
+			k.np = copy(k.partial[:], k.partial[size:k.np])
+		}
+	}
+
+	for i := 0; i < len(p); {
+		r, size := rune(p[i]), 1
+		if r >= utf8.RuneSelf {
+			if !utf8.FullRune(p[i:]) {
+				k.np = copy(k.partial[:], p[i:])
+				return n, nil
+			}
+			r, size = utf8.DecodeRune(p[i:])
+		}
+		if err := k.rune(r, size); err != nil {
+			return 0, err
+		}
+		i += size
+	}
+	return n, nil
+}
+
+func (k *counter) rune(r rune, size int) error {
+	k.c.Bytes += int64(size)
+	k.c.Runes++
+
+	if r == '\n' {
+		k.c.Newlines++
+		k.c.Lines++
+		if k.cr {
+			k.c.CRLF++
+			k.lineLen--
+		}
+		k.c.LongestLine = max(k.c.LongestLine, k.lineLen)
+		k.lineLen = 0
+		k.cr = false
+		k.inWord = false
+		return nil
+	}
+
+	k.lineLen += int64(size)
+	k.cr = r == '\r'
+	// A trailing '\r' may turn out to be part of the terminator.
+	if n := k.lineLen; k.max > 0 && (n > k.max+1 || n == k.max+1 && !k.cr) {
+		return ErrLineTooLong
+	}
+
+	space := unicode.IsSpace(r)
+	if !space && !k.inWord {
+		k.c.Words++
+	}
+	k.inWord = !space
+	return nil
+}
+
+// finish counts an incomplete UTF-8 sequence and a last line without a
+// terminator.
+func (k *counter) finish() error {
+	for k.np > 0 {
+		r, size := utf8.DecodeRune(k.partial[:k.np])
+		if err := k.rune(r, size); err != nil {
+			return err
+		}
+		k.np = copy(k.partial[:], k.partial[size:k.np])
+	}
+	if k.max > 0 && k.lineLen > k.max {
+		return ErrLineTooLong
+	}
+	if k.lineLen > 0 {
+		k.c.Lines++
+		k.c.LongestLine = max(k.c.LongestLine, k.lineLen)
+	}
+	return nil
+}
+
+// wrap adds the 1-based line number to ErrLineTooLong; lines is the
+// number of lines before the text the counter saw.
+func (k *counter) wrap(err error, lines int64) error {
+	if errors.Is(err, ErrLineTooLong) {
+		return fmt.Errorf("%w: line %d", ErrLineTooLong, lines+k.c.Newlines+1)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package textio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// CountFile counts the text of the named file. A large uncompressed file
+// is split into chunks that end right after a newline and counted by
+// opts.Workers goroutines, each with its own handle, so lines, words and
+// runes never span two chunks.
+func CountFile(fs afero.Fs, name string, opts Options) (Counts, error) {
+	opts.setDefaults()
+	f, err := fs.Open(name)
+	if err != nil {
+		return Counts{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Counts{}, err
+	}
+	if opts.Workers == 1 || info.Size() < 2*opts.ChunkSize || isGzip(f) {
+		return Count(f, opts)
+	}
+
+	bounds, err := chunkBounds(f, info.Size(), opts)
+	if err != nil {
+		return Counts{}, err
+	}
+	return countChunks(fs, name, bounds, opts)
+}
+
+func isGzip(r io.ReaderAt) bool {
+	head := make([]byte, len(gzipMagic))
+	n, _ := r.ReadAt(head, 0)
+	return bytes.Equal(head[:n], gzipMagic)
+}
+
+// chunkBounds returns the offsets that split a file of the given size
+// into at most opts.Workers chunks of at least opts.ChunkSize bytes. The
+// first offset is 0 and the last is size; the rest follow a '\n'. A
+// line longer than a chunk makes the chunk longer.
+func chunkBounds(r io.ReaderAt, size int64, opts Options) ([]int64, error) {
+	n := min(int64(opts.Workers), size/opts.ChunkSize)
+	bounds := []int64{0}
+	buf := make([]byte, 32<<10)
+	for i := int64(1); i < n; i++ {
+		off := max(size*i/n, bounds[len(bounds)-1])
+		next, err := nextLine(r, off, buf)
+		if err != nil {
+			return nil, err
+		}
+		if next >= size {
+			break
+		}
+		if next > bounds[len(bounds)-1] {
+			bounds = append(bounds, next)
+		}
+	}
+	return append(bounds, size), nil
+}
+
+// nextLine returns the offset after the first '\n' at or after off, or
+// the size of the file if there is none.
+func nextLine(r io.ReaderAt, off int64, buf []byte) (int64, error) {
+	for {
+		n, err := r.ReadAt(buf, off)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return off + int64(i) + 1, nil
+		}
+		off += int64(n)
+		if err == io.EOF {
+			return off, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+type chunk struct {
+	k   counter
+	err error
+}
+
+func countChunks(fs afero.Fs, name string, bounds []int64, opts Options) (Counts, error) {
+	chunks := make([]chunk, len(bounds)-1)
+	var wg sync.WaitGroup
+	for i := range chunks {
+		wg.Go(func() {
+			ch := &chunks[i]
+			ch.k.max = opts.MaxLineLength
+			f, err := fs.Open(name)
+			if err != nil {
+				ch.err = err
+				return
+			}
+			defer f.Close()
+			section := io.NewSectionReader(f, bounds[i], bounds[i+1]-bounds[i])
+			if _, ch.err = io.Copy(&ch.k, section); ch.err == nil {
+				ch.err = ch.k.finish()
+			}
+		})
+	}
+	wg.Wait()
+
+	// Report the first failure in the file, with the line numbers of the
+	// chunks before it.
+	var total Counts
+	for _, ch := range chunks {
+		if ch.err != nil {
+			return Counts{}, ch.k.wrap(ch.err, total.Newlines)
+		}
+		total.add(ch.k.c)
+	}
+	return total, nil
+}
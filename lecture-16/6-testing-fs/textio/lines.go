@@ -0,0 +1,76 @@
+package textio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Lines returns the lines of r without their terminators, decompressing
+// gzip input. The lines are the ones Counts.Lines counts. On a read error
+// or a line longer than opts.MaxLineLength the iteration yields the error
+// with an empty line and stops; a long line is never held in memory whole.
+func Lines(r io.Reader, opts Options) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		src, closeSrc, err := decompress(r)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		defer closeSrc()
+		br, ok := src.(*bufio.Reader)
+		if !ok {
+			br = bufio.NewReader(src)
+		}
+
+		var line []byte
+		for n := int64(1); ; n++ {
+			line, err = readLine(br, line[:0], opts.MaxLineLength)
+			if err == ErrLineTooLong {
+				yield("", fmt.Errorf("%w: line %d", ErrLineTooLong, n))
+				return
+			}
+			if err != nil && err != io.EOF {
+				yield("", err)
+				return
+			}
+			if len(line) == 0 && err == io.EOF {
+				return
+			}
+			if !yield(string(trimEOL(line)), nil) || err == io.EOF {
+				return
+			}
+		}
+	}
+}
+
+// readLine appends the next line of br, with its terminator, to line.
+func readLine(br *bufio.Reader, line []byte, maxLen int64) ([]byte, error) {
+	for {
+		frag, err := br.ReadSlice('\n')
+		line = append(line, frag...)
+		if maxLen > 0 {
+			// An unfinished line may still lose a trailing '\r' to the
+			// terminator.
+			n := int64(len(trimEOL(line)))
+			if n > maxLen+1 || n > maxLen && (err != bufio.ErrBufferFull || line[len(line)-1] != '\r') {
+				return line, ErrLineTooLong
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// trimEOL cuts "\n" or "\r\n" off the end of line.
+func trimEOL(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+	}
+	return line
+}
@@ -0,0 +1,304 @@
+package textio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/spf13/afero"
+
+	"ITMO-students/lecture-16/6-testing-fs/chaos"
+)
+
+var countTests = []struct {
+	name  string
+	text  string
+	want  Counts
+	lines []string
+}{
+	{"empty", "", Counts{}, nil},
+	{"only newline", "\n", Counts{Lines: 1, Newlines: 1, Runes: 1, Bytes: 1}, []string{""}},
+	{"no newline", "a b", Counts{Lines: 1, Words: 2, Runes: 3, Bytes: 3, LongestLine: 3}, []string{"a b"}},
+	{"trailing newline", "a\nbc\n", Counts{Lines: 2, Newlines: 2, Words: 2, Runes: 5, Bytes: 5, LongestLine: 2}, []string{"a", "bc"}},
+	{"no trailing newline", "a\nbc", Counts{Lines: 2, Newlines: 1, Words: 2, Runes: 4, Bytes: 4, LongestLine: 2}, []string{"a", "bc"}},
+	{"empty lines", "\n\na\n\n", Counts{Lines: 4, Newlines: 4, Words: 1, Runes: 5, Bytes: 5, LongestLine: 1}, []string{"", "", "a", ""}},
+	{"crlf", "ab\r\ncd\r\n", Counts{Lines: 2, Newlines: 2, CRLF: 2, Words: 2, Runes: 8, Bytes: 8, LongestLine: 2}, []string{"ab", "cd"}},
+	{"mixed endings", "ab\r\ncd\nef", Counts{Lines: 3, Newlines: 2, CRLF: 1, Words: 3, Runes: 9, Bytes: 9, LongestLine: 2}, []string{"ab", "cd", "ef"}},
+	{"lone cr", "a\rb\r", Counts{Lines: 1, Words: 2, Runes: 4, Bytes: 4, LongestLine: 4}, []string{"a\rb\r"}},
+	{"spaces", " \t one  two\u00a0three \n", Counts{Lines: 1, Newlines: 1, Words: 3, Runes: 19, Bytes: 20, LongestLine: 19}, []string{" \t one  two\u00a0three "}},
+	{"unicode", "привет, 世界\n", Counts{Lines: 1, Newlines: 1, Words: 2, Runes: 11, Bytes: 21, LongestLine: 20}, []string{"привет, 世界"}},
+	{"invalid utf-8", "a\xffb\xe2\x82\n", Counts{Lines: 1, Newlines: 1, Words: 1, Runes: 6, Bytes: 6, LongestLine: 5}, []string{"a\xffb\xe2\x82"}},
+	{"truncated rune at end", "ab\xe2\x82", Counts{Lines: 1, Words: 1, Runes: 4, Bytes: 4, LongestLine: 4}, []string{"ab\xe2\x82"}},
+}
+
+func TestCount(t *testing.T) {
+	for _, tt := range countTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Count(strings.NewReader(tt.text), Options{})
+			if err != nil {
+				t.Fatalf("Count: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Count = %+v\nwant    %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Куски чтения режут строки и UTF-8 последовательности в любом месте,
+// результат от этого не зависит.
+func TestCount_SplitReads(t *testing.T) {
+	for _, tt := range countTests {
+		for name, r := range map[string]io.Reader{
+			"one byte": iotest.OneByteReader(strings.NewReader(tt.text)),
+			"half":     iotest.HalfReader(strings.NewReader(tt.text)),
+		} {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				got, err := Count(r, Options{})
+				if err != nil {
+					t.Fatalf("Count: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("Count = %+v\nwant    %+v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// bufio.Scanner не читает строки длиннее 64 КБ.
+func TestCount_LongLine(t *testing.T) {
+	text := strings.Repeat("x", 1<<20) + "\nshort\n"
+	got, err := Count(strings.NewReader(text), Options{})
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if got.Lines != 2 || got.LongestLine != 1<<20 {
+		t.Errorf("Lines = %d, LongestLine = %d, want 2 and %d", got.Lines, got.LongestLine, 1<<20)
+	}
+}
+
+func TestCount_MaxLineLength(t *testing.T) {
+	tests := []struct {
+		text string
+		line int64 // 0 if the text fits
+	}{
+		{"abc\nabc", 0},
+		{"abc\r\nabc\r\n", 0},
+		{"abcd", 1},
+		{"abc\nabcd\n", 2},
+		{"a\r\n\r\nabc\rd\r\n", 3},
+		{"abc\r", 1},
+		{"абв\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.text), func(t *testing.T) {
+			_, err := Count(strings.NewReader(tt.text), Options{MaxLineLength: 3})
+			checkTooLong(t, err, tt.line)
+
+			var lineErr error
+			for _, err := range Lines(strings.NewReader(tt.text), Options{MaxLineLength: 3}) {
+				lineErr = err
+			}
+			checkTooLong(t, lineErr, tt.line)
+		})
+	}
+}
+
+func checkTooLong(t *testing.T, err error, line int64) {
+	t.Helper()
+	switch {
+	case line == 0 && err != nil:
+		t.Errorf("unexpected error: %v", err)
+	case line == 0:
+	case !errors.Is(err, ErrLineTooLong):
+		t.Errorf("err = %v, want ErrLineTooLong", err)
+	case !strings.HasSuffix(err.Error(), fmt.Sprintf("line %d", line)):
+		t.Errorf("err = %v, want it at line %d", err, line)
+	}
+}
+
+func gzipped(t *testing.T, parts ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, p := range parts {
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestCount_Gzip(t *testing.T) {
+	text := "привет\r\nмир\n" + strings.Repeat("word ", 10000)
+	want, err := Count(strings.NewReader(text), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Несколько gzip-членов подряд читаются как один поток, как у gunzip.
+	got, err := Count(bytes.NewReader(gzipped(t, text[:7], text[7:])), Options{})
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if got != want {
+		t.Errorf("Count = %+v\nwant    %+v", got, want)
+	}
+
+	corrupt := gzipped(t, text)[:100]
+	if _, err := Count(bytes.NewReader(corrupt), Options{}); err == nil {
+		t.Error("expected an error for truncated gzip")
+	}
+}
+
+func TestLines(t *testing.T) {
+	for _, tt := range countTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for line, err := range Lines(iotest.HalfReader(strings.NewReader(tt.text)), Options{}) {
+				if err != nil {
+					t.Fatalf("Lines: %v", err)
+				}
+				got = append(got, line)
+			}
+			if !slices.Equal(got, tt.lines) {
+				t.Errorf("Lines = %q, want %q", got, tt.lines)
+			}
+			if int64(len(got)) != tt.want.Lines {
+				t.Errorf("%d lines, Counts.Lines = %d", len(got), tt.want.Lines)
+			}
+		})
+	}
+}
+
+func TestLines_Break(t *testing.T) {
+	var got []string
+	for line, err := range Lines(bytes.NewReader(gzipped(t, "a\nb\nc\n")), Options{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got = append(got, line); len(got) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Lines = %q", got)
+	}
+}
+
+// randomText builds text with the cases that chunk boundaries could
+// break: CRLF, multi-byte runes, invalid bytes, long and empty lines.
+func randomText(r *rand.Rand, size int) string {
+	pieces := []string{"word", " ", "\t", "\n", "\r\n", "\r", "ж", "世界", "\xff", "\n\n", strings.Repeat("y", 300)}
+	var b strings.Builder
+	for b.Len() < size {
+		b.WriteString(pieces[r.IntN(len(pieces))])
+	}
+	return b.String()
+}
+
+func TestCountFile_Parallel(t *testing.T) {
+	for seed := range uint64(20) {
+		r := rand.New(rand.NewPCG(seed, 0))
+		text := randomText(r, 1000+r.IntN(20000))
+		fs := afero.NewMemMapFs()
+		afero.WriteFile(fs, "big.txt", []byte(text), 0o644)
+
+		want, err := Count(strings.NewReader(text), Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := CountFile(fs, "big.txt", Options{Workers: 1 + r.IntN(16), ChunkSize: 64})
+		if err != nil {
+			t.Fatalf("seed %d: CountFile: %v", seed, err)
+		}
+		if got != want {
+			t.Errorf("seed %d: CountFile = %+v\nwant          %+v", seed, got, want)
+		}
+
+		// Ошибка длинной строки указывает на ту же строку, что и без чанков.
+		_, seqErr := Count(strings.NewReader(text), Options{MaxLineLength: 200})
+		_, parErr := CountFile(fs, "big.txt", Options{MaxLineLength: 200, Workers: 8, ChunkSize: 64})
+		if fmt.Sprint(seqErr) != fmt.Sprint(parErr) {
+			t.Errorf("seed %d: parallel error %v, sequential %v", seed, parErr, seqErr)
+		}
+	}
+}
+
+func TestChunkBounds(t *testing.T) {
+	text := "aaaa\nbbbbbbbbbbbbbbbbbbbbbbbb\ncc\ndddd\n" + strings.Repeat("e", 40)
+	bounds, err := chunkBounds(strings.NewReader(text), int64(len(text)), Options{Workers: 4, ChunkSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds[0] != 0 || bounds[len(bounds)-1] != int64(len(text)) {
+		t.Fatalf("bounds = %v, want from 0 to %d", bounds, len(text))
+	}
+	for _, b := range bounds[1 : len(bounds)-1] {
+		if text[b-1] != '\n' {
+			t.Errorf("bound %d does not follow a newline: %v", b, bounds)
+		}
+	}
+	if !slices.IsSorted(bounds) || len(slices.Compact(slices.Clone(bounds))) != len(bounds) {
+		t.Errorf("bounds = %v, want strictly increasing", bounds)
+	}
+}
+
+func TestCountFile_Gzip(t *testing.T) {
+	text := strings.Repeat("line one\r\n", 1000)
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "log.gz", gzipped(t, text), 0o644)
+
+	got, err := CountFile(fs, "log.gz", Options{ChunkSize: 16})
+	if err != nil {
+		t.Fatalf("CountFile: %v", err)
+	}
+	if got.Lines != 1000 || got.CRLF != 1000 || got.Bytes != int64(len(text)) {
+		t.Errorf("CountFile = %+v", got)
+	}
+}
+
+func TestCountFile_Failures(t *testing.T) {
+	errIO := errors.New("input/output error")
+	text := randomText(rand.New(rand.NewPCG(1, 1)), 5000)
+	tests := []struct {
+		name string
+		opts Options
+		rule chaos.Rule
+	}{
+		{"not exists", Options{}, chaos.Rule{}},
+		{"sequential read", Options{Workers: 1}, chaos.Rule{Op: "File.Read", Fault: chaos.Fault{Err: errIO}}},
+		{"chunk read", Options{Workers: 4, ChunkSize: 64}, chaos.Rule{Op: "File.ReadAt", After: 5, Fault: chaos.Fault{Err: errIO}}},
+		{"worker open", Options{Workers: 4, ChunkSize: 64}, chaos.Rule{Op: "Open", After: 2, Fault: chaos.Fault{Err: errIO}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := afero.NewMemMapFs()
+			name := "missing.txt"
+			if tt.rule.Fault.Err != nil {
+				name = "text.txt"
+				afero.WriteFile(base, name, []byte(text), 0o644)
+			}
+			fs := chaos.Fs(base, chaos.New(chaos.Options{Rules: []chaos.Rule{tt.rule}}))
+
+			_, err := CountFile(fs, name, tt.opts)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.rule.Fault.Err != nil && !errors.Is(err, errIO) {
+				t.Errorf("err = %v, want the injected error", err)
+			}
+		})
+	}
+}
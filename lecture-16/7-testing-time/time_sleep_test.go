@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
 )
 
 type Sleeper interface {
@@ -45,3 +47,26 @@ func TestCountdown(t *testing.T) {
 		t.Errorf("expected sleeps %v, got %v", expected, sleeper.durations)
 	}
 }
+
+// clock.Fake тоже подходит как Sleeper: Countdown действительно ждёт,
+// а тест двигает время сам, дождавшись, пока горутина уснёт.
+func TestCountdown_FakeClock(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+
+	done := make(chan struct{})
+	go func() {
+		Countdown(fake, 3)
+		close(done)
+	}()
+
+	for range 3 {
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+	}
+	<-done
+
+	if got := fake.Since(start); got != 3*time.Second {
+		t.Errorf("expected 3s to pass, got %v", got)
+	}
+}
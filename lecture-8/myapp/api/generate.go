@@ -0,0 +1,4 @@
+// Package api holds protobuf definitions; run go generate to rebuild the Go code.
+package api
+
+//go:generate buf generate
@@ -0,0 +1,271 @@
+// Package cache is an in-process, size-bounded LRU cache with TTLs,
+// negative caching and collapsing of concurrent loads for the same key.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+var errLoadPanicked = errors.New("cache: load panicked")
+
+// Options configures a Cache. Zero fields take the defaults noted below.
+type Options struct {
+	// Size is the maximum number of entries, negative ones included. Default 1024.
+	Size int
+	// TTL is how long a loaded value is served. Default 1 minute.
+	TTL time.Duration
+	// NegativeTTL is how long a load error matching IsNegative is served.
+	// Zero disables negative caching.
+	NegativeTTL time.Duration
+	// IsNegative reports whether a load error means "no such key" and may be
+	// cached. Other errors are returned to the caller and never cached.
+	IsNegative func(error) bool
+	// LoadTimeout bounds a load, which outlives the callers that started
+	// it. Default 30s.
+	LoadTimeout time.Duration
+	// Clock drives the TTLs. Default clock.Real().
+	Clock clock.Clock
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	err       error
+	expiresAt time.Time
+}
+
+// call is a load in progress; waiters block on done.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// panicked is what load panicked with, if it did.
+	panicked any
+	// forgotten is set by Set and Delete so the result is not stored.
+	forgotten bool
+}
+
+// Cache is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	opts Options
+
+	mu     sync.Mutex
+	ll     *list.List // front is most recently used
+	items  map[K]*list.Element
+	flight map[K]*call[V]
+
+	hits, misses, evictions atomic.Int64
+}
+
+func New[K comparable, V any](opts Options) *Cache[K, V] {
+	if opts.Size <= 0 {
+		opts.Size = 1024
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.IsNegative == nil {
+		opts.NegativeTTL = 0
+	}
+	if opts.LoadTimeout <= 0 {
+		opts.LoadTimeout = 30 * time.Second
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	return &Cache[K, V]{
+		opts:   opts,
+		ll:     list.New(),
+		items:  make(map[K]*list.Element),
+		flight: make(map[K]*call[V]),
+	}
+}
+
+// Get returns the cached value for key. ok is false if the key is absent,
+// expired or negatively cached.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.lookup(key)
+	if !ok || e.err != nil {
+		c.misses.Add(1)
+		return value, false
+	}
+	c.hits.Add(1)
+	return e.value, true
+}
+
+// Set stores value under key for the cache's TTL. Like Delete, it makes
+// a load already in flight for key return without storing its result,
+// which may be older than value.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forget(key)
+	c.store(key, value, nil, c.opts.TTL)
+}
+
+// Delete removes key and makes any load already in flight for it
+// return its result without storing it, so a stale read that started
+// before a write cannot repopulate the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+	c.forget(key)
+}
+
+// forget detaches the load in flight for key, if any; c.mu must be held.
+func (c *Cache[K, V]) forget(key K) {
+	if cl, ok := c.flight[key]; ok {
+		cl.forgotten = true
+		delete(c.flight, key)
+	}
+}
+
+// GetOrLoad returns the cached result for key or calls load once for all
+// concurrent callers asking for the same key. Every caller, the one that
+// started the load included, stops waiting when its ctx is done. The load
+// runs in its own goroutine with the first caller's ctx values but not its
+// cancellation, so one impatient caller does not fail the rest; it is
+// bounded by Options.LoadTimeout instead. If load panics, the first caller
+// panics with the same value and the others get an error.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if e, ok := c.lookup(key); ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return e.value, e.err
+	}
+	c.misses.Add(1)
+
+	if cl, ok := c.flight[key]; ok {
+		c.mu.Unlock()
+		return c.wait(ctx, cl, false)
+	}
+
+	cl := &call[V]{done: make(chan struct{})}
+	c.flight[key] = cl
+	c.mu.Unlock()
+
+	go c.load(ctx, key, cl, load)
+	return c.wait(ctx, cl, true)
+}
+
+func (c *Cache[K, V]) wait(ctx context.Context, cl *call[V], first bool) (V, error) {
+	select {
+	case <-cl.done:
+		if first && cl.panicked != nil {
+			panic(cl.panicked)
+		}
+		return cl.value, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K, cl *call[V], load func(context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			cl.panicked = r
+			cl.err = errLoadPanicked
+		}
+
+		c.mu.Lock()
+		if !cl.forgotten {
+			delete(c.flight, key)
+			switch {
+			case cl.panicked != nil:
+			case cl.err == nil:
+				c.store(key, cl.value, nil, c.opts.TTL)
+			case c.opts.NegativeTTL > 0 && c.opts.IsNegative(cl.err):
+				c.store(key, cl.value, cl.err, c.opts.NegativeTTL)
+			}
+		}
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.opts.LoadTimeout)
+	defer cancel()
+	cl.value, cl.err = load(ctx)
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// lookup must be called with c.mu held.
+func (c *Cache[K, V]) lookup(key K) (*entry[K, V], bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry[K, V])
+	if !c.opts.Clock.Now().Before(e.expiresAt) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e, true
+}
+
+// store must be called with c.mu held.
+func (c *Cache[K, V]) store(key K, value V, err error, ttl time.Duration) {
+	e := &entry[K, V]{key: key, value: value, err: err, expiresAt: c.opts.Clock.Now().Add(ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(e)
+
+	for c.ll.Len() > c.opts.Size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[K, V]).key)
+		c.evictions.Add(1)
+	}
+}
+
+// Stats is a snapshot of cache counters.
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Size      int   `json:"size"`
+}
+
+// HitRatio is Hits / (Hits + Misses), or 0 before the first lookup.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      c.Len(),
+	}
+}
@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+var errMissing = errors.New("missing")
+
+func TestCache_LRU(t *testing.T) {
+	c := New[string, int](Options{Size: 2})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	_, ok := c.Get("a") // a becomes most recently used
+	require.True(t, ok)
+	c.Set("c", 3)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, int64(1), c.Stats().Evictions)
+}
+
+func TestCache_TTL(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := New[string, int](Options{TTL: time.Minute, Clock: clk})
+
+	c.Set("a", 1)
+	clk.Advance(59 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	clk.Advance(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := New[int, string](Options{
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+		IsNegative:  func(err error) bool { return errors.Is(err, errMissing) },
+		Clock:       clk,
+	})
+	ctx := context.Background()
+
+	var loads int
+	load := func(v string, err error) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			loads++
+			return v, err
+		}
+	}
+
+	t.Run("value is cached", func(t *testing.T) {
+		v, err := c.GetOrLoad(ctx, 1, load("one", nil))
+		require.NoError(t, err)
+		v, err = c.GetOrLoad(ctx, 1, load("other", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "one", v)
+		assert.Equal(t, 1, loads)
+	})
+
+	t.Run("not found is cached for NegativeTTL", func(t *testing.T) {
+		loads = 0
+		_, err := c.GetOrLoad(ctx, 2, load("", errMissing))
+		assert.ErrorIs(t, err, errMissing)
+		_, err = c.GetOrLoad(ctx, 2, load("two", nil))
+		assert.ErrorIs(t, err, errMissing)
+		assert.Equal(t, 1, loads)
+
+		clk.Advance(10 * time.Second)
+		v, err := c.GetOrLoad(ctx, 2, load("two", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "two", v)
+	})
+
+	t.Run("other errors are not cached", func(t *testing.T) {
+		loads = 0
+		_, err := c.GetOrLoad(ctx, 3, load("", errors.New("db down")))
+		assert.Error(t, err)
+		v, err := c.GetOrLoad(ctx, 3, load("three", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "three", v)
+		assert.Equal(t, 2, loads)
+	})
+}
+
+func TestCache_Singleflight(t *testing.T) {
+	c := New[int, int](Options{})
+	release := make(chan struct{})
+	var loads atomic.Int32
+
+	load := func(context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad(context.Background(), 1, load)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return c.Stats().Misses == 10 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	for _, r := range results {
+		assert.Equal(t, 42, r)
+	}
+}
+
+func TestCache_DeleteDuringLoad(t *testing.T) {
+	c := New[int, string](Options{})
+	started, release := make(chan struct{}), make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.GetOrLoad(context.Background(), 1, func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+	}()
+
+	<-started
+	c.Delete(1)
+	close(release)
+	<-done
+
+	_, ok := c.Get(1)
+	assert.False(t, ok, "a load that raced with Delete must not be stored")
+}
+
+func TestCache_SetDuringLoad(t *testing.T) {
+	c := New[int, string](Options{})
+	started, release := make(chan struct{}), make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.GetOrLoad(context.Background(), 1, func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+	}()
+
+	<-started
+	c.Set(1, "fresh")
+	close(release)
+	<-done
+
+	v, ok := c.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, "fresh", v, "a load that raced with Set must not overwrite it")
+}
+
+func TestCache_FirstCallerCanceled(t *testing.T) {
+	c := New[int, int](Options{})
+	started, release := make(chan struct{}), make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go c.GetOrLoad(ctx, 1, func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, ctx.Err()
+	})
+	<-started
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(context.Background(), 1, func(context.Context) (int, error) { return 2, nil })
+		waiter <- err
+	}()
+	require.Eventually(t, func() bool { return c.Stats().Misses == 2 }, time.Second, time.Millisecond)
+
+	cancel()
+	close(release)
+	assert.NoError(t, <-waiter, "the first caller's cancellation must not reach other waiters")
+	v, ok := c.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestCache_FirstCallerContext(t *testing.T) {
+	c := New[int, int](Options{LoadTimeout: 50 * time.Millisecond})
+	loadErr := make(chan error, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := c.GetOrLoad(ctx, 1, func(ctx context.Context) (int, error) {
+		<-ctx.Done() // a hung database call
+		loadErr <- ctx.Err()
+		return 0, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the first caller returns on its own ctx")
+
+	select {
+	case err := <-loadErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("the load is not bounded by LoadTimeout")
+	}
+}
+
+func TestCache_WaiterContext(t *testing.T) {
+	c := New[int, int](Options{})
+	release := make(chan struct{})
+	defer close(release)
+
+	go c.GetOrLoad(context.Background(), 1, func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	require.Eventually(t, func() bool { return c.Stats().Misses == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.GetOrLoad(ctx, 1, func(context.Context) (int, error) { return 2, nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCache_LoadPanics(t *testing.T) {
+	c := New[int, int](Options{})
+
+	assert.Panics(t, func() {
+		c.GetOrLoad(context.Background(), 1, func(context.Context) (int, error) { panic("boom") })
+	})
+
+	v, err := c.GetOrLoad(context.Background(), 1, func(context.Context) (int, error) { return 7, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 7, v)
+}
+
+func TestStats_HitRatio(t *testing.T) {
+	assert.Equal(t, 0.0, Stats{}.HitRatio())
+	assert.Equal(t, 0.75, Stats{Hits: 3, Misses: 1}.HitRatio())
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+)
+
+// Store is a byte-oriented backend shared between replicas, such as Redis.
+type Store interface {
+	// Get returns the value under key; ok is false if it is absent or expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key; a zero ttl means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Add is Set only if key is absent; added reports whether it stored value.
+	Add(ctx context.Context, key string, value []byte, ttl time.Duration) (added bool, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Codec turns cached values into bytes for a Store.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+var (
+	// JSON is readable with redis-cli and by other languages.
+	JSON Codec = jsonCodec{}
+	// Gob is more compact but Go-only and sensitive to type renames.
+	Gob Codec = gobCodec{}
+)
+
+// Shared is a typed view of a Store: values are encoded with Codec and
+// keys are prefixed so that several caches can share one backend.
+type Shared[V any] struct {
+	store  Store
+	codec  Codec
+	prefix string
+	ttl    time.Duration
+}
+
+func NewShared[V any](store Store, codec Codec, prefix string, ttl time.Duration) *Shared[V] {
+	return &Shared[V]{store: store, codec: codec, prefix: prefix, ttl: ttl}
+}
+
+func (s *Shared[V]) Get(ctx context.Context, key string) (value V, ok bool, err error) {
+	data, ok, err := s.store.Get(ctx, s.prefix+key)
+	if err != nil || !ok {
+		return value, false, err
+	}
+	if err := s.codec.Unmarshal(data, &value); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+func (s *Shared[V]) Set(ctx context.Context, key string, value V) error {
+	data, err := s.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, s.prefix+key, data, s.ttl)
+}
+
+// Add stores value only if key is absent, so a fill from a read cannot
+// overwrite a newer value stored by a write.
+func (s *Shared[V]) Add(ctx context.Context, key string, value V) (bool, error) {
+	data, err := s.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return s.store.Add(ctx, s.prefix+key, data, s.ttl)
+}
+
+func (s *Shared[V]) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, s.prefix+key)
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/handler"
+	"ITMO-students/lecture-8/myapp/middleware"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/service"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Idempotency(repository.NewMemoryIdempotency(), middleware.DefaultIdempotencyTTL))
+	handler.New(service.New(repository.New())).Register(r)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	srv := newTestServer(t)
+	c, err := New(srv.URL, append([]Option{WithHTTPClient(srv.Client())}, opts...)...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("localhost:8080")
+	assert.Error(t, err)
+
+	c, err := New("http://localhost:8080/api/")
+	require.NoError(t, err)
+	assert.Equal(t, "/api", c.baseURL.Path)
+}
+
+func TestClient_Users(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := c.CreateUser(ctx, CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+	assert.Equal(t, int64(1), created.Version)
+
+	got, err := c.GetUser(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	name := "Alicia"
+	updated, err := c.UpdateUser(ctx, created.ID, created.Version, UpdateUserRequest{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", updated.Name)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = c.UpdateUser(ctx, created.ID, created.Version, UpdateUserRequest{Name: &name})
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+}
+
+func TestClient_Errors(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := c.GetUser(ctx, 42)
+	require.ErrorIs(t, err, ErrNotFound)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.Detail)
+
+	_, err = c.CreateUser(ctx, CreateUserRequest{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+	_, err = c.CreateUser(ctx, CreateUserRequest{Name: "Bob", Email: "BOB@example.com"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = c.CreateUser(ctx, CreateUserRequest{Name: "Bob", Email: "not-an-email"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestClient_ProblemJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"type":"https://example.com/rate-limited","title":"Too Many Requests","detail":"slow down"}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	require.NoError(t, err)
+
+	_, err = c.GetUser(context.Background(), 1)
+	require.ErrorIs(t, err, ErrRateLimited)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "https://example.com/rate-limited", apiErr.Type)
+	assert.Equal(t, "slow down", apiErr.Detail)
+	assert.Equal(t, "3", apiErr.RetryAfter)
+}
+
+func TestClient_IdempotencyKey(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	req := CreateUserRequest{Name: "Alice", Email: "alice@example.com", IdempotencyKey: "key-1"}
+
+	first, err := c.CreateUser(ctx, req)
+	require.NoError(t, err)
+	second, err := c.CreateUser(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestClient_Auth(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"a","email":"a@b.c","version":1}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithBearerToken("secret"), WithUserAgent("test-agent"))
+	require.NoError(t, err)
+	_, err = c.GetUser(context.Background(), 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer secret", got.Get("Authorization"))
+	assert.Equal(t, "test-agent", got.Get("User-Agent"))
+}
+
+func TestClient_AllUsers(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		_, err := c.CreateUser(ctx, CreateUserRequest{Name: name, Email: name + "@example.com"})
+		require.NoError(t, err)
+	}
+
+	var names []string
+	for user, err := range c.AllUsers(ctx, 2) {
+		require.NoError(t, err)
+		names = append(names, user.Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, names)
+
+	names = nil
+	for user, err := range c.AllUsers(ctx, 2) {
+		require.NoError(t, err)
+		names = append(names, user.Name)
+		if len(names) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, err := range c.AllUsers(canceled, 2) {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
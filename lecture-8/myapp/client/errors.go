@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+var (
+	ErrBadRequest         = errors.New("bad request")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("rate limited")
+)
+
+// APIError is a non-2xx response. Bodies in RFC 9457 problem format
+// (application/problem+json) fill Type, Title and Detail; the API's
+// {"error": "..."} bodies fill Detail.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Title      string
+	Detail     string
+	RetryAfter string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("myapp: %d %s", e.StatusCode, msg)
+}
+
+// Is lets callers write errors.Is(err, client.ErrNotFound).
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed || e.StatusCode == http.StatusPreconditionRequired
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Error  string `json:"error"`
+}
+
+const maxErrorBody = 64 << 10
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: resp.Header.Get("Retry-After"),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	media, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var p problem
+	switch {
+	case media == "application/problem+json" || media == "application/json":
+		if json.Unmarshal(body, &p) != nil {
+			apiErr.Detail = string(body)
+			return apiErr
+		}
+	default:
+		apiErr.Detail = string(body)
+		return apiErr
+	}
+
+	apiErr.Type = p.Type
+	apiErr.Title = p.Title
+	apiErr.Detail = p.Detail
+	if apiErr.Detail == "" {
+		apiErr.Detail = p.Error
+	}
+	return apiErr
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"ITMO-students/lecture-8/myapp/model"
+)
+
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// IdempotencyKey makes the call safe to retry; it is sent as a header.
+	IdempotencyKey string `json:"-"`
+}
+
+// UpdateUserRequest changes only non-nil fields.
+type UpdateUserRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+}
+
+type ListUsersOptions struct {
+	// Limit is the page size; zero means the server default.
+	Limit  int
+	Cursor string
+}
+
+func (c *Client) GetUser(ctx context.Context, id int64) (*model.User, error) {
+	var user model.User
+	_, err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10), nil, nil, nil, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (*model.User, error) {
+	header := http.Header{}
+	if req.IdempotencyKey != "" {
+		header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+
+	var user model.User
+	_, err := c.do(ctx, http.MethodPost, "/users", nil, header, req, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the user if it still has the given version;
+// otherwise the error matches ErrPreconditionFailed.
+func (c *Client) UpdateUser(ctx context.Context, id, version int64, req UpdateUserRequest) (*model.User, error) {
+	header := http.Header{}
+	header.Set("If-Match", `"`+strconv.FormatInt(version, 10)+`"`)
+
+	var user model.User
+	_, err := c.do(ctx, http.MethodPatch, "/users/"+strconv.FormatInt(id, 10), nil, header, req, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers returns a single page.
+func (c *Client) ListUsers(ctx context.Context, opts ListUsersOptions) (*model.UserPage, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+
+	var page model.UserPage
+	_, err := c.do(ctx, http.MethodGet, "/users", query, nil, nil, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AllUsers iterates over every user, fetching pages of pageSize lazily.
+// Iteration stops after the first error, which is yielded with a zero user.
+//
+//	for user, err := range c.AllUsers(ctx, 50) {
+//		if err != nil { ... }
+//	}
+func (c *Client) AllUsers(ctx context.Context, pageSize int) iter.Seq2[model.User, error] {
+	return func(yield func(model.User, error) bool) {
+		opts := ListUsersOptions{Limit: pageSize}
+		for {
+			page, err := c.ListUsers(ctx, opts)
+			if err != nil {
+				yield(model.User{}, err)
+				return
+			}
+			for _, user := range page.Items {
+				if !yield(user, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
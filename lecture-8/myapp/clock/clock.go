@@ -0,0 +1,50 @@
+// Package clock puts the time functions behind an interface, so code that
+// waits or schedules can be tested without sleeping. Production code takes
+// a Clock and gets Real(); tests pass a *Fake and move time with Advance.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the subset of package time that code under test needs.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc calls f in its own goroutine after d. The Timer's C is nil.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is *time.Timer with the channel behind a method.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is *time.Ticker with the channel behind a method.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// SleepContext sleeps for d on c, or returns ctx.Err() if ctx is done first.
+func SleepContext(ctx context.Context, c Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
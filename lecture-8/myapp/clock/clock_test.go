@@ -0,0 +1,171 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFake_Timers(t *testing.T) {
+	c := NewFake(start)
+
+	timer := c.NewTimer(10 * time.Second)
+	after := c.After(5 * time.Second)
+	if c.Waiters() != 2 {
+		t.Fatalf("Waiters = %d, want 2", c.Waiters())
+	}
+
+	c.Advance(4 * time.Second)
+	select {
+	case <-after:
+		t.Fatal("After fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	if got := <-after; !got.Equal(start.Add(5 * time.Second)) {
+		t.Errorf("After fired with %v", got)
+	}
+
+	if !timer.Stop() {
+		t.Error("Stop of a pending timer returned false")
+	}
+	c.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+	if timer.Reset(time.Second) {
+		t.Error("Reset of a stopped timer returned true")
+	}
+	c.Advance(time.Second)
+	<-timer.C()
+	if c.Waiters() != 0 {
+		t.Errorf("Waiters = %d, want 0", c.Waiters())
+	}
+}
+
+func TestFake_TickerAndAfterFunc(t *testing.T) {
+	c := NewFake(start)
+
+	var fired []time.Time
+	c.AfterFunc(3*time.Second, func() { fired = append(fired, c.Now()) })
+	ticker := c.NewTicker(2 * time.Second)
+
+	c.Advance(3 * time.Second)
+	if len(fired) != 1 || !fired[0].Equal(start.Add(3*time.Second)) {
+		t.Errorf("AfterFunc ran at %v, want once at +3s", fired)
+	}
+	if got := <-ticker.C(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("tick at %v, want +2s", got)
+	}
+
+	// A receiver that falls behind misses ticks instead of queueing them.
+	c.Advance(10 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("ticks queued up")
+	default:
+	}
+
+	ticker.Reset(time.Minute)
+	c.Advance(59 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Error("tick before the new interval")
+	default:
+	}
+	c.Advance(time.Second)
+	<-ticker.C()
+	ticker.Stop()
+	if c.Waiters() != 0 {
+		t.Errorf("Waiters = %d, want 0", c.Waiters())
+	}
+}
+
+func TestFake_Set(t *testing.T) {
+	c := NewFake(start)
+	after := c.After(time.Minute)
+
+	c.Set(start.Add(-time.Hour))
+	if got := c.Since(start); got != -time.Hour {
+		t.Errorf("Since = %v, want -1h", got)
+	}
+	c.Set(start.Add(time.Hour))
+	select {
+	case <-after:
+	default:
+		t.Error("timer did not fire when the clock was set past it")
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(start)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Sleep(time.Second)
+		}()
+	}
+
+	c.BlockUntil(3)
+	c.Advance(time.Second)
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.BlockUntilContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("BlockUntilContext with no sleepers = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	c := NewFake(start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- SleepContext(ctx, c, time.Hour) }()
+	c.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("SleepContext = %v, want Canceled", err)
+	}
+	if c.Waiters() != 0 {
+		t.Error("canceled sleep left its timer behind")
+	}
+
+	go func() { done <- SleepContext(context.Background(), c, time.Hour) }()
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Errorf("SleepContext = %v", err)
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := Real()
+	begin := c.Now()
+	c.Sleep(time.Millisecond)
+	<-c.After(time.Millisecond)
+	<-c.NewTimer(time.Millisecond).C()
+
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+
+	done := make(chan struct{})
+	c.AfterFunc(time.Millisecond, func() { close(done) })
+	<-done
+
+	if c.Since(begin) < 4*time.Millisecond {
+		t.Errorf("Since = %v, want at least 4ms", c.Since(begin))
+	}
+}
@@ -0,0 +1,246 @@
+package clock
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that moves only when told to. Timers, tickers and
+// AfterFunc callbacks fire during Advance and Set, in deadline order, with
+// Now reporting each one's deadline as it fires. Callbacks run on the
+// goroutine calling Advance, so they have finished when it returns.
+//
+// Waiters and BlockUntil show how many timers are pending, which lets a
+// test wait until the goroutine under test is parked in Sleep or on a
+// timer before it advances the clock.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{} // closed and replaced when timers changes
+}
+
+// NewFake returns a Fake that starts at now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-f.NewTimer(d).C()
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return fakeTicker{t}
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &fakeTimer{clock: f, fn: fn}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer due on the way.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.advanceTo(f.now.Add(d))
+	f.mu.Unlock()
+}
+
+// Set moves the clock to t. Moving forward fires due timers like Advance;
+// moving back fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	if t.Before(f.now) {
+		f.now = t
+	} else {
+		f.advanceTo(t)
+	}
+	f.mu.Unlock()
+}
+
+// Waiters is the number of pending timers, tickers and AfterFuncs,
+// including the ones behind Sleep and After.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil waits until at least n timers are pending.
+func (f *Fake) BlockUntil(n int) {
+	f.BlockUntilContext(context.Background(), n)
+}
+
+// BlockUntilContext is BlockUntil that gives up when ctx is done.
+func (f *Fake) BlockUntilContext(ctx context.Context, n int) error {
+	for {
+		f.mu.Lock()
+		pending, changed := len(f.timers), f.changed
+		f.mu.Unlock()
+		if pending >= n {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// advanceTo fires due timers one at a time, releasing f.mu around
+// AfterFunc callbacks so they may use the clock.
+func (f *Fake) advanceTo(end time.Time) {
+	for {
+		i := slices.IndexFunc(f.timers, func(t *fakeTimer) bool { return !t.when.After(end) })
+		if i < 0 {
+			break
+		}
+		for j, t := range f.timers {
+			if t.when.Before(f.timers[i].when) {
+				i = j
+			}
+		}
+		t := f.timers[i]
+		if t.when.After(f.now) {
+			f.now = t.when
+		}
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+		} else {
+			f.remove(t)
+		}
+
+		if t.fn != nil {
+			f.mu.Unlock()
+			t.fn()
+			f.mu.Lock()
+			continue
+		}
+		select {
+		case t.ch <- f.now:
+		default: // like time.Ticker, drop ticks for a slow receiver
+		}
+	}
+	if end.After(f.now) {
+		f.now = end
+	}
+}
+
+func (f *Fake) add(t *fakeTimer) {
+	f.timers = append(f.timers, t)
+	f.notify()
+}
+
+func (f *Fake) remove(t *fakeTimer) bool {
+	i := slices.Index(f.timers, t)
+	if i < 0 {
+		return false
+	}
+	f.timers = slices.Delete(f.timers, i, i+1)
+	f.notify()
+	return true
+}
+
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+type fakeTimer struct {
+	clock  *Fake
+	ch     chan time.Time
+	fn     func()
+	period time.Duration // > 0 for tickers
+	when   time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.drain()
+	return t.clock.remove(t)
+}
+
+// Reset re-arms the timer; a non-positive d fires it at once.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.clock
+	f.mu.Lock()
+	active := f.remove(t)
+	t.drain()
+	if t.period > 0 {
+		t.period = d
+	}
+	t.when = f.now.Add(d)
+
+	if d > 0 {
+		f.add(t)
+		f.mu.Unlock()
+		return active
+	}
+	now := f.now
+	f.mu.Unlock()
+
+	if t.fn != nil {
+		go t.fn()
+	} else {
+		t.ch <- now
+	}
+	return active
+}
+
+// drain drops a fired value nobody received, as Stop and Reset on a
+// time.Timer do since Go 1.23.
+func (t *fakeTimer) drain() {
+	if t.ch == nil {
+		return
+	}
+	select {
+	case <-t.ch:
+	default:
+	}
+}
+
+type fakeTicker struct{ t *fakeTimer }
+
+func (k fakeTicker) C() <-chan time.Time { return k.t.C() }
+func (k fakeTicker) Stop()               { k.t.Stop() }
+
+func (k fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	k.t.Reset(d)
+}
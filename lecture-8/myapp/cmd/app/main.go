@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"google.golang.org/grpc"
+
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/grpcserver"
+	"ITMO-students/lecture-8/myapp/handler"
+	"ITMO-students/lecture-8/myapp/jobs"
+	"ITMO-students/lecture-8/myapp/middleware"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/openapi"
+	"ITMO-students/lecture-8/myapp/outbox"
+	"ITMO-students/lecture-8/myapp/ratelimit"
+	"ITMO-students/lecture-8/myapp/redisstore"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/scheduler"
+	"ITMO-students/lecture-8/myapp/service"
+	"ITMO-students/lecture-8/myapp/webhook"
+)
+
+const (
+	httpAddr = ":8080"
+	grpcAddr = ":9090"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	db, err := openDB()
+	if err != nil {
+		logger.Error("database", "error", err)
+		os.Exit(1)
+	}
+	// Users and their outbox events are written in one transaction, so
+	// both live in the database when there is one. Webhooks live there too:
+	// a replica that claims an outbox event must see every subscription.
+	var (
+		base         repository.UserRepository
+		outboxDB     outboxStore
+		jobStore     jobs.Store
+		webhookStore webhook.Store
+	)
+	if db != nil {
+		defer db.Close()
+		base = repository.NewPostgres(db)
+		outboxDB = repository.NewPostgresOutbox(db)
+		jobStore = jobs.NewPostgresStore(db)
+		webhookStore = webhook.NewPostgresStore(db)
+	} else {
+		memRepo := repository.New()
+		base = memRepo
+		outboxDB = memRepo.Outbox()
+		jobStore = jobs.NewMemoryStore()
+		webhookStore = webhook.NewMemoryStore()
+	}
+	// Writes read the version they check past every cache.
+	store := base
+	var rateStore ratelimit.Store = ratelimit.NewMemoryStore(nil)
+	localCache := cache.Options{}
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		rdb := redisstore.New(redisstore.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")})
+		defer rdb.Close()
+		shared := cache.NewShared[model.User](redisstore.NewCacheStore(rdb, "myapp:"), cache.JSON, "user:", repository.DefaultCacheTTL)
+		base = repository.NewSharedCached(base, shared)
+		rateStore = redisstore.NewRateLimitStore(rdb, "myapp:ratelimit:")
+		// Other replicas write through Redis; keep the local copy short-lived.
+		localCache.TTL = 5 * time.Second
+	}
+
+	repo := repository.NewCached(base, localCache)
+	expvar.Publish("user_cache", expvar.Func(func() any {
+		stats := repo.Stats()
+		return map[string]any{"stats": stats, "hit_ratio": stats.HitRatio()}
+	}))
+	svc := service.NewCached(store, repo)
+	h := handler.New(svc)
+
+	limiter := ratelimit.New(ratelimit.Config{
+		Default: ratelimit.Quota{Requests: 100, Period: ratelimit.Duration(time.Minute)},
+	}, rateStore, ratelimit.KeyByIP, nil)
+
+	r := gin.Default()
+	r.Use(limiter.Gin())
+	var idem idempotencyStore = repository.NewMemoryIdempotency()
+	if db != nil {
+		// A retry may reach another replica.
+		idem = repository.NewPostgresIdempotency(db)
+	}
+	r.Use(middleware.Idempotency(idem, middleware.DefaultIdempotencyTTL))
+	h.Register(r)
+	// Operator routes need ADMIN_TOKEN; without it they are closed.
+	admin := r.Group("", middleware.AdminAuth(os.Getenv("ADMIN_TOKEN")))
+	webhooks := webhook.NewService(webhookStore)
+	handler.NewWebhook(webhooks).Register(admin)
+	queue := jobs.NewQueue(jobStore)
+	handler.NewJobs(queue).Register(admin)
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+
+	reg := openapi.NewRegistry()
+	handler.Describe(reg)
+	handler.DescribeWebhooks(reg)
+	handler.DescribeJobs(reg)
+	openapi.Serve(r, openapi.Info{Title: "myapp", Version: "1.0.0"}, reg)
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpcserver.Recovery(logger),
+		grpcserver.Logging(logger),
+	}
+	if token := os.Getenv("GRPC_AUTH_TOKEN"); token != "" {
+		interceptors = append(interceptors, grpcserver.Auth(grpcserver.StaticToken(token)))
+	}
+	grpcSrv := grpcserver.New(svc, interceptors...)
+	httpSrv := &http.Server{Addr: httpAddr, Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pub, err := newPublisher(logger)
+	if err != nil {
+		logger.Error("outbox publisher", "error", err)
+		os.Exit(1)
+	}
+	relay := outbox.NewRelay(outboxDB, outbox.Fanout(webhooks, pub), outbox.RelayOptions{Logger: logger})
+	go relay.Run(ctx)
+	go webhook.NewPool(webhookStore, webhook.PoolOptions{Logger: logger}).Run(ctx)
+	// Features that enqueue jobs register their handlers here, before Run.
+	worker := jobs.NewWorker(jobStore, jobs.WorkerOptions{Logger: logger})
+	workerDone := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(workerDone)
+	}()
+
+	sched, err := newScheduler(db, logger, idem, queue, outboxDB)
+	if err != nil {
+		logger.Error("scheduler", "error", err)
+		os.Exit(1)
+	}
+	expvar.Publish("scheduler", expvar.Func(func() any { return sched.Stats() }))
+	schedDone := make(chan struct{})
+	go func() {
+		sched.Run(ctx)
+		close(schedDone)
+	}()
+
+	errc := make(chan error, 2)
+	go func() {
+		logger.Info("http listening", "addr", httpAddr)
+		if err := httpSrv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errc <- err
+		}
+	}()
+	go func() {
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			errc <- err
+			return
+		}
+		logger.Info("grpc listening", "addr", grpcAddr)
+		errc <- grpcSrv.Serve(lis)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-errc:
+		logger.Error("server failed", "error", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	grpcSrv.GracefulStop()
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http shutdown", "error", err)
+	}
+	stop()
+	<-workerDone
+	<-schedDone
+}
+
+// openDB connects to DATABASE_URL. Without it the app keeps everything in
+// memory and the returned DB is nil.
+func openDB() (*sql.DB, error) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return nil, nil
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// schedulerLockKey is the advisory lock id replicas elect a scheduler
+// leader with.
+const schedulerLockKey = 0x6d79617070 // "myapp"
+
+// jobRetention is how long finished queue jobs stay visible in /admin/jobs.
+const jobRetention = 7 * 24 * time.Hour
+
+// outboxRetention is how long delivered outbox events are kept.
+const outboxRetention = 24 * time.Hour
+
+// idempotencyStore is an idempotency repository that can drop expired keys.
+type idempotencyStore interface {
+	repository.IdempotencyRepository
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+// outboxStore is an outbox that can drop delivered events.
+type outboxStore interface {
+	outbox.Store
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// newScheduler registers the periodic jobs. With a database only the
+// replica holding the advisory lock runs the shared ones.
+func newScheduler(db *sql.DB, logger *slog.Logger, idem idempotencyStore, queue *jobs.Queue, ob outboxStore) (*scheduler.Scheduler, error) {
+	opts := scheduler.Options{Logger: logger}
+	if db != nil {
+		opts.Leader = scheduler.NewAdvisoryLock(db, schedulerLockKey)
+	}
+	sched := scheduler.New(opts)
+	err := sched.Add(scheduler.Job{
+		Name:     "jobs-purge",
+		Schedule: scheduler.MustParseCron("@hourly"),
+		Jitter:   time.Minute,
+		Timeout:  5 * time.Minute,
+		Task: func(ctx context.Context) error {
+			n, err := queue.Purge(ctx, jobRetention)
+			if n > 0 {
+				logger.Info("finished jobs purged", "count", n)
+			}
+			return err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = sched.Add(scheduler.Job{
+		Name:     "outbox-purge",
+		Schedule: scheduler.Every(10 * time.Minute),
+		Jitter:   time.Minute,
+		Timeout:  5 * time.Minute,
+		// Without a database each replica has its own outbox.
+		Local: db == nil,
+		Task: func(ctx context.Context) error {
+			n, err := ob.Purge(ctx, time.Now().Add(-outboxRetention))
+			if n > 0 {
+				logger.Info("delivered outbox events purged", "count", n)
+			}
+			return err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = sched.Add(scheduler.Job{
+		Name:     "idempotency-sweep",
+		Schedule: scheduler.Every(5 * time.Minute),
+		Jitter:   30 * time.Second,
+		Timeout:  time.Minute,
+		// Without a database each replica keeps its own keys in memory.
+		Local: db == nil,
+		Task: func(ctx context.Context) error {
+			n, err := idem.DeleteExpired(ctx)
+			if n > 0 {
+				logger.Info("idempotency keys expired", "count", n)
+			}
+			return err
+		},
+	})
+	return sched, err
+}
+
+// newPublisher picks the outbox destination: OUTBOX_WEBHOOK_URL, then
+// OUTBOX_FILE, otherwise the log.
+func newPublisher(logger *slog.Logger) (outbox.Publisher, error) {
+	if url := os.Getenv("OUTBOX_WEBHOOK_URL"); url != "" {
+		return outbox.WebhookPublisher{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	if path := os.Getenv("OUTBOX_FILE"); path != "" {
+		return outbox.NewFilePublisher(path)
+	}
+	return outbox.LogPublisher{Logger: logger}, nil
+}
@@ -0,0 +1,63 @@
+package factory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/jobs"
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+func TestUser(t *testing.T) {
+	testUser(t, repository.New())
+}
+
+// testUser runs against any repository; postgres_test.go reuses it.
+func testUser(t *testing.T, repo repository.UserRepository) {
+	alice := User().WithName("Alice").WithEmail("alice@example.com").Create(t, repo)
+	assert.NotZero(t, alice.ID)
+	assert.Equal(t, int64(1), alice.Version)
+	assert.Equal(t, "alice@example.com", alice.Email)
+
+	// Defaults are unique, so factories can be called freely.
+	a := User().Create(t, repo)
+	b := User().Create(t, repo)
+	assert.NotEqual(t, a.Email, b.Email)
+
+	stored, err := repo.FindByID(context.Background(), alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice, stored)
+
+	built := User().WithName("Bob").Build()
+	assert.Zero(t, built.ID)
+	assert.Equal(t, "Bob", built.Name)
+}
+
+func TestJob(t *testing.T) {
+	testJob(t, jobs.NewMemoryStore())
+}
+
+func testJob(t *testing.T, store jobs.Store) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	j := Job().
+		WithKind("email").
+		WithPayload(map[string]string{"to": "alice@example.com"}).
+		WithPriority(5).
+		WithRunAt(now.Add(time.Hour)).
+		At(now).
+		Create(t, store)
+
+	assert.NotZero(t, j.ID)
+	assert.Equal(t, jobs.StatusPending, j.Status)
+	assert.JSONEq(t, `{"to":"alice@example.com"}`, string(j.Payload))
+	assert.True(t, j.CreatedAt.Equal(now))
+	assert.True(t, j.RunAt.Equal(now.Add(time.Hour)))
+
+	due, err := store.Claim(context.Background(), []string{"email"}, now, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, due, "the job is not due yet")
+}
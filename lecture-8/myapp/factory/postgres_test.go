@@ -0,0 +1,24 @@
+//go:build integration
+
+package factory
+
+import (
+	"os"
+	"testing"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+	"ITMO-students/lecture-8/myapp/jobs"
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func TestUser_Postgres(t *testing.T) {
+	db := pgtest.Open(t, pgtest.Options{Migrations: "../migrations/*.sql"})
+	testUser(t, repository.NewPostgres(db))
+}
+
+func TestJob_Postgres(t *testing.T) {
+	db := pgtest.Open(t, pgtest.Options{Migrations: "../migrations/*.sql"})
+	testJob(t, jobs.NewPostgresStore(db))
+}
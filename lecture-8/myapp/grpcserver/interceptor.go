@@ -0,0 +1,93 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Recovery turns a panic in a handler into codes.Internal.
+func Recovery(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "panic in grpc handler",
+					"method", info.FullMethod,
+					"panic", r,
+					"stack", string(debug.Stack()))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Logging writes one line per call with its code and duration.
+func Logging(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		level := slog.LevelInfo
+		code := status.Code(err)
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		}
+		logger.Log(ctx, level, "grpc call",
+			"method", info.FullMethod,
+			"code", code.String(),
+			"duration", time.Since(start))
+		return resp, err
+	}
+}
+
+// AuthFunc checks a bearer token and returns the context to continue with,
+// e.g. with the caller's identity in it.
+type AuthFunc func(ctx context.Context, token string) (context.Context, error)
+
+// Auth requires "authorization: Bearer <token>" metadata accepted by check.
+func Auth(check AuthFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		token, ok := bearerToken(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+
+		ctx, err := check(ctx, token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StaticToken accepts a single shared token; useful for service-to-service calls.
+func StaticToken(want string) AuthFunc {
+	return func(ctx context.Context, token string) (context.Context, error) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return ctx, nil
+	}
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, v := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok && token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
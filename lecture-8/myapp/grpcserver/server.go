@@ -0,0 +1,89 @@
+// Package grpcserver exposes service.UserService over gRPC.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	userv1 "ITMO-students/lecture-8/myapp/api/user/v1"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/service"
+)
+
+// UserServer is the gRPC adapter over the same service the REST handlers use.
+type UserServer struct {
+	userv1.UnimplementedUserServiceServer
+	service *service.UserService
+}
+
+func NewUserServer(s *service.UserService) *UserServer {
+	return &UserServer{service: s}
+}
+
+// New creates a grpc.Server with the user service and the given interceptors
+// registered, in the order they are listed.
+func New(s *service.UserService, interceptors ...grpc.UnaryServerInterceptor) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	userv1.RegisterUserServiceServer(srv, NewUserServer(s))
+	return srv
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.User, error) {
+	user, err := s.service.GetUser(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	user, err := s.service.CreateUser(ctx, req.GetName(), req.GetEmail())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.User, error) {
+	user, err := s.service.UpdateUser(ctx, req.GetId(), req.GetVersion(),
+		service.UserPatch{Name: req.Name, Email: req.Email})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func toProto(u model.User) *userv1.User {
+	return &userv1.User{
+		Id:      u.ID,
+		Name:    u.Name,
+		Email:   u.Email,
+		Version: u.Version,
+	}
+}
+
+// toStatus maps domain errors to gRPC codes, the same way handler.writeError
+// maps them to HTTP statuses.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, repository.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, "email already taken")
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return status.Error(codes.Aborted, "user was modified")
+	case errors.Is(err, service.ErrInvalidUser):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
@@ -0,0 +1,161 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	userv1 "ITMO-students/lecture-8/myapp/api/user/v1"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/service"
+)
+
+const testToken = "secret"
+
+// startServer поднимает сервер на bufconn: без портов и сети.
+func startServer(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newClient(t *testing.T, logs *bytes.Buffer) userv1.UserServiceClient {
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	srv := New(service.New(repository.New()),
+		Recovery(logger),
+		Logging(logger),
+		Auth(StaticToken(testToken)),
+	)
+	return userv1.NewUserServiceClient(startServer(t, srv))
+}
+
+func authed() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testToken)
+}
+
+func TestUserServer(t *testing.T) {
+	var logs bytes.Buffer
+	client := newClient(t, &logs)
+	ctx := authed()
+
+	created, err := client.CreateUser(ctx, &userv1.CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.GetId())
+	assert.Equal(t, int64(1), created.GetVersion())
+
+	got, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(created, got))
+
+	updated, err := client.UpdateUser(ctx, &userv1.UpdateUserRequest{
+		Id: 1, Version: 1, Name: proto.String("Alicia"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", updated.GetName())
+	assert.Equal(t, "alice@example.com", updated.GetEmail())
+	assert.Equal(t, int64(2), updated.GetVersion())
+
+	assert.Contains(t, logs.String(), "method=/myapp.user.v1.UserService/UpdateUser code=OK")
+}
+
+func TestUserServer_ErrorCodes(t *testing.T) {
+	var logs bytes.Buffer
+	client := newClient(t, &logs)
+	ctx := authed()
+
+	_, err := client.CreateUser(ctx, &userv1.CreateUserRequest{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		call func() error
+		want codes.Code
+	}{
+		"not found": {
+			call: func() error {
+				_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: 42})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		"duplicate email": {
+			call: func() error {
+				_, err := client.CreateUser(ctx, &userv1.CreateUserRequest{Name: "A", Email: "alice@example.com"})
+				return err
+			},
+			want: codes.AlreadyExists,
+		},
+		"invalid user": {
+			call: func() error {
+				_, err := client.CreateUser(ctx, &userv1.CreateUserRequest{Email: "bob@example.com"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		"stale version": {
+			call: func() error {
+				_, err := client.UpdateUser(ctx, &userv1.UpdateUserRequest{Id: 1, Version: 7, Name: proto.String("X")})
+				return err
+			},
+			want: codes.Aborted,
+		},
+		"no token": {
+			call: func() error {
+				_, err := client.GetUser(context.Background(), &userv1.GetUserRequest{Id: 1})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		"wrong token": {
+			call: func() error {
+				ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")
+				_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: 1})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, status.Code(tt.call()))
+		})
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	panicky := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		panic("boom")
+	}
+	srv := New(service.New(repository.New()), Recovery(logger), panicky)
+	client := userv1.NewUserServiceClient(startServer(t, srv))
+
+	_, err := client.GetUser(context.Background(), &userv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, logs.String(), "panic in grpc handler")
+}
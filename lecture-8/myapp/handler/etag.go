@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"ITMO-students/lecture-8/myapp/model"
+)
+
+// etag is the strong entity tag of a user: its version in quotes.
+func etag(u model.User) string {
+	return `"` + strconv.FormatInt(u.Version, 10) + `"`
+}
+
+// parseETag extracts the version from a single strong ETag.
+func parseETag(tag string) (int64, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// matchETag reports whether header (a list of tags or "*") contains tag.
+// It uses weak comparison, as required for If-None-Match.
+func matchETag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-16/2-table-tests/prop"
+	"ITMO-students/lecture-8/myapp/jobs"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/service"
+)
+
+// Fuzz targets check properties that hold for any input: no 5xx, only
+// the documented statuses, and a store that stays consistent. The seed
+// corpus is in testdata/fuzz; run one target with
+//
+//	go test ./handler -run '^$' -fuzz FuzzUserHandler_CreateUser
+
+func FuzzUserHandler_CreateUser(f *testing.F) {
+	f.Add(`{"name":"Alice","email":"alice@example.com"}`)
+	f.Add(`{"name":"  Bob  ","email":" bob@example.com "}`)
+	f.Add(`{"name":"","email":"x@example.com"}`)
+	f.Add(`{"name":"Eve","email":"not an email"}`)
+	f.Add(`{"name":1,"email":null}`)
+	f.Add(`[]`)
+	f.Add(``)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		r := newTestRouter()
+
+		rec := do(r, http.MethodPost, "/users", body, nil)
+		require.Contains(t, []int{http.StatusCreated, http.StatusBadRequest}, rec.Code, rec.Body.String())
+		if rec.Code != http.StatusCreated {
+			assertError(t, rec.Body.Bytes())
+			assert.Equal(t, http.StatusNotFound, do(r, http.MethodGet, "/users/1", "", nil).Code,
+				"a rejected payload stores nothing")
+			return
+		}
+
+		var created model.User
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, int64(1), created.ID)
+		assert.Equal(t, int64(1), created.Version)
+		assert.NotEmpty(t, created.Name)
+		assert.Equal(t, strings.TrimSpace(created.Name), created.Name)
+		assert.Equal(t, strings.TrimSpace(created.Email), created.Email)
+		assert.Equal(t, "/users/1", rec.Header().Get("Location"))
+
+		rec = do(r, http.MethodGet, "/users/1", "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got model.User
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, created, got)
+	})
+}
+
+func FuzzUserHandler_UpdateUser(f *testing.F) {
+	f.Add(`{"name":"Alicia"}`, `"1"`)
+	f.Add(`{"email":"ALICE@example.com"}`, `W/"1"`)
+	f.Add(`{"name":null,"email":null}`, `"1"`)
+	f.Add(`{"name":"   "}`, `"1"`)
+	f.Add(`{"name":"Alicia"}`, `"2"`)
+	f.Add(`{}`, `*`)
+
+	f.Fuzz(func(t *testing.T, body, ifMatch string) {
+		r := newTestRouter()
+		rec := do(r, http.MethodPost, "/users", `{"name":"Alice","email":"alice@example.com"}`, nil)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		var before model.User
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &before))
+
+		rec = do(r, http.MethodPatch, "/users/1", body, map[string]string{"If-Match": ifMatch})
+		require.Contains(t, []int{
+			http.StatusOK, http.StatusBadRequest,
+			http.StatusPreconditionFailed, http.StatusPreconditionRequired,
+		}, rec.Code, rec.Body.String())
+
+		rec2 := do(r, http.MethodGet, "/users/1", "", nil)
+		require.Equal(t, http.StatusOK, rec2.Code)
+		var after model.User
+		require.NoError(t, json.Unmarshal(rec2.Body.Bytes(), &after))
+
+		if rec.Code != http.StatusOK {
+			assertError(t, rec.Body.Bytes())
+			assert.Equal(t, before, after, "a rejected update changes nothing")
+			return
+		}
+		var updated model.User
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+		assert.Equal(t, updated, after)
+		assert.Equal(t, before.Version+1, updated.Version)
+		assert.Equal(t, etag(updated), rec.Header().Get("ETag"))
+	})
+}
+
+func FuzzUserHandler_ListUsers(f *testing.F) {
+	f.Add("2", "")
+	f.Add("", "1")
+	f.Add("0", "")
+	f.Add("-1", "-1")
+	f.Add("1000", "99999999999999999999")
+	f.Add("+3", "+0")
+	f.Add("abc", "xyz")
+
+	f.Fuzz(func(t *testing.T, limit, cursor string) {
+		r := newTestRouter()
+		for i := range 5 {
+			body := `{"name":"User","email":"user` + strconv.Itoa(i) + `@example.com"}`
+			require.Equal(t, http.StatusCreated, do(r, http.MethodPost, "/users", body, nil).Code)
+		}
+
+		q := url.Values{}
+		q.Set("limit", limit)
+		q.Set("cursor", cursor)
+		rec := do(r, http.MethodGet, "/users?"+q.Encode(), "", nil)
+
+		n, limitErr := strconv.Atoi(limit)
+		afterID, cursorErr := strconv.ParseInt(cursor, 10, 64)
+		valid := (limit == "" || limitErr == nil && n >= 1) &&
+			(cursor == "" || cursorErr == nil && afterID >= 0)
+		if !valid {
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+			assertError(t, rec.Body.Bytes())
+			return
+		}
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		if limit == "" {
+			n = service.DefaultPageSize
+		}
+		var page model.UserPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.LessOrEqual(t, len(page.Items), min(n, service.MaxPageSize))
+		prev := afterID
+		for _, u := range page.Items {
+			assert.Greater(t, u.ID, prev, "items are ordered by ID and start after the cursor")
+			prev = u.ID
+		}
+		if page.NextCursor != "" {
+			assert.Equal(t, strconv.FormatInt(prev, 10), page.NextCursor)
+		}
+	})
+}
+
+func FuzzJobsHandler_ListJobs(f *testing.F) {
+	f.Add("", "", "", "")
+	f.Add("pending", "email", "2", "1")
+	f.Add("done", "", "0", "x")
+	f.Add("failed", "report", "-5", "-1")
+
+	f.Fuzz(func(t *testing.T, status, kind, limit, after string) {
+		r := newJobsTestRouter(t)
+
+		q := url.Values{}
+		q.Set("status", status)
+		q.Set("kind", kind)
+		q.Set("limit", limit)
+		q.Set("after", after)
+		rec := do(r, http.MethodGet, "/admin/jobs?"+q.Encode(), "", nil)
+		require.Contains(t, []int{http.StatusOK, http.StatusBadRequest}, rec.Code, rec.Body.String())
+		if rec.Code != http.StatusOK {
+			assertError(t, rec.Body.Bytes())
+			return
+		}
+
+		var list []jobs.Job
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		for _, j := range list {
+			if status != "" {
+				assert.Equal(t, jobs.Status(status), j.Status)
+			}
+			if kind != "" {
+				assert.Equal(t, kind, j.Kind)
+			}
+		}
+	})
+}
+
+// email generates addresses from a few names in random case, so that
+// generated users collide, and now and then a malformed one.
+type email string
+
+func (e *email) Generate(r *rand.Rand) {
+	local := []string{"alice", "Bob", "ALICE", "bob.smith"}[r.IntN(4)]
+	switch r.IntN(8) {
+	case 0:
+		*e = email(local)
+	case 1:
+		*e = email(" " + local + "@example.com ")
+	default:
+		*e = email(local + "@example.com")
+	}
+}
+
+type userPayload struct {
+	Name  string `json:"name"`
+	Email email  `json:"email"`
+}
+
+// TestUserHandler_CreateUser_Property posts a random batch of users: each
+// one is created, rejected as invalid or conflicts with an earlier email,
+// and the listing ends up with exactly the created ones.
+func TestUserHandler_CreateUser_Property(t *testing.T) {
+	prop.Check(t, prop.Options{}, func(payloads []userPayload) error {
+		r := newTestRouter()
+		var created []model.User
+		taken := map[string]bool{}
+		for _, p := range payloads {
+			body, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			key := strings.ToLower(strings.TrimSpace(string(p.Email)))
+			rec := do(r, http.MethodPost, "/users", string(body), nil)
+			switch rec.Code {
+			case http.StatusCreated:
+				if taken[key] {
+					return fmt.Errorf("POST %s: taken email accepted", body)
+				}
+				taken[key] = true
+				var u model.User
+				if err := json.Unmarshal(rec.Body.Bytes(), &u); err != nil {
+					return err
+				}
+				created = append(created, u)
+			case http.StatusConflict:
+				if !taken[key] {
+					return fmt.Errorf("POST %s: conflict on a free email", body)
+				}
+			case http.StatusBadRequest:
+			default:
+				return fmt.Errorf("POST %s: status %d", body, rec.Code)
+			}
+		}
+
+		rec := do(r, http.MethodGet, "/users?limit=100", "", nil)
+		var page model.UserPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			return err
+		}
+		if len(page.Items) != len(created) || len(created) > 0 && !assert.ObjectsAreEqual(created, page.Items) {
+			return fmt.Errorf("listed %v, created %v", page.Items, created)
+		}
+		return nil
+	})
+}
+
+// assertError checks the body of a 4xx answer: {"error": "..."}.
+func assertError(t *testing.T, body []byte) {
+	t.Helper()
+	var resp struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(body, &resp), string(body))
+	assert.NotEmpty(t, resp.Error)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ITMO-students/lecture-8/myapp/jobs"
+)
+
+type JobsHandler struct {
+	queue *jobs.Queue
+}
+
+func NewJobs(q *jobs.Queue) *JobsHandler {
+	return &JobsHandler{queue: q}
+}
+
+// Register mounts the job admin routes on r.
+func (h *JobsHandler) Register(r gin.IRouter) {
+	r.GET("/admin/jobs", h.ListJobs)
+	r.GET("/admin/jobs/:id", h.GetJob)
+}
+
+// ListJobs pages through jobs ordered by id, optionally filtered by
+// status and kind.
+func (h *JobsHandler) ListJobs(c *gin.Context) {
+	opts := jobs.ListOptions{
+		Status: jobs.Status(c.Query("status")),
+		Kind:   c.Query("kind"),
+	}
+	switch opts.Status {
+	case "", jobs.StatusPending, jobs.StatusRunning, jobs.StatusSucceeded, jobs.StatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		opts.Limit = n
+	}
+	if v := c.Query("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after"})
+			return
+		}
+		opts.AfterID = n
+	}
+
+	list, err := h.queue.List(c.Request.Context(), opts)
+	if err != nil {
+		writeJobsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *JobsHandler) GetJob(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	j, err := h.queue.Get(c.Request.Context(), id)
+	if err != nil {
+		writeJobsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, j)
+}
+
+func writeJobsError(c *gin.Context, err error) {
+	if errors.Is(err, jobs.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/jobs"
+	"ITMO-students/lecture-8/myapp/openapi"
+	"ITMO-students/lecture-8/myapp/openapi/openapitest"
+)
+
+func newJobsTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	q := jobs.NewQueue(jobs.NewMemoryStore())
+	for _, kind := range []string{"email", "report", "email"} {
+		_, err := q.Enqueue(t.Context(), kind, map[string]int{"n": 1}, jobs.EnqueueOptions{})
+		require.NoError(t, err)
+	}
+	NewJobs(q).Register(r)
+	return r
+}
+
+func TestJobsHandler_ListJobs(t *testing.T) {
+	r := newJobsTestRouter(t)
+
+	list := func(query string) []jobs.Job {
+		t.Helper()
+		rec := do(r, http.MethodGet, "/admin/jobs"+query, "", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got []jobs.Job
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		return got
+	}
+
+	assert.Len(t, list(""), 3)
+	assert.Len(t, list("?kind=email"), 2)
+	page := list("?limit=2")
+	require.Len(t, page, 2)
+	assert.Equal(t, int64(3), list("?after=2")[0].ID)
+	assert.Empty(t, list("?status=failed"))
+
+	for _, query := range []string{"?status=done", "?limit=0", "?after=x"} {
+		rec := do(r, http.MethodGet, "/admin/jobs"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestJobsHandler_ConformsToSpec(t *testing.T) {
+	r := newJobsTestRouter(t)
+	reg := openapi.NewRegistry()
+	DescribeJobs(reg)
+	doc := openapi.Generate(openapi.Info{Title: "myapp", Version: "test"}, r.Routes(), reg)
+
+	for _, path := range []string{
+		"/admin/jobs",
+		"/admin/jobs?status=pending&limit=1",
+		"/admin/jobs?status=failed",
+		"/admin/jobs?limit=x",
+		"/admin/jobs/1",
+		"/admin/jobs/99",
+		"/admin/jobs/x",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		openapitest.AssertResponse(t, doc, req, rec)
+	}
+}
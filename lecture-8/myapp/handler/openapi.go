@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	"ITMO-students/lecture-8/myapp/jobs"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/openapi"
+	"ITMO-students/lecture-8/myapp/webhook"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// add registers op with the responses every route can return: 429 from the
+// rate limiter in front of the router and 500 from the handlers. POST and
+// PATCH routes also get the Idempotency-Key header and the 400, 409, 413
+// and 422 answers of middleware.Idempotency.
+func add(reg *openapi.Registry, method, path string, op openapi.Operation) {
+	responses := maps.Clone(op.Responses)
+	if responses == nil {
+		responses = make(map[int]any)
+	}
+	responses[http.StatusTooManyRequests] = ErrorResponse{}
+	responses[http.StatusInternalServerError] = ErrorResponse{}
+	if method == http.MethodPost || method == http.MethodPatch {
+		for _, status := range []int{
+			http.StatusBadRequest,
+			http.StatusConflict,
+			http.StatusRequestEntityTooLarge,
+			http.StatusUnprocessableEntity,
+		} {
+			responses[status] = ErrorResponse{}
+		}
+		op.Params = append(slices.Clone(op.Params), openapi.Param{
+			Name: "Idempotency-Key", In: "header", Description: "Makes retries safe",
+		})
+	}
+	op.Responses = responses
+	reg.Add(method, path, op)
+}
+
+// Describe adds the routes mounted by Register to reg.
+func Describe(reg *openapi.Registry) {
+	idParam := openapi.Param{Name: "id", In: "path", Type: int64(0)}
+
+	add(reg, http.MethodGet, "/users", openapi.Operation{
+		Summary: "List users ordered by id",
+		Params: []openapi.Param{
+			{Name: "limit", In: "query", Type: 0, Description: "Page size, at most 100"},
+			{Name: "cursor", In: "query", Description: "next_cursor of the previous page"},
+		},
+		Responses: map[int]any{
+			http.StatusOK:         model.UserPage{},
+			http.StatusBadRequest: ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodGet, "/users/:id", openapi.Operation{
+		Summary: "Get a user",
+		Params: []openapi.Param{
+			idParam,
+			{Name: "If-None-Match", In: "header", Description: "ETag from a previous response"},
+		},
+		Headers: []string{"ETag"},
+		Responses: map[int]any{
+			http.StatusOK:          model.User{},
+			http.StatusNotModified: nil,
+			http.StatusBadRequest:  ErrorResponse{},
+			http.StatusNotFound:    ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodPost, "/users", openapi.Operation{
+		Summary: "Create a user",
+		Request: createUserRequest{},
+		Headers: []string{"ETag", "Location"},
+		Responses: map[int]any{
+			http.StatusCreated: model.User{},
+		},
+	})
+
+	add(reg, http.MethodPatch, "/users/:id", openapi.Operation{
+		Summary: "Update a user",
+		Request: updateUserRequest{},
+		Params: []openapi.Param{
+			idParam,
+			{Name: "If-Match", In: "header", Required: true, Description: "ETag of the version being changed"},
+		},
+		Headers: []string{"ETag"},
+		Responses: map[int]any{
+			http.StatusOK:                   model.User{},
+			http.StatusNotFound:             ErrorResponse{},
+			http.StatusPreconditionFailed:   ErrorResponse{},
+			http.StatusPreconditionRequired: ErrorResponse{},
+		},
+	})
+}
+
+// DescribeWebhooks adds the routes mounted by WebhookHandler.Register to reg.
+// They are served behind middleware.AdminAuth.
+func DescribeWebhooks(reg *openapi.Registry) {
+	idParam := openapi.Param{Name: "id", In: "path", Type: int64(0)}
+
+	add(reg, http.MethodGet, "/webhooks", openapi.Operation{
+		Summary: "List webhook subscriptions",
+		Responses: map[int]any{
+			http.StatusOK:           []webhook.Subscription{},
+			http.StatusUnauthorized: ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodPost, "/webhooks", openapi.Operation{
+		Summary: "Subscribe a URL to events; the response holds the signing secret",
+		Request: createSubscriptionRequest{},
+		Headers: []string{"Location"},
+		Responses: map[int]any{
+			http.StatusCreated:      webhook.Subscription{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodGet, "/webhooks/:id", openapi.Operation{
+		Summary: "Get a webhook subscription",
+		Params:  []openapi.Param{idParam},
+		Responses: map[int]any{
+			http.StatusOK:           webhook.Subscription{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+			http.StatusNotFound:     ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodPatch, "/webhooks/:id", openapi.Operation{
+		Summary: "Update a subscription; active=true re-enables it",
+		Request: updateSubscriptionRequest{},
+		Params:  []openapi.Param{idParam},
+		Responses: map[int]any{
+			http.StatusOK:           webhook.Subscription{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+			http.StatusNotFound:     ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodDelete, "/webhooks/:id", openapi.Operation{
+		Summary: "Delete a subscription and its delivery log",
+		Params:  []openapi.Param{idParam},
+		Responses: map[int]any{
+			http.StatusNoContent:    nil,
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+			http.StatusNotFound:     ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodGet, "/webhooks/:id/deliveries", openapi.Operation{
+		Summary: "Delivery log, newest first",
+		Params: []openapi.Param{
+			idParam,
+			{Name: "limit", In: "query", Type: 0, Description: "At most 50"},
+		},
+		Responses: map[int]any{
+			http.StatusOK:           []webhook.Delivery{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+			http.StatusNotFound:     ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodPost, "/webhooks/:id/deliveries/:delivery_id/replay", openapi.Operation{
+		Summary: "Send a delivery again",
+		Params: []openapi.Param{
+			idParam,
+			{Name: "delivery_id", In: "path", Type: int64(0)},
+		},
+		Responses: map[int]any{
+			http.StatusAccepted:     webhook.Delivery{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+			http.StatusNotFound:     ErrorResponse{},
+		},
+	})
+}
+
+// DescribeJobs adds the routes mounted by JobsHandler.Register to reg.
+// They are served behind middleware.AdminAuth.
+func DescribeJobs(reg *openapi.Registry) {
+	add(reg, http.MethodGet, "/admin/jobs", openapi.Operation{
+		Summary: "List background jobs ordered by id",
+		Params: []openapi.Param{
+			{Name: "status", In: "query", Description: "pending, running, succeeded or failed"},
+			{Name: "kind", In: "query"},
+			{Name: "after", In: "query", Type: int64(0), Description: "Last id of the previous page"},
+			{Name: "limit", In: "query", Type: 0, Description: "Page size, at most 500"},
+		},
+		Responses: map[int]any{
+			http.StatusOK:           []jobs.Job{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+		},
+	})
+
+	add(reg, http.MethodGet, "/admin/jobs/:id", openapi.Operation{
+		Summary: "Get a background job",
+		Params:  []openapi.Param{{Name: "id", In: "path", Type: int64(0)}},
+		Responses: map[int]any{
+			http.StatusOK:           jobs.Job{},
+			http.StatusBadRequest:   ErrorResponse{},
+			http.StatusUnauthorized: ErrorResponse{},
+			http.StatusNotFound:     ErrorResponse{},
+		},
+	})
+}
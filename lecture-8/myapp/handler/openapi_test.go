@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"ITMO-students/lecture-8/myapp/openapi"
+	"ITMO-students/lecture-8/myapp/openapi/openapitest"
+)
+
+func TestUserHandler_ConformsToSpec(t *testing.T) {
+	r := newTestRouter()
+	reg := openapi.NewRegistry()
+	Describe(reg)
+	doc := openapi.Generate(openapi.Info{Title: "myapp", Version: "test"}, r.Routes(), reg)
+
+	tests := []struct {
+		method, path, body string
+		header             map[string]string
+	}{
+		{http.MethodPost, "/users", `{"name":"Alice","email":"alice@example.com"}`, nil},
+		{http.MethodPost, "/users", `{"name":"Alice","email":"alice@example.com"}`, nil},
+		{http.MethodPost, "/users", `{"name":"","email":"x"}`, nil},
+		{http.MethodGet, "/users/1", "", nil},
+		{http.MethodGet, "/users/1", "", map[string]string{"If-None-Match": `"1"`}},
+		{http.MethodGet, "/users/2", "", nil},
+		{http.MethodGet, "/users/x", "", nil},
+		{http.MethodPatch, "/users/1", `{"name":"Alicia"}`, map[string]string{"If-Match": `"1"`}},
+		{http.MethodPatch, "/users/1", `{"name":"Alicia"}`, map[string]string{"If-Match": `"1"`}},
+		{http.MethodPatch, "/users/1", `{"name":"Alicia"}`, nil},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		if tt.body != "" {
+			openapitest.AssertRequest(t, doc, req)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		openapitest.AssertResponse(t, doc, req, rec)
+	}
+}
+
+func TestDescribe_CommonResponses(t *testing.T) {
+	r := newTestRouter()
+	reg := openapi.NewRegistry()
+	Describe(reg)
+	doc := openapi.Generate(openapi.Info{Title: "myapp", Version: "test"}, r.Routes(), reg)
+
+	header := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError} {
+		if err := doc.ValidateResponse(http.MethodGet, "/users/1", status, header, []byte(`{"error":"x"}`)); err != nil {
+			t.Errorf("status %d: %v", status, err)
+		}
+	}
+
+	// Answers of middleware.Idempotency.
+	for _, method := range []string{http.MethodPost, http.MethodPatch} {
+		path := "/users"
+		if method == http.MethodPatch {
+			path = "/users/1"
+		}
+		for _, status := range []int{http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity} {
+			if err := doc.ValidateResponse(method, path, status, header, []byte(`{"error":"x"}`)); err != nil {
+				t.Errorf("%s %s status %d: %v", method, path, status, err)
+			}
+		}
+	}
+	var params []string
+	for _, p := range doc.Paths["/users/{id}"]["patch"].Parameters {
+		params = append(params, p.Name)
+	}
+	if !slices.Contains(params, "Idempotency-Key") {
+		t.Errorf("PATCH /users/{id} parameters %v lack Idempotency-Key", params)
+	}
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	r := newTestRouter()
+	reg := openapi.NewRegistry()
+	Describe(reg)
+	openapi.Register(r, openapi.Generate(openapi.Info{Title: "myapp", Version: "test"}, r.Routes(), reg))
+
+	for _, path := range []string{"/openapi.json", "/docs"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, rec.Code)
+		}
+	}
+}
@@ -1,27 +1,157 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"ITMO-students/lecture-8/myapp/repository"
 	"ITMO-students/lecture-8/myapp/service"
 )
 
 type UserHandler struct {
-	service service.UserService
+	service *service.UserService
 }
 
-func New(s service.UserService) *UserHandler {
+func New(s *service.UserService) *UserHandler {
 	return &UserHandler{service: s}
 }
 
+// Register mounts user routes on r.
+func (h *UserHandler) Register(r gin.IRouter) {
+	r.GET("/users", h.ListUsers)
+	r.GET("/users/:id", h.GetUser)
+	r.POST("/users", h.CreateUser)
+	r.PATCH("/users/:id", h.UpdateUser)
+}
+
+// GetUser returns the user with its version in ETag and answers
+// 304 Not Modified if If-None-Match already has it.
 func (h *UserHandler) GetUser(c *gin.Context) {
-	id := c.Param("id")
-	user, err := h.service.GetUser(id)
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetUser(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		writeError(c, err)
+		return
+	}
+
+	tag := etag(user)
+	c.Header("ETag", tag)
+	if matchETag(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, user)
 }
+
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	page, err := h.service.ListUsers(c.Request.Context(), c.Query("cursor"), limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+type createUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+		return
+	}
+
+	user, err := h.service.CreateUser(c.Request.Context(), req.Name, req.Email)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Location", "/users/"+strconv.FormatInt(user.ID, 10))
+	c.Header("ETag", etag(user))
+	c.JSON(http.StatusCreated, user)
+}
+
+type updateUserRequest struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+// UpdateUser requires If-Match with the current ETag so that concurrent
+// updates do not overwrite each other. "*" is not accepted: the client
+// must say which version it has seen.
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return
+	}
+	version, ok := parseETag(ifMatch)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "User was modified"})
+		return
+	}
+
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+		return
+	}
+
+	user, err := h.service.UpdateUser(c.Request.Context(), id, version,
+		service.UserPatch{Name: req.Name, Email: req.Email})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("ETag", etag(user))
+	c.JSON(http.StatusOK, user)
+}
+
+func userID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repository.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already taken"})
+	case errors.Is(err, repository.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "User was modified"})
+	case errors.Is(err, service.ErrInvalidUser), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+	}
+}
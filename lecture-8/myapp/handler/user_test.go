@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/service"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	New(service.New(repository.New())).Register(r)
+	return r
+}
+
+func do(r http.Handler, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUserHandler_GetUser(t *testing.T) {
+	r := newTestRouter()
+
+	rec := do(r, http.MethodGet, "/users/1", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(r, http.MethodGet, "/users/abc", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(r, http.MethodPost, "/users", `{"name":"Alice","email":"alice@example.com"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	rec = do(r, http.MethodGet, "/users/1", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	var user model.User
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	assert.Equal(t, model.User{ID: 1, Name: "Alice", Email: "alice@example.com", Version: 1}, user)
+}
+
+func TestUserHandler_IfNoneMatch(t *testing.T) {
+	r := newTestRouter()
+	do(r, http.MethodPost, "/users", `{"name":"Alice","email":"alice@example.com"}`, nil)
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{`"1"`, http.StatusNotModified},
+		{`W/"1"`, http.StatusNotModified},
+		{`"0", "1"`, http.StatusNotModified},
+		{`*`, http.StatusNotModified},
+		{`"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ifNoneMatch, func(t *testing.T) {
+			rec := do(r, http.MethodGet, "/users/1", "", map[string]string{"If-None-Match": tt.ifNoneMatch})
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+			if tt.want == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_UpdateUser(t *testing.T) {
+	r := newTestRouter()
+	do(r, http.MethodPost, "/users", `{"name":"Alice","email":"alice@example.com"}`, nil)
+
+	rec := do(r, http.MethodPatch, "/users/1", `{"name":"Alicia"}`, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = do(r, http.MethodPatch, "/users/1", `{"name":"Alicia"}`, map[string]string{"If-Match": `"1"`})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	var user model.User
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	assert.Equal(t, "Alicia", user.Name)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	// второй клиент всё ещё видел версию 1
+	rec = do(r, http.MethodPatch, "/users/1", `{"name":"Al"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = do(r, http.MethodPatch, "/users/1", `{"name":"Al"}`, map[string]string{"If-Match": `*`})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = do(r, http.MethodPatch, "/users/2", `{"name":"Al"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(r, http.MethodPatch, "/users/1", `{"email":"not-an-email"}`, map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestUserHandler_ListUsers(t *testing.T) {
+	r := newTestRouter()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		rec := do(r, http.MethodPost, "/users", `{"name":"`+name+`","email":"`+name+`@example.com"}`, nil)
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	rec := do(r, http.MethodGet, "/users?limit=2", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page model.UserPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "alice", page.Items[0].Name)
+	require.NotEmpty(t, page.NextCursor)
+
+	rec = do(r, http.MethodGet, "/users?limit=2&cursor="+page.NextCursor, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	page = model.UserPage{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "carol", page.Items[0].Name)
+	assert.Empty(t, page.NextCursor)
+
+	rec = do(r, http.MethodGet, "/users?limit=abc", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(r, http.MethodGet, "/users?cursor=xyz", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"ITMO-students/lecture-8/myapp/webhook"
+)
+
+type WebhookHandler struct {
+	service *webhook.Service
+}
+
+func NewWebhook(s *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{service: s}
+}
+
+// Register mounts webhook subscription routes on r.
+func (h *WebhookHandler) Register(r gin.IRouter) {
+	r.GET("/webhooks", h.ListSubscriptions)
+	r.POST("/webhooks", h.CreateSubscription)
+	r.GET("/webhooks/:id", h.GetSubscription)
+	r.PATCH("/webhooks/:id", h.UpdateSubscription)
+	r.DELETE("/webhooks/:id", h.DeleteSubscription)
+	r.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+	r.POST("/webhooks/:id/deliveries/:delivery_id/replay", h.Replay)
+}
+
+type createSubscriptionRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+type updateSubscriptionRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// CreateSubscription answers with the secret, which is not shown again.
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req createSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(c.Request.Context(), webhook.SubscriptionInput{
+		URL: req.URL, Events: req.Events, Secret: req.Secret,
+	})
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.Header("Location", "/webhooks/"+strconv.FormatInt(sub.ID, 10))
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	sub, err := h.service.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	var req updateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(c.Request.Context(), id, webhook.SubscriptionPatch{
+		URL: req.URL, Events: req.Events, Active: req.Active,
+	})
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries returns the newest deliveries with their attempt log.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Replay queues the delivery again; the worker pool sends it.
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := pathID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	d, err := h.service.Replay(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
+
+func pathID(c *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, webhook.ErrInvalidSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+	}
+}
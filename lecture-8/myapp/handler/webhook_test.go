@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/openapi"
+	"ITMO-students/lecture-8/myapp/openapi/openapitest"
+	"ITMO-students/lecture-8/myapp/outbox"
+	"ITMO-students/lecture-8/myapp/webhook"
+)
+
+func newWebhookTestRouter() (*gin.Engine, *webhook.Service) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	svc := webhook.NewService(webhook.NewMemoryStore())
+	NewWebhook(svc).Register(r)
+	return r, svc
+}
+
+func TestWebhookHandler(t *testing.T) {
+	r, svc := newWebhookTestRouter()
+
+	rec := do(r, http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","events":["user.*"]}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/webhooks/1", rec.Header().Get("Location"))
+
+	var sub webhook.Subscription
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sub))
+	assert.NotEmpty(t, sub.Secret)
+
+	rec = do(r, http.MethodGet, "/webhooks/1", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret")
+
+	rec = do(r, http.MethodPatch, "/webhooks/1", `{"active":false}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"active":false`)
+	rec = do(r, http.MethodPatch, "/webhooks/1", `{"active":true}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	require.NoError(t, svc.Publish(t.Context(), outbox.Event{ID: 1, Type: "user.created"}))
+	rec = do(r, http.MethodGet, "/webhooks/1/deliveries", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var deliveries []webhook.Delivery
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+
+	rec = do(r, http.MethodPost, "/webhooks/1/deliveries/1/replay", "", nil)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"replay_of":1`)
+
+	rec = do(r, http.MethodPost, "/webhooks/1/deliveries/99/replay", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(r, http.MethodDelete, "/webhooks/1", "", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = do(r, http.MethodGet, "/webhooks/1", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWebhookHandler_ConformsToSpec(t *testing.T) {
+	r, svc := newWebhookTestRouter()
+	reg := openapi.NewRegistry()
+	DescribeWebhooks(reg)
+	doc := openapi.Generate(openapi.Info{Title: "myapp", Version: "test"}, r.Routes(), reg)
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","events":["*"]}`},
+		{http.MethodPost, "/webhooks", `{"url":"nope","events":["*"]}`},
+		{http.MethodGet, "/webhooks", ""},
+		{http.MethodGet, "/webhooks/1", ""},
+		{http.MethodGet, "/webhooks/2", ""},
+		{http.MethodPatch, "/webhooks/1", `{"events":["user.created"]}`},
+		{http.MethodGet, "/webhooks/1/deliveries", ""},
+		{http.MethodPost, "/webhooks/1/deliveries/1/replay", ""},
+		{http.MethodPost, "/webhooks/1/deliveries/x/replay", ""},
+		{http.MethodDelete, "/webhooks/1", ""},
+	}
+
+	for i, tt := range tests {
+		if i == 6 {
+			require.NoError(t, svc.Publish(t.Context(), outbox.Event{ID: 1, Type: "user.created"}))
+		}
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.body != "" {
+			openapitest.AssertRequest(t, doc, req)
+		}
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		openapitest.AssertResponse(t, doc, req, rec)
+	}
+}
@@ -0,0 +1,84 @@
+// Package jobs is a durable background job queue. Jobs are enqueued with an
+// optional delay, priority and unique key; a Worker claims due jobs, runs
+// the handler registered for their kind and retries failures with backoff.
+// A claimed job is invisible to other workers for the visibility timeout,
+// after which a job whose worker died is claimed again.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("job not found")
+	// ErrDuplicate is returned by Enqueue together with the queued or
+	// running job that already holds the unique key.
+	ErrDuplicate = errors.New("job with this unique key is already queued")
+	// ErrLeaseLost means the visibility timeout ran out and the job may
+	// have been claimed by another worker; the result is discarded.
+	ErrLeaseLost = errors.New("job lease lost")
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+type Job struct {
+	ID      int64           `json:"id"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+	// Higher priorities are claimed first; equal priorities by RunAt.
+	Priority int `json:"priority"`
+	// UniqueKey, if set, allows one pending or running job per key.
+	UniqueKey   string `json:"unique_key,omitempty"`
+	Status      Status `json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	// RunAt is when the job becomes due, or is due again after a failure.
+	RunAt       time.Time  `json:"run_at"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// DefaultMaxAttempts is used when a job is enqueued without MaxAttempts.
+const DefaultMaxAttempts = 5
+
+// ListOptions filters Store.List. Zero fields match everything.
+type ListOptions struct {
+	Status Status
+	Kind   string
+	// AfterID and Limit page through jobs ordered by ID.
+	AfterID int64
+	Limit   int
+}
+
+type Store interface {
+	// Enqueue assigns ID, Status, CreatedAt and fills a zero RunAt with now.
+	// If UniqueKey is taken it returns the existing job and ErrDuplicate.
+	Enqueue(ctx context.Context, j Job, now time.Time) (Job, error)
+	// Claim marks up to limit due jobs of the given kinds running until
+	// now+visibility and counts the attempt. Running jobs whose visibility
+	// ran out are due again.
+	Claim(ctx context.Context, kinds []string, now time.Time, limit int, visibility time.Duration) ([]Job, error)
+	// Complete and Fail act only if the job is still running under the
+	// claim identified by attempt; otherwise they return ErrLeaseLost.
+	Complete(ctx context.Context, id int64, attempt int, now time.Time) error
+	// Fail puts the job back to pending, due at next. If dead it marks the
+	// job failed instead, finished at next.
+	Fail(ctx context.Context, id int64, attempt int, lastErr string, next time.Time, dead bool) error
+	Get(ctx context.Context, id int64) (Job, error)
+	List(ctx context.Context, opts ListOptions) ([]Job, error)
+	// Purge deletes succeeded and failed jobs finished before before and
+	// returns how many there were.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,364 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+type fixture struct {
+	store  *MemoryStore
+	queue  *Queue
+	worker *Worker
+	clock  *clock.Fake
+}
+
+func newFixture(t *testing.T, opts WorkerOptions) *fixture {
+	t.Helper()
+	f := &fixture{store: NewMemoryStore(), clock: clock.NewFake(time.Now().Truncate(time.Second))}
+	f.queue = NewQueue(f.store)
+	f.queue.now = f.clock.Now
+	opts.Now = f.clock.Now
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	f.worker = NewWorker(f.store, opts)
+	return f
+}
+
+// runDue claims and processes every due job once.
+func (f *fixture) runDue(t *testing.T, kinds ...string) int {
+	t.Helper()
+	due, err := f.store.Claim(context.Background(), kinds, f.clock.Now(), 100, time.Minute)
+	require.NoError(t, err)
+	for _, j := range due {
+		f.worker.Process(context.Background(), j)
+	}
+	return len(due)
+}
+
+func (f *fixture) get(t *testing.T, id int64) Job {
+	t.Helper()
+	j, err := f.queue.Get(context.Background(), id)
+	require.NoError(t, err)
+	return j
+}
+
+func TestQueue_DelayAndPriority(t *testing.T) {
+	f := newFixture(t, WorkerOptions{})
+	ctx := context.Background()
+
+	var order []string
+	f.worker.Handle("email", func(ctx context.Context, j Job) error {
+		order = append(order, string(j.Payload))
+		return nil
+	})
+
+	_, err := f.queue.Enqueue(ctx, "email", "low", EnqueueOptions{})
+	require.NoError(t, err)
+	_, err = f.queue.Enqueue(ctx, "email", "high", EnqueueOptions{Priority: 10})
+	require.NoError(t, err)
+	later, err := f.queue.Enqueue(ctx, "email", "later", EnqueueOptions{Delay: time.Hour, Priority: 100})
+	require.NoError(t, err)
+	assert.Equal(t, f.clock.Now().Add(time.Hour), later.RunAt)
+
+	assert.Equal(t, 2, f.runDue(t, "email"))
+	assert.Equal(t, []string{`"high"`, `"low"`}, order)
+
+	f.clock.Advance(time.Hour)
+	assert.Equal(t, 1, f.runDue(t, "email"))
+	assert.Equal(t, StatusSucceeded, f.get(t, later.ID).Status)
+	assert.Equal(t, 0, f.runDue(t, "email"), "finished jobs are not claimed again")
+}
+
+func TestQueue_UniqueKey(t *testing.T) {
+	f := newFixture(t, WorkerOptions{})
+	ctx := context.Background()
+	f.worker.Handle("report", func(ctx context.Context, j Job) error { return nil })
+
+	first, err := f.queue.Enqueue(ctx, "report", 1, EnqueueOptions{UniqueKey: "report:1"})
+	require.NoError(t, err)
+	dup, err := f.queue.Enqueue(ctx, "report", 1, EnqueueOptions{UniqueKey: "report:1"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.Equal(t, first.ID, dup.ID)
+
+	f.runDue(t, "report")
+	again, err := f.queue.Enqueue(ctx, "report", 1, EnqueueOptions{UniqueKey: "report:1"})
+	require.NoError(t, err, "a finished job frees its key")
+	assert.NotEqual(t, first.ID, again.ID)
+}
+
+func TestQueue_Purge(t *testing.T) {
+	f := newFixture(t, WorkerOptions{})
+	ctx := context.Background()
+	f.worker.Handle("email", func(ctx context.Context, j Job) error { return nil })
+
+	done, err := f.queue.Enqueue(ctx, "email", "done", EnqueueOptions{})
+	require.NoError(t, err)
+	f.runDue(t, "email")
+	pending, err := f.queue.Enqueue(ctx, "email", "pending", EnqueueOptions{Delay: 48 * time.Hour})
+	require.NoError(t, err)
+
+	n, err := f.queue.Purge(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, n, "recently finished jobs are kept")
+
+	f.clock.Advance(25 * time.Hour)
+	n, err = f.queue.Purge(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = f.queue.Get(ctx, done.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, StatusPending, f.get(t, pending.ID).Status)
+}
+
+func TestWorker_RetriesWithBackoff(t *testing.T) {
+	f := newFixture(t, WorkerOptions{Backoff: func(n int) time.Duration { return time.Duration(n) * time.Minute }})
+	ctx := context.Background()
+
+	calls := 0
+	f.worker.Handle("flaky", func(ctx context.Context, j Job) error {
+		calls++
+		if calls < 3 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	j, err := f.queue.Enqueue(ctx, "flaky", nil, EnqueueOptions{})
+	require.NoError(t, err)
+
+	f.runDue(t, "flaky")
+	got := f.get(t, j.ID)
+	assert.Equal(t, StatusPending, got.Status)
+	assert.Equal(t, "try again", got.LastError)
+	assert.Equal(t, f.clock.Now().Add(time.Minute), got.RunAt)
+
+	assert.Equal(t, 0, f.runDue(t, "flaky"), "not due during backoff")
+	f.clock.Advance(time.Minute)
+	f.runDue(t, "flaky")
+	f.clock.Advance(2 * time.Minute)
+	f.runDue(t, "flaky")
+
+	got = f.get(t, j.ID)
+	assert.Equal(t, StatusSucceeded, got.Status)
+	assert.Equal(t, 3, got.Attempts)
+	assert.Empty(t, got.LastError)
+}
+
+func TestWorker_GivesUp(t *testing.T) {
+	f := newFixture(t, WorkerOptions{Backoff: func(int) time.Duration { return 0 }})
+	ctx := context.Background()
+
+	f.worker.Handle("broken", func(ctx context.Context, j Job) error { return errors.New("boom") })
+	f.worker.Handle("panics", func(ctx context.Context, j Job) error { panic("oops") })
+	f.worker.Handle("invalid", func(ctx context.Context, j Job) error {
+		return Permanent(errors.New("bad payload"))
+	})
+
+	broken, _ := f.queue.Enqueue(ctx, "broken", nil, EnqueueOptions{MaxAttempts: 2})
+	panics, _ := f.queue.Enqueue(ctx, "panics", nil, EnqueueOptions{MaxAttempts: 1})
+	invalid, _ := f.queue.Enqueue(ctx, "invalid", nil, EnqueueOptions{})
+
+	for range 3 {
+		f.runDue(t, "broken", "panics", "invalid")
+	}
+
+	got := f.get(t, broken.ID)
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.Equal(t, 2, got.Attempts)
+	assert.NotNil(t, got.FinishedAt)
+
+	got = f.get(t, panics.ID)
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.Contains(t, got.LastError, "panic: oops")
+
+	got = f.get(t, invalid.ID)
+	assert.Equal(t, StatusFailed, got.Status)
+	assert.Equal(t, 1, got.Attempts)
+}
+
+func TestWorker_VisibilityTimeout(t *testing.T) {
+	f := newFixture(t, WorkerOptions{})
+	ctx := context.Background()
+	f.worker.Handle("slow", func(ctx context.Context, j Job) error { return nil })
+
+	j, err := f.queue.Enqueue(ctx, "slow", nil, EnqueueOptions{MaxAttempts: 2})
+	require.NoError(t, err)
+
+	// A worker claims the job and dies.
+	claimed, err := f.store.Claim(ctx, []string{"slow"}, f.clock.Now(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 0, f.runDue(t, "slow"), "hidden while the claim is visible")
+
+	f.clock.Advance(time.Minute)
+	reclaimed, err := f.store.Claim(ctx, []string{"slow"}, f.clock.Now(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, reclaimed, 1)
+	assert.Equal(t, 2, reclaimed[0].Attempts)
+
+	// The first worker comes back too late; its result is dropped.
+	assert.ErrorIs(t, f.worker.Process(ctx, claimed[0]), ErrLeaseLost)
+	require.NoError(t, f.worker.Process(ctx, reclaimed[0]))
+	assert.Equal(t, StatusSucceeded, f.get(t, j.ID).Status)
+
+	// Claims that keep expiring use up the attempts.
+	k, _ := f.queue.Enqueue(ctx, "slow", nil, EnqueueOptions{MaxAttempts: 1})
+	for range 2 {
+		_, err := f.store.Claim(ctx, []string{"slow"}, f.clock.Now(), 10, time.Minute)
+		require.NoError(t, err)
+		f.clock.Advance(time.Minute)
+	}
+	f.runDue(t, "slow")
+	assert.Equal(t, StatusFailed, f.get(t, k.ID).Status)
+}
+
+func TestWorker_DeadlineFollowsClaim(t *testing.T) {
+	f := newFixture(t, WorkerOptions{Visibility: 10 * time.Minute})
+	ctx := context.Background()
+	var deadline time.Time
+	ran := 0
+	f.worker.Handle("job", func(ctx context.Context, j Job) error {
+		ran++
+		deadline, _ = ctx.Deadline()
+		return nil
+	})
+	_, err := f.queue.Enqueue(ctx, "job", nil, EnqueueOptions{})
+	require.NoError(t, err)
+	_, err = f.queue.Enqueue(ctx, "job", nil, EnqueueOptions{})
+	require.NoError(t, err)
+
+	claimed, err := f.store.Claim(ctx, []string{"job"}, f.clock.Now(), 2, 10*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	// The job waited 8 of its 10 minutes; a minute is kept as margin.
+	f.clock.Advance(8 * time.Minute)
+	require.NoError(t, f.worker.Process(ctx, claimed[0]))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	// Inside the margin the job is not started at all.
+	f.clock.Advance(90 * time.Second)
+	assert.ErrorIs(t, f.worker.Process(ctx, claimed[1]), ErrLeaseLost)
+	assert.Equal(t, 1, ran)
+}
+
+func TestWorker_ClaimsOnlyIdle(t *testing.T) {
+	store := NewMemoryStore()
+	queue := NewQueue(store)
+	w := NewWorker(store, WorkerOptions{
+		Workers:      2,
+		PollInterval: time.Millisecond,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	var running atomic.Int32
+	release := make(chan struct{})
+	w.Handle("block", func(ctx context.Context, j Job) error {
+		running.Add(1)
+		<-release
+		return nil
+	})
+	for range 5 {
+		_, err := queue.Enqueue(context.Background(), "block", nil, EnqueueOptions{})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond) // let Run poll while both workers are busy
+	list, err := queue.List(context.Background(), ListOptions{Status: StatusRunning})
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "jobs are claimed only for idle workers")
+
+	close(release)
+	require.Eventually(t, func() bool { return running.Load() == 5 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+}
+
+func TestWorker_Run(t *testing.T) {
+	store := NewMemoryStore()
+	queue := NewQueue(store)
+	w := NewWorker(store, WorkerOptions{
+		Workers:      2,
+		PollInterval: 5 * time.Millisecond,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	var done atomic.Int32
+	w.Handle("count", func(ctx context.Context, j Job) error {
+		done.Add(1)
+		return nil
+	})
+	for range 10 {
+		_, err := queue.Enqueue(context.Background(), "count", nil, EnqueueOptions{})
+		require.NoError(t, err)
+	}
+	// Jobs without a handler stay queued.
+	other, _ := queue.Enqueue(context.Background(), "other", nil, EnqueueOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return done.Load() == 10 }, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+
+	j, err := store.Get(context.Background(), other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, j.Status)
+}
+
+func TestWorker_GracefulShutdown(t *testing.T) {
+	store := NewMemoryStore()
+	queue := NewQueue(store)
+	w := NewWorker(store, WorkerOptions{
+		PollInterval:  5 * time.Millisecond,
+		ShutdownGrace: 20 * time.Millisecond,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	started := make(chan struct{})
+	w.Handle("finishes", func(ctx context.Context, j Job) error {
+		close(started)
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	w.Handle("hangs", func(ctx context.Context, j Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	finishes, _ := queue.Enqueue(context.Background(), "finishes", nil, EnqueueOptions{Priority: 1})
+	hangs, _ := queue.Enqueue(context.Background(), "hangs", nil, EnqueueOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+	<-started
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-errc:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the shutdown grace")
+	}
+
+	j, _ := store.Get(context.Background(), finishes.ID)
+	assert.Equal(t, StatusSucceeded, j.Status, "a running job finishes within the grace")
+	j, _ = store.Get(context.Background(), hangs.ID)
+	assert.Equal(t, StatusPending, j.Status, "an interrupted job is queued again")
+	assert.False(t, j.RunAt.After(time.Now()), "and due at once")
+}
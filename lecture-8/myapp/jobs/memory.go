@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store for a single replica and for tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	jobs   map[int64]Job
+	nextID int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[int64]Job)}
+}
+
+func (m *MemoryStore) Enqueue(ctx context.Context, j Job, now time.Time) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if j.UniqueKey != "" {
+		for _, other := range m.jobs {
+			if other.UniqueKey == j.UniqueKey && active(other.Status) {
+				return cloneJob(other), ErrDuplicate
+			}
+		}
+	}
+
+	m.nextID++
+	j.ID = m.nextID
+	j.Status = StatusPending
+	j.Attempts = 0
+	j.LockedUntil = nil
+	j.FinishedAt = nil
+	j.CreatedAt = now
+	if j.RunAt.IsZero() {
+		j.RunAt = now
+	}
+	m.jobs[j.ID] = cloneJob(j)
+	return cloneJob(j), nil
+}
+
+func (m *MemoryStore) Claim(ctx context.Context, kinds []string, now time.Time, limit int, visibility time.Duration) ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []Job
+	for _, j := range m.jobs {
+		if slices.Contains(kinds, j.Kind) && isDue(j, now) {
+			due = append(due, j)
+		}
+	}
+	slices.SortFunc(due, claimOrder)
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	lockedUntil := now.Add(visibility)
+	for i := range due {
+		j := &due[i]
+		j.Status = StatusRunning
+		j.Attempts++
+		j.LockedUntil = &lockedUntil
+		m.jobs[j.ID] = cloneJob(*j)
+		*j = cloneJob(*j)
+	}
+	return due, nil
+}
+
+func (m *MemoryStore) Complete(ctx context.Context, id int64, attempt int, now time.Time) error {
+	return m.finish(id, attempt, func(j *Job) {
+		j.Status = StatusSucceeded
+		j.LastError = ""
+		j.FinishedAt = &now
+	})
+}
+
+func (m *MemoryStore) Fail(ctx context.Context, id int64, attempt int, lastErr string, next time.Time, dead bool) error {
+	return m.finish(id, attempt, func(j *Job) {
+		j.LastError = lastErr
+		if dead {
+			j.Status = StatusFailed
+			j.FinishedAt = &next
+			return
+		}
+		j.Status = StatusPending
+		j.RunAt = next
+	})
+}
+
+func (m *MemoryStore) finish(id int64, attempt int, update func(*Job)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if j.Status != StatusRunning || j.Attempts != attempt {
+		return ErrLeaseLost
+	}
+	j.LockedUntil = nil
+	update(&j)
+	m.jobs[id] = j
+	return nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id int64) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return cloneJob(j), nil
+}
+
+func (m *MemoryStore) List(ctx context.Context, opts ListOptions) ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := []Job{}
+	for _, j := range m.jobs {
+		if j.ID <= opts.AfterID ||
+			(opts.Status != "" && j.Status != opts.Status) ||
+			(opts.Kind != "" && j.Kind != opts.Kind) {
+			continue
+		}
+		jobs = append(jobs, cloneJob(j))
+	}
+	slices.SortFunc(jobs, func(a, b Job) int { return cmp.Compare(a.ID, b.ID) })
+	if opts.Limit > 0 && len(jobs) > opts.Limit {
+		jobs = jobs[:opts.Limit]
+	}
+	return jobs, nil
+}
+
+func (m *MemoryStore) Purge(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for id, j := range m.jobs {
+		if !active(j.Status) && j.FinishedAt != nil && j.FinishedAt.Before(before) {
+			delete(m.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func active(s Status) bool {
+	return s == StatusPending || s == StatusRunning
+}
+
+func isDue(j Job, now time.Time) bool {
+	switch j.Status {
+	case StatusPending:
+		return !j.RunAt.After(now)
+	case StatusRunning:
+		return j.LockedUntil != nil && !j.LockedUntil.After(now)
+	}
+	return false
+}
+
+// claimOrder matches the ORDER BY of PostgresStore.Claim.
+func claimOrder(a, b Job) int {
+	if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+		return c
+	}
+	if c := a.RunAt.Compare(b.RunAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func cloneJob(j Job) Job {
+	j.Payload = slices.Clone(j.Payload)
+	if j.LockedUntil != nil {
+		t := *j.LockedUntil
+		j.LockedUntil = &t
+	}
+	if j.FinishedAt != nil {
+		t := *j.FinishedAt
+		j.FinishedAt = &t
+	}
+	return j
+}
@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+)
+
+const jobColumns = `id, kind, payload, priority, unique_key, status, attempts, max_attempts,
+	run_at, locked_until, last_error, created_at, finished_at`
+
+// PostgresStore is a Store over the jobs table from migrations/. Claim uses
+// FOR UPDATE SKIP LOCKED, so any number of workers can poll it.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Enqueue(ctx context.Context, j Job, now time.Time) (Job, error) {
+	if j.RunAt.IsZero() {
+		j.RunAt = now
+	}
+	// The holder of the key may finish between the insert and the lookup;
+	// then the insert is simply tried again.
+	for range 3 {
+		created, err := scanJob(s.db.QueryRowContext(ctx,
+			`INSERT INTO jobs (kind, payload, priority, unique_key, max_attempts, run_at, created_at)
+			 VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+			 ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL AND status IN ('pending', 'running')
+			 DO NOTHING
+			 RETURNING `+jobColumns,
+			j.Kind, []byte(j.Payload), j.Priority, j.UniqueKey, j.MaxAttempts, j.RunAt, now))
+		if !errors.Is(err, sql.ErrNoRows) {
+			return created, err
+		}
+
+		existing, err := scanJob(s.db.QueryRowContext(ctx,
+			`SELECT `+jobColumns+` FROM jobs
+			 WHERE unique_key = $1 AND status IN ('pending', 'running')`, j.UniqueKey))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Job{}, err
+		}
+		return existing, ErrDuplicate
+	}
+	return Job{}, errors.New("jobs: unique key keeps changing hands")
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, kinds []string, now time.Time, limit int, visibility time.Duration) ([]Job, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until = $3
+		 WHERE id IN (
+		     SELECT id FROM jobs
+		     WHERE kind = ANY($1)
+		       AND ((status = 'pending' AND run_at <= $2)
+		         OR (status = 'running' AND locked_until <= $2))
+		     ORDER BY priority DESC, run_at, id
+		     LIMIT $4
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING `+jobColumns,
+		kinds, now, now.Add(visibility), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the subquery's order.
+	slices.SortFunc(jobs, claimOrder)
+	return jobs, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, id int64, attempt int, now time.Time) error {
+	return s.finish(ctx, id,
+		`UPDATE jobs SET status = 'succeeded', last_error = '', finished_at = $3, locked_until = NULL
+		 WHERE id = $1 AND attempts = $2 AND status = 'running'`,
+		id, attempt, now)
+}
+
+func (s *PostgresStore) Fail(ctx context.Context, id int64, attempt int, lastErr string, next time.Time, dead bool) error {
+	if dead {
+		return s.finish(ctx, id,
+			`UPDATE jobs SET status = 'failed', last_error = $3, finished_at = $4, locked_until = NULL
+			 WHERE id = $1 AND attempts = $2 AND status = 'running'`,
+			id, attempt, lastErr, next)
+	}
+	return s.finish(ctx, id,
+		`UPDATE jobs SET status = 'pending', last_error = $3, run_at = $4, locked_until = NULL
+		 WHERE id = $1 AND attempts = $2 AND status = 'running'`,
+		id, attempt, lastErr, next)
+}
+
+// finish runs an update guarded by the claim and tells a lost lease from a
+// missing job.
+func (s *PostgresStore) finish(ctx context.Context, id int64, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM jobs WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrLeaseLost
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id int64) (Job, error) {
+	j, err := scanJob(s.db.QueryRowContext(ctx,
+		`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	return j, err
+}
+
+func (s *PostgresStore) List(ctx context.Context, opts ListOptions) ([]Job, error) {
+	limit := sql.NullInt64{Int64: int64(opts.Limit), Valid: opts.Limit > 0}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+jobColumns+` FROM jobs
+		 WHERE id > $1 AND ($2 = '' OR status = $2) AND ($3 = '' OR kind = $3)
+		 ORDER BY id
+		 LIMIT $4`,
+		opts.AfterID, string(opts.Status), opts.Kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM jobs WHERE status IN ('succeeded', 'failed') AND finished_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner) (Job, error) {
+	var j Job
+	var payload []byte
+	var uniqueKey sql.NullString
+	err := row.Scan(&j.ID, &j.Kind, &payload, &j.Priority, &uniqueKey, &j.Status,
+		&j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedUntil, &j.LastError,
+		&j.CreatedAt, &j.FinishedAt)
+	if err != nil {
+		return Job{}, err
+	}
+	j.Payload = payload
+	j.UniqueKey = uniqueKey.String
+	return j, nil
+}
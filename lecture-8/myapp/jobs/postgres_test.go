@@ -0,0 +1,100 @@
+//go:build integration
+
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func testDB(t *testing.T) *sql.DB {
+	return pgtest.Open(t, pgtest.Options{Migrations: "../migrations/*.sql"})
+}
+
+func TestPostgresStore(t *testing.T) {
+	store := NewPostgresStore(testDB(t))
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	low, err := store.Enqueue(ctx, Job{Kind: "email", Payload: []byte(`{}`), MaxAttempts: 3}, now)
+	require.NoError(t, err)
+	high, err := store.Enqueue(ctx, Job{Kind: "email", Payload: []byte(`{}`), Priority: 5, MaxAttempts: 3, UniqueKey: "k"}, now)
+	require.NoError(t, err)
+	dup, err := store.Enqueue(ctx, Job{Kind: "email", Payload: []byte(`{}`), MaxAttempts: 3, UniqueKey: "k"}, now)
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.Equal(t, high.ID, dup.ID)
+
+	claimed, err := store.Claim(ctx, []string{"email"}, now, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, []int64{high.ID, low.ID}, []int64{claimed[0].ID, claimed[1].ID})
+	assert.Equal(t, StatusRunning, claimed[0].Status)
+
+	require.NoError(t, store.Complete(ctx, high.ID, 1, now))
+	require.NoError(t, store.Fail(ctx, low.ID, 1, "boom", now.Add(time.Second), false))
+	assert.ErrorIs(t, store.Complete(ctx, low.ID, 1, now), ErrLeaseLost)
+	assert.ErrorIs(t, store.Complete(ctx, 999, 1, now), ErrNotFound)
+
+	_, err = store.Enqueue(ctx, Job{Kind: "email", Payload: []byte(`{}`), MaxAttempts: 3, UniqueKey: "k"}, now)
+	require.NoError(t, err, "a finished job frees its key")
+
+	pending, err := store.List(ctx, ListOptions{Status: StatusPending, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "boom", pending[0].LastError)
+
+	n, err := store.Purge(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the succeeded job is purged")
+	_, err = store.Get(ctx, high.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPostgresStore_ConcurrentClaim(t *testing.T) {
+	store := NewPostgresStore(testDB(t))
+	ctx := context.Background()
+	now := time.Now()
+
+	for range 50 {
+		_, err := store.Enqueue(ctx, Job{Kind: "k", Payload: []byte(`{}`), MaxAttempts: 1}, now)
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int64]int)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				jobs, err := store.Claim(ctx, []string{"k"}, now, 3, time.Minute)
+				if !assert.NoError(t, err) || len(jobs) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, j := range jobs {
+					seen[j.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, 50)
+	for id, n := range seen {
+		assert.Equal(t, 1, n, "job %d claimed more than once", id)
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// EnqueueOptions are the optional parts of a job.
+type EnqueueOptions struct {
+	// Delay postpones the first run.
+	Delay     time.Duration
+	Priority  int
+	UniqueKey string
+	// MaxAttempts defaults to DefaultMaxAttempts.
+	MaxAttempts int
+}
+
+// Queue is the producer and admin side of a Store.
+type Queue struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewQueue(store Store) *Queue {
+	return &Queue{store: store, now: time.Now}
+}
+
+// Enqueue stores a job of the given kind with payload encoded as JSON.
+// A duplicate unique key returns the existing job and ErrDuplicate.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts EnqueueOptions) (Job, error) {
+	if kind == "" {
+		return Job{}, errors.New("jobs: empty kind")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, err
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	now := q.now()
+	return q.store.Enqueue(ctx, Job{
+		Kind:        kind,
+		Payload:     body,
+		Priority:    opts.Priority,
+		UniqueKey:   opts.UniqueKey,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       now.Add(opts.Delay),
+	}, now)
+}
+
+func (q *Queue) Get(ctx context.Context, id int64) (Job, error) {
+	return q.store.Get(ctx, id)
+}
+
+// DefaultListLimit and MaxListLimit bound List.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+func (q *Queue) List(ctx context.Context, opts ListOptions) ([]Job, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultListLimit
+	}
+	opts.Limit = min(opts.Limit, MaxListLimit)
+	return q.store.List(ctx, opts)
+}
+
+// Purge deletes jobs that finished more than retention ago.
+func (q *Queue) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	return q.store.Purge(ctx, q.now().Add(-retention))
+}
@@ -0,0 +1,232 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Handler runs one job. Its context expires a safety margin before the
+// job's claim does, so a handler that overruns cannot race the next claim.
+type Handler func(ctx context.Context, j Job) error
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: the job fails at once.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// WorkerOptions configures a Worker. Zero fields take the defaults noted below.
+type WorkerOptions struct {
+	// Workers is the number of jobs run concurrently. Default 4.
+	Workers int
+	// PollInterval is the pause after a poll that found nothing. Default 1s.
+	PollInterval time.Duration
+	// Visibility is how long a claimed job is hidden from other workers.
+	// The handler's context ends a tenth of it before the claim expires,
+	// leaving time to record the outcome. Default 5m.
+	Visibility time.Duration
+	// ShutdownGrace is how long running handlers may continue after Run's
+	// context is canceled before their contexts are canceled too. Default 30s.
+	ShutdownGrace time.Duration
+	// Backoff returns the delay after the given failed attempt (1-based).
+	// Default: 5s doubling up to 1h.
+	Backoff func(attempt int) time.Duration
+	Logger  *slog.Logger
+	Now     func() time.Time
+}
+
+// Worker claims jobs of the kinds it has handlers for and runs them.
+// Several workers, in one process or many, may share a Store.
+type Worker struct {
+	store    Store
+	opts     WorkerOptions
+	handlers map[string]Handler
+}
+
+func NewWorker(store Store, opts WorkerOptions) *Worker {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Visibility <= 0 {
+		opts.Visibility = 5 * time.Minute
+	}
+	if opts.ShutdownGrace <= 0 {
+		opts.ShutdownGrace = 30 * time.Second
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Worker{store: store, opts: opts, handlers: make(map[string]Handler)}
+}
+
+// DefaultBackoff is 5s, 10s, 20s, ... capped at one hour.
+func DefaultBackoff(attempt int) time.Duration {
+	d := 5 * time.Second << min(attempt-1, 12)
+	return min(d, time.Hour)
+}
+
+// Handle registers h for jobs of kind. It must be called before Run.
+func (w *Worker) Handle(kind string, h Handler) {
+	w.handlers[kind] = h
+}
+
+// Run claims and runs jobs until ctx is canceled. It then stops claiming,
+// gives running jobs ShutdownGrace to finish, waits for them and returns
+// ctx.Err(). It claims only as many jobs as it has idle workers.
+func (w *Worker) Run(ctx context.Context) error {
+	kinds := slices.Sorted(maps.Keys(w.handlers))
+
+	runCtx, cancelRun := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRun()
+	stopGrace := context.AfterFunc(ctx, func() {
+		time.AfterFunc(w.opts.ShutdownGrace, cancelRun)
+	})
+	defer stopGrace()
+
+	// A slot is taken before a job is claimed, so a claimed job never
+	// waits for a busy worker while its claim runs down.
+	slots := make(chan struct{}, w.opts.Workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		free := 1
+	acquire:
+		for free < w.opts.Workers {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				break acquire
+			}
+		}
+
+		var due []Job
+		var err error
+		if len(kinds) > 0 {
+			due, err = w.store.Claim(ctx, kinds, w.opts.Now(), free, w.opts.Visibility)
+		}
+		if err != nil && ctx.Err() == nil {
+			w.opts.Logger.Error("jobs: claim", "error", err)
+		}
+		for _, j := range due {
+			wg.Go(func() {
+				defer func() { <-slots }()
+				w.Process(runCtx, j)
+			})
+		}
+		for range free - len(due) {
+			<-slots
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		t := time.NewTimer(w.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Process runs one claimed job and records the outcome. A handler error
+// or panic schedules a retry with backoff until MaxAttempts is reached.
+func (w *Worker) Process(ctx context.Context, j Job) error {
+	log := w.opts.Logger.With("job_id", j.ID, "kind", j.Kind, "attempt", j.Attempts)
+	// The outcome is recorded even if ctx was canceled by a shutdown.
+	recordCtx := context.WithoutCancel(ctx)
+
+	// Attempts are counted at claim time, so a job whose worker keeps
+	// dying runs out of attempts too.
+	if j.Attempts > j.MaxAttempts {
+		log.Warn("jobs: attempts exhausted by expired claims")
+		return w.store.Fail(recordCtx, j.ID, j.Attempts, "visibility timeout expired", w.opts.Now(), true)
+	}
+
+	// A job that waited past its claim may already run elsewhere.
+	if w.leaseLeft(j) <= 0 {
+		log.Warn("jobs: claim expired before the job started")
+		return ErrLeaseLost
+	}
+
+	err := w.run(ctx, j)
+	now := w.opts.Now()
+	if err == nil {
+		err = w.store.Complete(recordCtx, j.ID, j.Attempts, now)
+		if errors.Is(err, ErrLeaseLost) {
+			log.Warn("jobs: finished after the visibility timeout")
+		}
+		return err
+	}
+
+	dead := IsPermanent(err) || j.Attempts >= j.MaxAttempts
+	// A job interrupted by shutdown is due again right away.
+	next := now
+	if !dead && ctx.Err() == nil {
+		next = now.Add(w.opts.Backoff(j.Attempts))
+	}
+	log.Info("jobs: job failed", "error", err, "dead", dead)
+	return w.store.Fail(recordCtx, j.ID, j.Attempts, err.Error(), next, dead)
+}
+
+func (w *Worker) run(ctx context.Context, j Job) (err error) {
+	h, ok := w.handlers[j.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for kind %q", j.Kind))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.leaseLeft(j))
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, j)
+}
+
+// leaseLeft is how long the handler of j may run: until a tenth of
+// Visibility before its claim expires. A job without a claim gets the
+// full timeout.
+func (w *Worker) leaseLeft(j Job) time.Duration {
+	margin := w.opts.Visibility / 10
+	if j.LockedUntil == nil {
+		return w.opts.Visibility - margin
+	}
+	return j.LockedUntil.Sub(w.opts.Now()) - margin
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	ReplayedHeader       = "Idempotent-Replayed"
+
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// MaxIdempotentBodySize bounds the body of a keyed request, which is
+	// read into memory to fingerprint it.
+	MaxIdempotentBodySize = 1 << 20
+	maxIdempotencyKeyLen  = 255
+)
+
+// Idempotency makes POST and PATCH requests carrying an Idempotency-Key safe
+// to retry:
+//   - the first request runs the handler and its response is stored for ttl;
+//   - a retry with the same key and payload gets the stored response;
+//   - the same key with another payload is rejected with 422;
+//   - a retry while the first request is still running gets 409;
+//   - a body over MaxIdempotentBodySize is rejected with 413.
+//
+// 5xx responses are not stored, so the client may retry them. Keys are
+// scoped to the caller (see scope), so one client cannot replay another's
+// response by reusing its key.
+func Idempotency(repo repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		method := c.Request.Method
+		if key == "" || (method != http.MethodPost && method != http.MethodPatch) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Cannot read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		key = scopedKey(c.Request, key)
+		fp := fingerprint(method, c.Request.URL.Path, c.Request.URL.RawQuery, body)
+
+		rec, created, err := repo.Reserve(ctx, key, fp, ttl)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+			return
+		}
+		if !created {
+			replay(c, rec, fp)
+			return
+		}
+
+		// Handler panics must not leave the key reserved forever.
+		done := false
+		defer func() {
+			if !done {
+				repo.Release(ctx, key)
+			}
+		}()
+
+		before := make(map[string]bool, len(c.Writer.Header()))
+		for name := range c.Writer.Header() {
+			before[name] = true
+		}
+
+		rw := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = rw
+		c.Next()
+
+		status := rw.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		// Keep only headers set by the handler: rate limit and similar
+		// per-request headers must not be replayed.
+		header := http.Header{}
+		for name, values := range rw.Header() {
+			if !before[name] {
+				header[name] = values
+			}
+		}
+
+		if err := repo.Complete(ctx, key, status, header, rw.body.Bytes()); err != nil {
+			return
+		}
+		done = true
+	}
+}
+
+func replay(c *gin.Context, rec repository.IdempotencyRecord, fp string) {
+	switch {
+	case rec.Fingerprint != fp:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+			gin.H{"error": "Idempotency-Key was used with a different request"})
+	case !rec.Completed:
+		c.AbortWithStatusJSON(http.StatusConflict,
+			gin.H{"error": "A request with this Idempotency-Key is in progress"})
+	default:
+		for name, values := range rec.Header {
+			c.Writer.Header()[name] = values
+		}
+		c.Header(ReplayedHeader, "true")
+		c.Status(rec.Status)
+		c.Writer.Write(rec.Body)
+		c.Abort()
+	}
+}
+
+func fingerprint(method, path, query string, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, method)
+	h.Write([]byte{0})
+	io.WriteString(h, path)
+	h.Write([]byte{0})
+	io.WriteString(h, query)
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scopedKey is the stored form of key: a hash of it and the caller's
+// scope, so equal keys from different callers never meet.
+func scopedKey(r *http.Request, key string) string {
+	h := sha256.New()
+	io.WriteString(h, scope(r))
+	h.Write([]byte{0})
+	io.WriteString(h, key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scope identifies the caller: by its credentials when the request has
+// an Authorization header, so retries from another address still match,
+// and by the peer address otherwise. X-Forwarded-For is not trusted.
+func scope(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return "auth:" + auth
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/handler"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/service"
+)
+
+func newRouter(idem repository.IdempotencyRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Header("RateLimit-Remaining", "1")
+		c.Next()
+	})
+	r.Use(Idempotency(idem, DefaultIdempotencyTTL))
+	handler.New(service.New(repository.New())).Register(r)
+	return r
+}
+
+func post(r http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	r := newRouter(repository.NewMemoryIdempotency())
+	body := `{"name":"Alice","email":"alice@example.com"}`
+
+	first := post(r, http.MethodPost, "/users", "key-1", body)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	second := post(r, http.MethodPost, "/users", "key-1", body)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "/users/1", second.Header().Get("Location"))
+	assert.Equal(t, "true", second.Header().Get(ReplayedHeader))
+	assert.Equal(t, []string{"1"}, second.Header().Values("RateLimit-Remaining"))
+
+	// без ключа запрос выполняется заново и упирается в уникальность email
+	third := post(r, http.MethodPost, "/users", "", body)
+	assert.Equal(t, http.StatusConflict, third.Code)
+}
+
+func TestIdempotency_DifferentPayload(t *testing.T) {
+	r := newRouter(repository.NewMemoryIdempotency())
+
+	rec := post(r, http.MethodPost, "/users", "key-1", `{"name":"Alice","email":"alice@example.com"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = post(r, http.MethodPost, "/users", "key-1", `{"name":"Bob","email":"bob@example.com"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotency_QueryIsPartOfPayload(t *testing.T) {
+	r := newRouter(repository.NewMemoryIdempotency())
+	body := `{"name":"Alice","email":"alice@example.com"}`
+
+	rec := post(r, http.MethodPost, "/users?a=1", "key-1", body)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = post(r, http.MethodPost, "/users?a=2", "key-1", body)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotency_ScopedToCaller(t *testing.T) {
+	r := newRouter(repository.NewMemoryIdempotency())
+	send := func(remoteAddr, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req.RemoteAddr = remoteAddr
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	alice := `{"name":"Alice","email":"alice@example.com"}`
+	bob := `{"name":"Bob","email":"bob@example.com"}`
+
+	rec := send("10.0.0.1:1000", "", alice)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// Another client with the same key gets its own request run, not
+	// Alice's stored response.
+	rec = send("10.0.0.2:1000", "", bob)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(ReplayedHeader))
+	assert.Contains(t, rec.Body.String(), "bob@example.com")
+
+	// A caller with credentials is recognized from any address.
+	carol := `{"name":"Carol","email":"carol@example.com"}`
+	rec = send("10.0.0.3:1000", "Bearer carol", carol)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec = send("10.0.0.4:1000", "Bearer carol", carol)
+	assert.Equal(t, "true", rec.Header().Get(ReplayedHeader))
+	rec = send("10.0.0.3:1000", "Bearer mallory", carol)
+	assert.Empty(t, rec.Header().Get(ReplayedHeader))
+	assert.Equal(t, http.StatusConflict, rec.Code, "runs again and hits the taken email")
+}
+
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	r := newRouter(repository.NewMemoryIdempotency())
+	body := `{"name":"` + strings.Repeat("a", MaxIdempotentBodySize) + `","email":"alice@example.com"}`
+
+	rec := post(r, http.MethodPost, "/users", "key-1", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = post(r, http.MethodPost, "/users", "key-1", `{"name":"Alice","email":"alice@example.com"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, "the rejected request does not reserve the key")
+}
+
+func TestIdempotency_ClientErrorsAreStored(t *testing.T) {
+	r := newRouter(repository.NewMemoryIdempotency())
+
+	rec := post(r, http.MethodPost, "/users", "key-1", `{"name":"","email":"x"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = post(r, http.MethodPost, "/users", "key-1", `{"name":"","email":"x"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(ReplayedHeader))
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+
+	r := gin.New()
+	r.Use(Idempotency(repository.NewMemoryIdempotency(), DefaultIdempotencyTTL))
+	r.POST("/jobs", func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusAccepted)
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, post(r, http.MethodPost, "/jobs", "k", "{}").Code)
+	assert.Equal(t, http.StatusAccepted, post(r, http.MethodPost, "/jobs", "k", "{}").Code)
+	assert.Equal(t, http.StatusAccepted, post(r, http.MethodPost, "/jobs", "k", "{}").Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	r := gin.New()
+	r.Use(Idempotency(repository.NewMemoryIdempotency(), DefaultIdempotencyTTL))
+	r.PATCH("/users/:id", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var first *httptest.ResponseRecorder
+	go func() {
+		defer wg.Done()
+		first = post(r, http.MethodPatch, "/users/1", "k", `{"name":"A"}`)
+	}()
+
+	<-started
+	rec := post(r, http.MethodPatch, "/users/1", "k", `{"name":"A"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	panics := true
+
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.Use(Idempotency(repository.NewMemoryIdempotency(), DefaultIdempotencyTTL))
+	r.POST("/boom", func(c *gin.Context) {
+		if panics {
+			panic("boom")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, post(r, http.MethodPost, "/boom", "k", "").Code)
+
+	panics = false
+	assert.Equal(t, http.StatusOK, post(r, http.MethodPost, "/boom", "k", "").Code)
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS users
+(
+    id      BIGSERIAL PRIMARY KEY,
+    name    TEXT   NOT NULL,
+    email   TEXT   NOT NULL,
+    version BIGINT NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email));
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions
+(
+    id                   BIGSERIAL PRIMARY KEY,
+    url                  TEXT        NOT NULL,
+    events               JSONB       NOT NULL,
+    secret               TEXT        NOT NULL,
+    active               BOOLEAN     NOT NULL DEFAULT true,
+    consecutive_failures INT         NOT NULL DEFAULT 0,
+    disabled_at          TIMESTAMPTZ,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT      NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        BIGINT      NOT NULL,
+    event_type      TEXT        NOT NULL,
+    -- BYTEA, not JSONB: a replay must send the exact bytes that were signed.
+    body            BYTEA       NOT NULL,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    replay_of       BIGINT,
+    attempts        JSONB       NOT NULL DEFAULT '[]',
+    locked_until    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- An outbox redelivery of the same event does not notify the partner twice.
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_event_idx ON webhook_deliveries (subscription_id, event_id)
+    WHERE replay_of IS NULL;
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_log_idx ON webhook_deliveries (subscription_id, id DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
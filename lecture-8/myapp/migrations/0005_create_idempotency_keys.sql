@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT        NOT NULL,
+    completed   BOOLEAN     NOT NULL DEFAULT false,
+    status      INT         NOT NULL DEFAULT 0,
+    header      JSONB,
+    body        BYTEA,
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at);
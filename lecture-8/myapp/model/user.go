@@ -0,0 +1,21 @@
+package model
+
+// User.Version grows by one on every update and is used for optimistic locking.
+type User struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Version int64  `json:"version"`
+}
+
+// UserPage is one page of a user listing. NextCursor is empty on the last page.
+type UserPage struct {
+	Items      []User `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Event types recorded in the outbox on user changes; the payload is the User.
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+)
@@ -0,0 +1,114 @@
+// Package openapi builds an OpenAPI 3.1 document from gin routes and the
+// Go types handlers read and write, serves it and validates responses against it.
+package openapi
+
+import "encoding/json"
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components,omitzero"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem maps a lower-case HTTP method to its operation.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema 2020-12 produced by this package.
+// Type holds one or more JSON types; ["string", "null"] is written as an array.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 []string           `json:"-"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+type schemaAlias Schema
+
+func (s Schema) MarshalJSON() ([]byte, error) {
+	var typ any
+	switch len(s.Type) {
+	case 0:
+	case 1:
+		typ = s.Type[0]
+	default:
+		typ = s.Type
+	}
+	return json.Marshal(struct {
+		Type any `json:"type,omitempty"`
+		schemaAlias
+	}{typ, schemaAlias(s)})
+}
+
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Type json.RawMessage `json:"type"`
+		schemaAlias
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*s = Schema(v.schemaAlias)
+
+	if len(v.Type) == 0 {
+		return nil
+	}
+	var one string
+	if err := json.Unmarshal(v.Type, &one); err == nil {
+		s.Type = []string{one}
+		return nil
+	}
+	return json.Unmarshal(v.Type, &s.Type)
+}
@@ -0,0 +1,179 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation describes what a route reads and writes. Body types are
+// Go values (usually zero values) whose types are turned into schemas.
+type Operation struct {
+	Summary string
+	// Request is the JSON body type; nil means no body.
+	Request any
+	// Responses maps a status code to the JSON body type; a nil value
+	// means the response has no body.
+	Responses map[int]any
+	// Params documents query and header parameters and may override the
+	// string type that is assumed for path parameters.
+	Params []Param
+	// Headers lists response headers, they are documented for 2xx responses.
+	Headers []string
+}
+
+type Param struct {
+	Name        string
+	In          string // "path", "query" or "header"
+	Type        any
+	Required    bool
+	Description string
+}
+
+// Registry collects operations by method and gin path, e.g. ("GET", "/users/:id").
+type Registry struct {
+	ops map[string]Operation
+}
+
+func NewRegistry() *Registry {
+	return &Registry{ops: make(map[string]Operation)}
+}
+
+func (r *Registry) Add(method, path string, op Operation) {
+	r.ops[method+" "+path] = op
+}
+
+// Generate builds the document for the registered gin routes. Routes without
+// an operation in reg are still listed with their path parameters.
+func Generate(info Info, routes gin.RoutesInfo, reg *Registry) *Document {
+	g := newSchemas()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	for _, route := range routes {
+		path, pathParams := convertPath(route.Path)
+		op := reg.ops[route.Method+" "+route.Path]
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = g.operation(route.Method, path, pathParams, op)
+	}
+
+	if len(g.components) > 0 {
+		doc.Components.Schemas = g.components
+	}
+	return doc
+}
+
+func (g *schemas) operation(method, path string, pathParams []string, op Operation) *OperationObject {
+	o := &OperationObject{
+		OperationID: operationID(method, path),
+		Summary:     op.Summary,
+		Responses:   make(map[string]Response),
+	}
+
+	overrides := make(map[string]Param)
+	for _, p := range op.Params {
+		if p.In == "path" {
+			overrides[p.Name] = p
+		}
+	}
+	for _, name := range pathParams {
+		p, ok := overrides[name]
+		if !ok {
+			p = Param{Name: name, In: "path"}
+		}
+		p.Required = true
+		o.Parameters = append(o.Parameters, g.parameter(p))
+	}
+	for _, p := range op.Params {
+		if p.In != "path" {
+			o.Parameters = append(o.Parameters, g.parameter(p))
+		}
+	}
+
+	if op.Request != nil {
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(g.of(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	for status, body := range op.Responses {
+		resp := Response{Description: http.StatusText(status)}
+		if body != nil {
+			resp.Content = jsonContent(g.of(reflect.TypeOf(body)))
+		}
+		if status < 300 && len(op.Headers) > 0 {
+			resp.Headers = make(map[string]Header)
+			for _, h := range op.Headers {
+				resp.Headers[h] = Header{Schema: &Schema{Type: []string{"string"}}}
+			}
+		}
+		o.Responses[strconv.Itoa(status)] = resp
+	}
+	if len(o.Responses) == 0 {
+		o.Responses["default"] = Response{Description: "Undocumented response"}
+	}
+	return o
+}
+
+func (g *schemas) parameter(p Param) Parameter {
+	typ := p.Type
+	if typ == nil {
+		typ = ""
+	}
+	return Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      g.of(reflect.TypeOf(typ)),
+	}
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// convertPath turns gin's /users/:id into OpenAPI's /users/{id}.
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, s := range segments {
+		if len(s) > 1 && (s[0] == ':' || s[0] == '*') {
+			params = append(params, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID makes ids like getUsersId from GET /users/{id}.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type person struct {
+	ID       int64          `json:"id"`
+	Name     string         `json:"name"`
+	Nickname *string        `json:"nickname"`
+	Tags     []string       `json:"tags,omitempty"`
+	Meta     map[string]int `json:"meta,omitempty"`
+	Born     time.Time      `json:"born"`
+	Address  *address       `json:"address"`
+	Friends  []person       `json:"friends,omitempty"`
+	Secret   string         `json:"-"`
+	internal string
+	Anything any               `json:"anything,omitempty"`
+	Extra    map[string]string `json:"extra,omitzero"`
+	embedded
+}
+
+type embedded struct {
+	CreatedBy string `json:"created_by"`
+}
+
+func newTestDocument() *Document {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	noop := func(*gin.Context) {}
+	r.GET("/people/:id", noop)
+	r.POST("/people", noop)
+	r.GET("/health", noop)
+
+	reg := NewRegistry()
+	reg.Add(http.MethodGet, "/people/:id", Operation{
+		Params:    []Param{{Name: "id", In: "path", Type: int64(0)}, {Name: "q", In: "query"}},
+		Headers:   []string{"ETag"},
+		Responses: map[int]any{http.StatusOK: person{}, http.StatusNotModified: nil},
+	})
+	reg.Add(http.MethodPost, "/people", Operation{
+		Request:   person{},
+		Responses: map[int]any{http.StatusCreated: person{}},
+	})
+	return Generate(Info{Title: "test", Version: "1"}, r.Routes(), reg)
+}
+
+func TestGenerate(t *testing.T) {
+	doc := newTestDocument()
+
+	require.Contains(t, doc.Paths, "/people/{id}")
+	get := doc.Paths["/people/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "getPeopleId", get.OperationID)
+	require.Len(t, get.Parameters, 2)
+	assert.Equal(t, Parameter{Name: "id", In: "path", Required: true,
+		Schema: &Schema{Type: []string{"integer"}, Format: "int64"}}, get.Parameters[0])
+	assert.Equal(t, "query", get.Parameters[1].In)
+	assert.Contains(t, get.Responses["200"].Headers, "ETag")
+	assert.Nil(t, get.Responses["304"].Content)
+
+	assert.Contains(t, doc.Paths["/health"]["get"].Responses, "default")
+
+	p := doc.Components.Schemas["Person"]
+	require.NotNil(t, p)
+	assert.ElementsMatch(t, []string{"id", "name", "born", "created_by"}, p.Required)
+	assert.NotContains(t, p.Properties, "Secret")
+	assert.NotContains(t, p.Properties, "internal")
+	assert.Equal(t, []string{"string", "null"}, p.Properties["nickname"].Type)
+	assert.Equal(t, "date-time", p.Properties["born"].Format)
+	assert.Equal(t, "#/components/schemas/Address", p.Properties["address"].AnyOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/Person", p.Properties["friends"].Items.Ref)
+	assert.Equal(t, []string{"integer"}, p.Properties["meta"].AdditionalProperties.Type)
+}
+
+func TestDocument_JSONRoundTrip(t *testing.T) {
+	doc := newTestDocument()
+
+	b, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"openapi":"3.1.0"`)
+	assert.Contains(t, string(b), `"type":["string","null"]`)
+	assert.Contains(t, string(b), `"type":"object"`)
+
+	var back Document
+	require.NoError(t, json.Unmarshal(b, &back))
+	assert.Equal(t, doc.Components.Schemas["Person"], back.Components.Schemas["Person"])
+}
+
+func TestDocument_Lookup(t *testing.T) {
+	op := func(id string) *OperationObject { return &OperationObject{OperationID: id} }
+	doc := &Document{Paths: map[string]PathItem{
+		"/users/{id}":              {"get": op("getUser"), "patch": op("updateUser")},
+		"/users/me":                {"get": op("getMe")},
+		"/users/{id}/posts/latest": {"get": op("latestPost")},
+		"/users/me/posts/{post}":   {"get": op("myPost")},
+	}}
+
+	tests := []struct{ method, path, want string }{
+		{"GET", "/users/42", "getUser"},
+		{"GET", "/users/me", "getMe"},
+		{"PATCH", "/users/me", "updateUser"},
+		{"GET", "/users/me/posts/latest", "myPost"},
+		{"GET", "/users/7/posts/latest", "latestPost"},
+	}
+	// Map order changes between runs; the result must not.
+	for range 20 {
+		for _, tt := range tests {
+			got, err := doc.Lookup(tt.method, tt.path)
+			require.NoError(t, err, tt.path)
+			assert.Equal(t, tt.want, got.OperationID, "%s %s", tt.method, tt.path)
+		}
+	}
+
+	_, err := doc.Lookup("DELETE", "/users/me")
+	assert.Error(t, err)
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := newTestDocument()
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}, "Etag": {`"1"`}}
+
+	valid := `{"id":1,"name":"A","nickname":null,"born":"2000-01-01T00:00:00Z","address":{"city":"X"},"created_by":"me"}`
+	assert.NoError(t, doc.ValidateResponse("GET", "/people/1", 200, jsonHeader, []byte(valid)))
+	assert.NoError(t, doc.ValidateResponse("GET", "/people/1", 304, http.Header{}, nil))
+
+	tests := map[string]struct {
+		path   string
+		status int
+		header http.Header
+		body   string
+	}{
+		"undocumented path":   {"/cars/1", 200, jsonHeader, valid},
+		"undocumented status": {"/people/1", 500, jsonHeader, valid},
+		"missing header":      {"/people/1", 200, http.Header{"Content-Type": {"application/json"}}, valid},
+		"wrong content type":  {"/people/1", 200, http.Header{"Content-Type": {"text/plain"}, "Etag": {"x"}}, valid},
+		"missing required":    {"/people/1", 200, jsonHeader, `{"id":1,"born":"2000-01-01T00:00:00Z","created_by":"me"}`},
+		"wrong type":          {"/people/1", 200, jsonHeader, `{"id":"1","name":"A","born":"2000-01-01T00:00:00Z","created_by":"me"}`},
+		"not integer":         {"/people/1", 200, jsonHeader, `{"id":1.5,"name":"A","born":"2000-01-01T00:00:00Z","created_by":"me"}`},
+		"bad date-time":       {"/people/1", 200, jsonHeader, `{"id":1,"name":"A","born":"yesterday","created_by":"me"}`},
+		"nested":              {"/people/1", 200, jsonHeader, `{"id":1,"name":"A","born":"2000-01-01T00:00:00Z","created_by":"me","friends":[{"id":2}]}`},
+		"unexpected body":     {"/people/1", 304, http.Header{}, `{}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := doc.ValidateResponse("GET", tt.path, tt.status, tt.header, []byte(tt.body))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc := newTestDocument()
+	header := http.Header{"Content-Type": {"application/json"}}
+
+	err := doc.ValidateRequest("POST", "/people", header, []byte(`{"name":"A"}`))
+	assert.ErrorContains(t, err, `"id" is missing`)
+
+	err = doc.ValidateRequest("GET", "/people/1", header, nil)
+	assert.NoError(t, err)
+}
+
+func TestServe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/health", func(*gin.Context) {})
+	Serve(r, Info{Title: "test", Version: "1"}, NewRegistry())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var doc Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Contains(t, doc.Paths, "/health")
+	assert.Contains(t, doc.Paths, "/openapi.json", "the spec lists its own endpoints")
+	assert.Equal(t, "Interactive documentation", doc.Paths["/docs"]["get"].Summary)
+}
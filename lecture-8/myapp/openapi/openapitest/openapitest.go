@@ -0,0 +1,43 @@
+// Package openapitest checks httptest responses against an OpenAPI document.
+package openapitest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ITMO-students/lecture-8/myapp/openapi"
+)
+
+// AssertResponse fails the test if the recorded response to req does not
+// conform to doc. req is the request that was served; its body is not read.
+func AssertResponse(t testing.TB, doc *openapi.Document, req *http.Request, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	err := doc.ValidateResponse(req.Method, req.URL.Path, rec.Code, rec.Header(), rec.Body.Bytes())
+	if err != nil {
+		t.Errorf("response does not conform to the spec:\n%v\nbody: %s", err, rec.Body.String())
+	}
+}
+
+// AssertRequest fails the test if the body of req does not conform to doc.
+// The body is restored, so req can be served afterwards.
+func AssertRequest(t testing.TB, doc *openapi.Document, req *http.Request) {
+	t.Helper()
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("read request body: %v", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if err := doc.ValidateRequest(req.Method, req.URL.Path, req.Header, body); err != nil {
+		t.Errorf("request does not conform to the spec:\n%v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemas turns Go types into schemas following encoding/json rules.
+// Named structs go to components and are referenced by $ref.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+func (g *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: []string{"string"}, Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.of(t.Elem())
+		if s.Ref != "" {
+			return &Schema{AnyOf: []*Schema{s, {Type: []string{"null"}}}}
+		}
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: []string{"integer"}, Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: []string{"integer"}, Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: []string{"number"}}
+	case reflect.String:
+		return &Schema{Type: []string{"string"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: []string{"string"}, Format: "byte"}
+		}
+		return &Schema{Type: []string{"array"}, Items: g.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object"}, AdditionalProperties: g.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	default:
+		// interface{} and anything else accept any JSON value
+		return &Schema{}
+	}
+}
+
+func (g *schemas) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = componentName(t)
+		g.names[t] = name
+		g.components[name] = &Schema{} // placeholder for recursive types
+		*g.components[name] = *g.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *schemas) object(t reflect.Type) *Schema {
+	s := &Schema{Type: []string{"object"}, Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+func (g *schemas) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, s)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = g.of(f.Type)
+		optional := strings.Contains(opts, "omitempty") ||
+			strings.Contains(opts, "omitzero") ||
+			f.Type.Kind() == reflect.Pointer
+		if !optional {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// componentName makes a schema name from the Go type name:
+// createUserRequest -> CreateUserRequest, Page[model.User] -> PageModelUser.
+func componentName(t reflect.Type) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range t.Name() {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Register serves doc at /openapi.json and a docs page at /docs.
+func Register(r gin.IRouter, doc *Document) {
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	})
+}
+
+// Serve mounts the routes of Register on r and generates their document
+// afterwards, so the spec lists /openapi.json and /docs as well.
+func Serve(r *gin.Engine, info Info, reg *Registry) *Document {
+	reg.Add(http.MethodGet, "/openapi.json", Operation{Summary: "This document"})
+	reg.Add(http.MethodGet, "/docs", Operation{Summary: "Interactive documentation"})
+
+	doc := new(Document)
+	Register(r, doc)
+	*doc = *Generate(info, r.Routes(), reg)
+	return doc
+}
@@ -0,0 +1,253 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lookup finds the operation for a concrete request path such as /users/42.
+// Like the router, it prefers literal segments over parameters, so
+// /users/me wins over /users/{id}.
+func (d *Document) Lookup(method, path string) (*OperationObject, error) {
+	method = strings.ToLower(method)
+	var best string
+	var found *OperationObject
+	// Sorted, so that equally specific templates resolve the same way
+	// on every run.
+	for _, template := range slices.Sorted(maps.Keys(d.Paths)) {
+		op, ok := d.Paths[template][method]
+		if !ok || !matchPath(template, path) {
+			continue
+		}
+		if found == nil || moreSpecific(template, best) {
+			best, found = template, op
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("openapi: %s %s is not documented", strings.ToUpper(method), path)
+	}
+	return found, nil
+}
+
+// ValidateResponse checks that status, documented headers and body of a
+// response to method and path conform to the document.
+func (d *Document) ValidateResponse(method, path string, status int, header http.Header, body []byte) error {
+	op, err := d.Lookup(method, path)
+	if err != nil {
+		return err
+	}
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("openapi: %s %s: status %d is not documented", strings.ToUpper(method), path, status)
+	}
+
+	var errs []error
+	for name := range resp.Headers {
+		if header.Get(name) == "" {
+			errs = append(errs, fmt.Errorf("header %s is missing", name))
+		}
+	}
+
+	if resp.Content == nil {
+		if len(bytes.TrimSpace(body)) > 0 {
+			errs = append(errs, errors.New("body is not documented"))
+		}
+	} else if err := d.validateJSON(resp.Content, header.Get("Content-Type"), body); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("openapi: %s %s %d: %w", strings.ToUpper(method), path, status, err)
+	}
+	return nil
+}
+
+// ValidateRequest checks the request body against the documented one.
+func (d *Document) ValidateRequest(method, path string, header http.Header, body []byte) error {
+	op, err := d.Lookup(method, path)
+	if err != nil {
+		return err
+	}
+	if op.RequestBody == nil {
+		return nil
+	}
+	if err := d.validateJSON(op.RequestBody.Content, header.Get("Content-Type"), body); err != nil {
+		return fmt.Errorf("openapi: %s %s request: %w", strings.ToUpper(method), path, err)
+	}
+	return nil
+}
+
+func (d *Document) validateJSON(content map[string]MediaType, contentType string, body []byte) error {
+	media, _, _ := mime.ParseMediaType(contentType)
+	mt, ok := content[media]
+	if !ok {
+		return fmt.Errorf("content type %q is not documented", contentType)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return d.Validate(mt.Schema, v)
+}
+
+// Validate checks a decoded JSON value (numbers as json.Number or float64)
+// against schema and reports every mismatch.
+func (d *Document) Validate(s *Schema, v any) error {
+	return errors.Join(d.validate(s, v, "$")...)
+}
+
+func (d *Document) validate(s *Schema, v any, at string) []error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		ref, ok := d.resolve(s.Ref)
+		if !ok {
+			return []error{fmt.Errorf("%s: unknown $ref %s", at, s.Ref)}
+		}
+		return d.validate(ref, v, at)
+	}
+	if len(s.AnyOf) > 0 {
+		for _, alt := range s.AnyOf {
+			if len(d.validate(alt, v, at)) == 0 {
+				return nil
+			}
+		}
+		return []error{fmt.Errorf("%s: value matches none of anyOf", at)}
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return isType(v, t) }) {
+		return []error{fmt.Errorf("%s: expected %s, got %s", at, strings.Join(s.Type, " or "), jsonType(v))}
+	}
+
+	var errs []error
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s: required property %q is missing", at, name))
+			}
+		}
+		for name, value := range v {
+			if prop, ok := s.Properties[name]; ok {
+				errs = append(errs, d.validate(prop, value, at+"."+name)...)
+			} else if s.AdditionalProperties != nil {
+				errs = append(errs, d.validate(s.AdditionalProperties, value, at+"."+name)...)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			errs = append(errs, d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a date-time", at, v))
+			}
+		}
+	}
+	return errs
+}
+
+func (d *Document) resolve(ref string) (*Schema, bool) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok {
+		return nil, false
+	}
+	s, ok := d.Components.Schemas[name]
+	return s, ok
+}
+
+func isType(v any, t string) bool {
+	switch t {
+	case "integer":
+		switch n := v.(type) {
+		case json.Number:
+			_, err := n.Int64()
+			return err == nil
+		case float64:
+			return n == float64(int64(n))
+		}
+		return false
+	case "number":
+		switch v.(type) {
+		case json.Number, float64:
+			return true
+		}
+		return false
+	}
+	return jsonType(v) == t
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// matchPath matches /users/42 against the template /users/{id}.
+func matchPath(template, path string) bool {
+	ts := strings.Split(strings.Trim(template, "/"), "/")
+	ps := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ts) != len(ps) {
+		return false
+	}
+	for i := range ts {
+		if isParam(ts[i]) {
+			if ps[i] == "" {
+				return false
+			}
+			continue
+		}
+		if ts[i] != ps[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// moreSpecific reports whether template a has a literal segment where b
+// has a parameter, at the first segment where they differ that way. Both
+// must match the same path.
+func moreSpecific(a, b string) bool {
+	as := strings.Split(strings.Trim(a, "/"), "/")
+	bs := strings.Split(strings.Trim(b, "/"), "/")
+	for i := range as {
+		if ap, bp := isParam(as[i]), isParam(bs[i]); ap != bp {
+			return bp
+		}
+	}
+	return false
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
@@ -0,0 +1,97 @@
+// Package outbox delivers domain events recorded by repositories in the same
+// transaction as the change that caused them (the transactional outbox
+// pattern). A Relay polls the Store and hands events to a Publisher;
+// delivery is at-least-once, so consumers must deduplicate by Event.ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	// StatusDead events ran out of attempts or failed permanently;
+	// they stay in the store for inspection and manual replay.
+	StatusDead Status = "dead"
+)
+
+type Event struct {
+	ID int64 `json:"id"`
+	// Type is e.g. "user.created"; see the Event* constants in model.
+	Type string `json:"type"`
+	// AggregateID identifies the changed entity, e.g. the user ID.
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+
+	Status        Status    `json:"-"`
+	Attempts      int       `json:"-"`
+	NextAttemptAt time.Time `json:"-"`
+	LastError     string    `json:"-"`
+	// LockedUntil is set by Claim and identifies the claim to MarkDelivered
+	// and MarkFailed.
+	LockedUntil time.Time `json:"-"`
+}
+
+// NewEvent encodes payload as JSON. ID and CreatedAt are set by the store.
+func NewEvent(typ, aggregateID string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: typ, AggregateID: aggregateID, Payload: data}, nil
+}
+
+// Store is the relay's view of the outbox table.
+type Store interface {
+	// Claim returns up to limit pending events due at now, oldest first, and
+	// hides them from other relays until now+lease. An event whose relay
+	// died mid-delivery becomes claimable again once the lease runs out.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Event, error)
+	//
+	// MarkDelivered and MarkFailed only apply to the claim whose LockedUntil
+	// they are given. Once another relay has claimed, delivered or
+	// dead-lettered the event they return ErrLeaseLost.
+	MarkDelivered(ctx context.Context, id int64, lockedUntil time.Time) error
+	// MarkFailed records a failed attempt. The event is retried at next,
+	// or moves to StatusDead if dead is true.
+	MarkFailed(ctx context.Context, id int64, lockedUntil time.Time, lastErr string, next time.Time, dead bool) error
+}
+
+// ErrLeaseLost means the claim ran out and another relay took the event
+// over; the result of this delivery is discarded.
+var ErrLeaseLost = errors.New("outbox lease lost")
+
+// Publisher sends an event downstream. Returning nil acknowledges it.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+type PublisherFunc func(ctx context.Context, e Event) error
+
+func (f PublisherFunc) Publish(ctx context.Context, e Event) error { return f(ctx, e) }
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: the relay dead-letters the
+// event at once instead of backing off.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// LogPublisher writes every event to a logger; handy in development.
+type LogPublisher struct {
+	Logger *slog.Logger
+}
+
+func (p LogPublisher) Publish(ctx context.Context, e Event) error {
+	logger := p.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "event", "id", e.ID, "type", e.Type,
+		"aggregate_id", e.AggregateID, "payload", string(e.Payload))
+	return nil
+}
+
+// FilePublisher appends events to a file as JSON lines.
+type FilePublisher struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FilePublisher{f: f}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Permanent(err)
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.f.Write(line); err != nil {
+		return err
+	}
+	// Acknowledging means the event survives a crash.
+	return p.f.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.f.Close()
+}
+
+// WebhookPublisher POSTs each event as JSON to URL. Consumers can
+// deduplicate retries by the Event-ID header. Use Client to set timeouts
+// and transports; the default is http.DefaultClient.
+type WebhookPublisher struct {
+	URL    string
+	Client *http.Client
+}
+
+func (p WebhookPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Event-ID", strconv.FormatInt(e.ID, 10))
+	req.Header.Set("Event-Type", e.Type)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode >= 500:
+		return fmt.Errorf("webhook %s: status %d", p.URL, resp.StatusCode)
+	default:
+		// The receiver rejected the event; sending it again will not help.
+		return Permanent(fmt.Errorf("webhook %s: status %d", p.URL, resp.StatusCode))
+	}
+}
+
+// Fanout publishes each event to every publisher in order and fails if
+// any of them fails. The relay then retries the event for all of them,
+// so each publisher must tolerate duplicates.
+func Fanout(pubs ...Publisher) Publisher {
+	return PublisherFunc(func(ctx context.Context, e Event) error {
+		var errs []error
+		for _, p := range pubs {
+			if err := p.Publish(ctx, e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
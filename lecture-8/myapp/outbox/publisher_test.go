@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(t *testing.T) Event {
+	t.Helper()
+	e, err := NewEvent("user.created", "1", map[string]string{"name": "Alice"})
+	require.NoError(t, err)
+	e.ID = 7
+	e.CreatedAt = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	return e
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	pub, err := NewFilePublisher(path)
+	require.NoError(t, err)
+
+	e := testEvent(t)
+	require.NoError(t, pub.Publish(context.Background(), e))
+	require.NoError(t, pub.Publish(context.Background(), e))
+	require.NoError(t, pub.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines int
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var got Event
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &got))
+		assert.Equal(t, e.ID, got.ID)
+		assert.Equal(t, e.Type, got.Type)
+		assert.JSONEq(t, `{"name":"Alice"}`, string(got.Payload))
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{http.StatusNoContent, false, false},
+		{http.StatusServiceUnavailable, true, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusBadRequest, true, true},
+		{http.StatusGone, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var header http.Header
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			pub := WebhookPublisher{URL: srv.URL, Client: srv.Client()}
+			err := pub.Publish(context.Background(), testEvent(t))
+
+			if !tt.wantErr {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+			assert.Equal(t, tt.wantPermanent, IsPermanent(err))
+			assert.Equal(t, "7", header.Get("Event-ID"))
+			assert.Equal(t, "user.created", header.Get("Event-Type"))
+			assert.JSONEq(t, `{"id":7,"type":"user.created","aggregate_id":"1",
+				"payload":{"name":"Alice"},"created_at":"2025-01-01T12:00:00Z"}`, string(body))
+		})
+	}
+}
+
+func TestWebhookPublisher_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	err := WebhookPublisher{URL: url}.Publish(context.Background(), testEvent(t))
+	require.Error(t, err)
+	assert.False(t, IsPermanent(err), "network errors are retried")
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, DefaultBackoff(1))
+	assert.Equal(t, 8*time.Second, DefaultBackoff(4))
+	assert.Equal(t, time.Hour, DefaultBackoff(50))
+}
+
+func TestFanout(t *testing.T) {
+	var calls []string
+	ok := PublisherFunc(func(ctx context.Context, e Event) error {
+		calls = append(calls, "ok")
+		return nil
+	})
+	failing := PublisherFunc(func(ctx context.Context, e Event) error {
+		calls = append(calls, "failing")
+		return Permanent(errors.New("rejected"))
+	})
+
+	err := Fanout(failing, ok).Publish(context.Background(), testEvent(t))
+	require.Error(t, err)
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, []string{"failing", "ok"}, calls, "a failure does not skip later publishers")
+
+	assert.NoError(t, Fanout(ok, ok).Publish(context.Background(), testEvent(t)))
+}
@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// RelayOptions configures a Relay. Zero fields take the defaults noted below.
+type RelayOptions struct {
+	// BatchSize is how many events are claimed per poll. Default 100.
+	BatchSize int
+	// PollInterval is the pause after a poll that found less than a full batch. Default 1s.
+	PollInterval time.Duration
+	// Lease is how long claimed events stay hidden from other relays. Default 30s.
+	Lease time.Duration
+	// MaxAttempts is how many failed deliveries move an event to StatusDead. Default 10.
+	MaxAttempts int
+	// Backoff returns the delay after the given failed attempt (1-based).
+	// Default: 1s doubling up to 1h.
+	Backoff func(attempt int) time.Duration
+	Logger  *slog.Logger
+	Now     func() time.Time
+}
+
+// Relay moves events from a Store to a Publisher. Several relays may share
+// one Store; Claim keeps them from delivering the same event concurrently.
+type Relay struct {
+	store Store
+	pub   Publisher
+	opts  RelayOptions
+}
+
+func NewRelay(store Store, pub Publisher, opts RelayOptions) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 30 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Relay{store: store, pub: pub, opts: opts}
+}
+
+// DefaultBackoff is 1s, 2s, 4s, ... capped at one hour.
+func DefaultBackoff(attempt int) time.Duration {
+	d := time.Second << min(attempt-1, 12)
+	return min(d, time.Hour)
+}
+
+// Run polls until ctx is canceled. Store errors are logged and retried
+// on the next poll.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		n, err := r.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.opts.Logger.Error("outbox: process batch", "error", err)
+		}
+		if n == r.opts.BatchSize && err == nil {
+			continue
+		}
+
+		t := time.NewTimer(r.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// ProcessBatch claims one batch and tries to deliver each event once.
+// It returns the number of events claimed.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	events, err := r.store.Claim(ctx, r.opts.Now(), r.opts.BatchSize, r.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range events {
+		if err := r.deliver(ctx, e); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+func (r *Relay) deliver(ctx context.Context, e Event) error {
+	// An event that waited past its claim may already be sent elsewhere.
+	if !r.opts.Now().Before(e.LockedUntil) {
+		r.opts.Logger.Warn("outbox: claim expired before publishing", "event_id", e.ID)
+		return nil
+	}
+
+	pubErr := r.pub.Publish(ctx, e)
+	if pubErr == nil {
+		return r.leaseLost(e, r.store.MarkDelivered(ctx, e.ID, e.LockedUntil))
+	}
+	if ctx.Err() != nil {
+		// Shutting down: the lease expires and the event is retried later.
+		return ctx.Err()
+	}
+
+	attempt := e.Attempts + 1
+	dead := attempt >= r.opts.MaxAttempts || IsPermanent(pubErr)
+	next := r.opts.Now().Add(r.opts.Backoff(attempt))
+
+	log := r.opts.Logger.With("event_id", e.ID, "type", e.Type, "attempt", attempt, "error", pubErr)
+	if dead {
+		log.Error("outbox: event dead-lettered")
+	} else {
+		log.Warn("outbox: publish failed", "retry_at", next)
+	}
+	return r.leaseLost(e, r.store.MarkFailed(ctx, e.ID, e.LockedUntil, pubErr.Error(), next, dead))
+}
+
+// leaseLost logs and drops ErrLeaseLost: the relay that took the event
+// over records the outcome, and the rest of the batch is still ours.
+func (r *Relay) leaseLost(e Event, err error) error {
+	if errors.Is(err, ErrLeaseLost) {
+		r.opts.Logger.Warn("outbox: lease lost before recording the result", "event_id", e.ID)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Duration is a time.Duration that is written in config as "1m", "500ms" etc.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Quota allows Requests per Period with bursts of up to Burst requests.
+// Zero Requests means the route is not limited.
+type Quota struct {
+	Requests int      `json:"requests"`
+	Period   Duration `json:"period"`
+	Burst    int      `json:"burst,omitempty"`
+}
+
+func (q Quota) limited() bool {
+	return q.Requests > 0
+}
+
+// burst returns the bucket capacity; it defaults to Requests.
+func (q Quota) burst() int {
+	if q.Burst > 0 {
+		return q.Burst
+	}
+	return q.Requests
+}
+
+// interval is the time needed to restore a single token.
+func (q Quota) interval() time.Duration {
+	return time.Duration(q.Period) / time.Duration(q.Requests)
+}
+
+// Config holds the default quota and per-route overrides.
+// Routes are keyed by "METHOD /path" where path is the registered
+// pattern, e.g. "GET /users/:id" for gin or "GET /users/{id}" for net/http.
+type Config struct {
+	Default Quota            `json:"default"`
+	Routes  map[string]Quota `json:"routes,omitempty"`
+}
+
+func LoadConfig(r io.Reader) (Config, error) {
+	var cfg Config
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("decode rate limit config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("default quota: %w", err)
+	}
+	for route, q := range c.Routes {
+		if err := q.validate(); err != nil {
+			return fmt.Errorf("quota for %q: %w", route, err)
+		}
+	}
+	return nil
+}
+
+func (q Quota) validate() error {
+	switch {
+	case q.Requests < 0:
+		return fmt.Errorf("negative requests %d", q.Requests)
+	case q.Requests > 0 && q.Period <= 0:
+		return fmt.Errorf("period must be positive")
+	case q.Requests > 0 && q.interval() == 0:
+		return fmt.Errorf("period %v is too short for %d requests", time.Duration(q.Period), q.Requests)
+	case q.Burst < 0:
+		return fmt.Errorf("negative burst %d", q.Burst)
+	}
+	return nil
+}
+
+// quota returns the quota for route and the bucket namespace it belongs to.
+// Routes without an override share the default bucket.
+func (c Config) quota(route string) (Quota, string) {
+	if q, ok := c.Routes[route]; ok {
+		return q, route
+	}
+	return c.Default, "*"
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc identifies the client a request belongs to.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP uses the peer address. X-Forwarded-For is not trusted here:
+// put a proxy-aware KeyFunc in front if the service runs behind one.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// KeyByHeader uses a header such as X-API-Key and falls back to the IP.
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return "key:" + v
+		}
+		return KeyByIP(r)
+	}
+}
+
+// KeyByUser uses the user ID an auth middleware put into the request context
+// under ctxKey and falls back to the IP for anonymous requests.
+func KeyByUser(ctxKey any) KeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Context().Value(ctxKey); v != nil {
+			if id := fmt.Sprint(v); id != "" {
+				return "user:" + id
+			}
+		}
+		return KeyByIP(r)
+	}
+}
+
+// Middleware limits a net/http handler. The route is taken from
+// r.Pattern when the handler is registered on a ServeMux, so wrap
+// individual handlers to get per-route quotas.
+//
+// Store errors fail open: the request is served without limiting.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Pattern
+		if route == "" {
+			route = r.Method + " " + r.URL.Path
+		}
+
+		res, err := l.Allow(r.Context(), route, l.key(r))
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeHeaders(w.Header(), res)
+		if !res.Allowed {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Gin limits a gin route; the route is "METHOD " + c.FullPath().
+func (l *Limiter) Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+
+		res, err := l.Allow(c.Request.Context(), route, l.key(c.Request))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		writeHeaders(c.Writer.Header(), res)
+		if !res.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// writeHeaders sets RateLimit-* fields from draft-ietf-httpapi-ratelimit-headers
+// and Retry-After for rejected requests.
+func writeHeaders(h http.Header, res Result) {
+	if res.Limit == 0 {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", seconds(res.ResetAfter))
+	if !res.Allowed {
+		h.Set("Retry-After", seconds(res.RetryAfter))
+	}
+}
+
+// seconds rounds up so clients never retry too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
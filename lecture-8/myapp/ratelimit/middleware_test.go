@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_Headers(t *testing.T) {
+	l, clock, _ := newTestLimiter(Config{
+		Default: Quota{Requests: 2, Period: Duration(10 * time.Second)},
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.RemoteAddr = "10.0.0.1:5555"
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "5", rec.Header().Get("RateLimit-Reset"))
+
+	do()
+	rec = do()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	clock.Advance(5 * time.Second)
+	rec = do()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestGin_RouteQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l, _, _ := newTestLimiter(Config{
+		Default: Quota{Requests: 100, Period: Duration(time.Second)},
+		Routes: map[string]Quota{
+			"GET /users/:id": {Requests: 1, Period: Duration(time.Minute)},
+		},
+	})
+	l.key = KeyByHeader("X-API-Key")
+
+	r := gin.New()
+	r.Use(l.Gin())
+	r.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, do("/users/1", "alice").Code)
+
+	rec := do("/users/2", "alice")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, do("/users/1", "bob").Code)
+}
+
+type userKey struct{}
+
+func TestKeyFuncs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.168.1.10:1234"
+
+	assert.Equal(t, "ip:192.168.1.10", KeyByIP(req))
+	assert.Equal(t, "ip:192.168.1.10", KeyByHeader("X-API-Key")(req))
+	assert.Equal(t, "ip:192.168.1.10", KeyByUser(userKey{})(req))
+
+	req.Header.Set("X-API-Key", "secret")
+	assert.Equal(t, "key:secret", KeyByHeader("X-API-Key")(req))
+
+	req = req.WithContext(context.WithValue(req.Context(), userKey{}, 42))
+	assert.Equal(t, "user:42", KeyByUser(userKey{})(req))
+}
@@ -0,0 +1,99 @@
+// Package ratelimit implements GCRA (a token bucket without a refill timer)
+// limits with per-route quotas and middleware for net/http and gin.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+// casAttempts bounds retries when concurrent requests race on the same key.
+const casAttempts = 8
+
+var ErrContention = errors.New("ratelimit: too much contention on key")
+
+// Result describes the outcome of a single Allow call.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is allowed, zero if Allowed
+}
+
+type Limiter struct {
+	cfg   Config
+	store Store
+	key   KeyFunc
+	clock clock.Clock
+}
+
+// New creates a limiter. A nil clk means clock.Real(), a nil key means KeyByIP.
+func New(cfg Config, store Store, key KeyFunc, clk clock.Clock) *Limiter {
+	if clk == nil {
+		clk = clock.Real()
+	}
+	if key == nil {
+		key = KeyByIP
+	}
+	return &Limiter{cfg: cfg, store: store, key: key, clock: clk}
+}
+
+// Allow takes one token from the bucket of client on route.
+// Unlimited routes are always allowed and report a zero Limit.
+func (l *Limiter) Allow(ctx context.Context, route, client string) (Result, error) {
+	q, bucket := l.cfg.quota(route)
+	if !q.limited() {
+		return Result{Allowed: true}, nil
+	}
+
+	key := bucket + "|" + client
+	interval := q.interval()
+	tolerance := interval * time.Duration(q.burst())
+
+	for range casAttempts {
+		now := l.clock.Now()
+
+		stored, exists, err := l.store.Get(ctx, key)
+		if err != nil {
+			return Result{}, err
+		}
+
+		// tat - theoretical arrival time: when the bucket becomes full.
+		tat := now
+		if exists && stored > now.UnixNano() {
+			tat = time.Unix(0, stored)
+		}
+
+		newTat := tat.Add(interval)
+		allowAt := newTat.Add(-tolerance)
+		if now.Before(allowAt) {
+			return Result{
+				Allowed:    false,
+				Limit:      q.burst(),
+				Remaining:  0,
+				ResetAfter: tat.Sub(now),
+				RetryAfter: allowAt.Sub(now),
+			}, nil
+		}
+
+		ok, err := l.store.CompareAndSwap(ctx, key, stored, exists, newTat.UnixNano(), newTat.Sub(now))
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			continue
+		}
+
+		return Result{
+			Allowed:    true,
+			Limit:      q.burst(),
+			Remaining:  int(now.Sub(allowAt) / interval),
+			ResetAfter: newTat.Sub(now),
+		}, nil
+	}
+	return Result{}, ErrContention
+}
@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+func newTestLimiter(cfg Config) (*Limiter, *clock.Fake, *MemoryStore) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryStore(fake)
+	return New(cfg, store, nil, fake), fake, store
+}
+
+func TestLimiter_Burst(t *testing.T) {
+	l, clock, _ := newTestLimiter(Config{
+		Default: Quota{Requests: 1, Period: Duration(time.Second), Burst: 3},
+	})
+	ctx := context.Background()
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		res, err := l.Allow(ctx, "GET /users", "ip:1.1.1.1")
+		require.NoError(t, err)
+		assert.True(t, res.Allowed, "request %d", i)
+		assert.Equal(t, 3, res.Limit)
+		assert.Equal(t, wantRemaining, res.Remaining)
+	}
+
+	res, err := l.Allow(ctx, "GET /users", "ip:1.1.1.1")
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.ResetAfter)
+
+	// другой клиент не затронут
+	res, err = l.Allow(ctx, "GET /users", "ip:2.2.2.2")
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	clock.Advance(time.Second)
+	res, err = l.Allow(ctx, "GET /users", "ip:1.1.1.1")
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	l, clock, _ := newTestLimiter(Config{
+		Default: Quota{Requests: 10, Period: Duration(time.Minute)},
+	})
+	ctx := context.Background()
+
+	for range 10 {
+		res, err := l.Allow(ctx, "GET /", "c")
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+	}
+	res, _ := l.Allow(ctx, "GET /", "c")
+	require.False(t, res.Allowed)
+	assert.Equal(t, 6*time.Second, res.RetryAfter)
+
+	clock.Advance(time.Minute)
+	res, _ = l.Allow(ctx, "GET /", "c")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 9, res.Remaining)
+}
+
+func TestLimiter_RouteQuotas(t *testing.T) {
+	l, _, _ := newTestLimiter(Config{
+		Default: Quota{Requests: 100, Period: Duration(time.Second)},
+		Routes: map[string]Quota{
+			"POST /login":  {Requests: 1, Period: Duration(time.Minute)},
+			"GET /healthz": {},
+		},
+	})
+	ctx := context.Background()
+
+	res, _ := l.Allow(ctx, "POST /login", "c")
+	assert.True(t, res.Allowed)
+	res, _ = l.Allow(ctx, "POST /login", "c")
+	assert.False(t, res.Allowed)
+
+	// маршруты без своей квоты делят общий бакет
+	res, _ = l.Allow(ctx, "GET /users", "c")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 99, res.Remaining)
+	res, _ = l.Allow(ctx, "GET /users/1", "c")
+	assert.Equal(t, 98, res.Remaining)
+
+	for range 1000 {
+		res, _ = l.Allow(ctx, "GET /healthz", "c")
+		require.True(t, res.Allowed)
+	}
+	assert.Zero(t, res.Limit)
+}
+
+func TestLimiter_Concurrent(t *testing.T) {
+	l, _, _ := newTestLimiter(Config{
+		Default: Quota{Requests: 50, Period: Duration(time.Hour)},
+	})
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := l.Allow(context.Background(), "GET /", "c")
+			if err != nil {
+				return
+			}
+			if res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, allowed, 50)
+}
+
+func TestMemoryStore_Eviction(t *testing.T) {
+	l, clock, store := newTestLimiter(Config{
+		Default: Quota{Requests: 1, Period: Duration(time.Second)},
+	})
+	ctx := context.Background()
+
+	for _, client := range []string{"a", "b", "c"} {
+		_, err := l.Allow(ctx, "GET /", client)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, store.Len())
+
+	clock.Advance(defaultSweepInterval)
+	_, err := l.Allow(ctx, "GET /", "d")
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(`{
+		"default": {"requests": 100, "period": "1m"},
+		"routes": {"POST /login": {"requests": 5, "period": "1m", "burst": 2}}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, Quota{Requests: 100, Period: Duration(time.Minute)}, cfg.Default)
+	assert.Equal(t, 2, cfg.Routes["POST /login"].burst())
+
+	_, err = LoadConfig(strings.NewReader(`{"default": {"requests": 1}}`))
+	assert.Error(t, err)
+
+	_, err = LoadConfig(strings.NewReader(`{"default": {"requests": 1, "period": "soon"}}`))
+	assert.Error(t, err)
+
+	// A token per less than a nanosecond would divide by zero in Allow.
+	_, err = LoadConfig(strings.NewReader(`{"default": {"requests": 2000, "period": "1us"}}`))
+	assert.Error(t, err)
+	_, err = LoadConfig(strings.NewReader(`{"default": {"requests": 1000, "period": "1ms"}}`))
+	assert.NoError(t, err)
+}
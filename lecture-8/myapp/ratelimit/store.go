@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+// Store keeps the limiter state: one int64 per key.
+// Implementations for shared backends must make CompareAndSwap atomic.
+type Store interface {
+	// Get returns the value stored under key; ok is false if it is absent or expired.
+	Get(ctx context.Context, key string) (value int64, ok bool, err error)
+	// CompareAndSwap stores new under key with the given ttl if the current
+	// value equals old. If exists is false the key must be absent.
+	CompareAndSwap(ctx context.Context, key string, old int64, exists bool, new int64, ttl time.Duration) (bool, error)
+}
+
+type memoryEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store for a single replica. Expired keys are evicted
+// lazily on access and by a sweep that runs at most once per sweepInterval.
+type MemoryStore struct {
+	mu            sync.Mutex
+	items         map[string]memoryEntry
+	clock         clock.Clock
+	sweepInterval time.Duration
+	lastSweep     time.Time
+}
+
+const defaultSweepInterval = time.Minute
+
+// NewMemoryStore uses clock.Real() if clk is nil.
+func NewMemoryStore(clk clock.Clock) *MemoryStore {
+	if clk == nil {
+		clk = clock.Real()
+	}
+	return &MemoryStore{
+		items:         make(map[string]memoryEntry),
+		clock:         clk,
+		sweepInterval: defaultSweepInterval,
+		lastSweep:     clk.Now(),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.lookup(key, s.clock.Now())
+	return e.value, ok, nil
+}
+
+func (s *MemoryStore) CompareAndSwap(ctx context.Context, key string, old int64, exists bool, new int64, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.maybeSweep(now)
+
+	e, ok := s.lookup(key, now)
+	if ok != exists || (ok && e.value != old) {
+		return false, nil
+	}
+	s.items[key] = memoryEntry{value: new, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Len returns the number of stored keys, including not yet evicted expired ones.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *MemoryStore) lookup(key string, now time.Time) (memoryEntry, bool) {
+	e, ok := s.items[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !now.Before(e.expiresAt) {
+		delete(s.items, key)
+		return memoryEntry{}, false
+	}
+	return e, true
+}
+
+func (s *MemoryStore) maybeSweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.items {
+		if !now.Before(e.expiresAt) {
+			delete(s.items, key)
+		}
+	}
+}
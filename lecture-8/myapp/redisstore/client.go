@@ -0,0 +1,218 @@
+// Package redisstore implements the cache and rate-limit stores on top of
+// Redis, speaking RESP over plain TCP with a small connection pool.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/redisstore/internal/resp"
+)
+
+// Error is an error reply from the server, e.g. "WRONGTYPE ...".
+type Error = resp.Error
+
+var ErrClosed = errors.New("redisstore: client closed")
+
+type Options struct {
+	// Addr is host:port. Default "localhost:6379".
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize is the maximum number of idle connections kept. Default 10.
+	PoolSize int
+	// DialTimeout bounds connecting and authenticating. Default 5s.
+	DialTimeout time.Duration
+}
+
+// Client is safe for concurrent use. Connections are dialed on demand.
+type Client struct {
+	opts Options
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func New(opts Options) *Client {
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	return &Client{opts: opts}
+}
+
+// Do sends one command and returns the reply. Arguments may be string,
+// []byte, int, int64 or time.Duration (sent as milliseconds).
+// Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	var reply any
+	err := c.withConn(ctx, func(cn *conn) error {
+		var err error
+		reply, err = cn.do(ctx, args...)
+		return err
+	})
+	return reply, err
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes idle connections; connections in use are closed when released.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle, c.closed = nil, true
+	c.mu.Unlock()
+
+	var errs []error
+	for _, cn := range idle {
+		errs = append(errs, cn.nc.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// withConn runs fn on one connection, which is how WATCH/MULTI/EXEC
+// sequences stay on the same session. A connection that saw a network or
+// protocol error is discarded rather than returned to the pool.
+func (c *Client) withConn(ctx context.Context, fn func(*conn) error) error {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(cn)
+	var replyErr Error
+	if cn.broken || err != nil && !errors.As(err, &replyErr) {
+		cn.nc.Close()
+		return err
+	}
+	c.put(cn)
+	return err
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.opts.PoolSize {
+		cn.nc.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.DialTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redisstore: dial %s: %w", c.opts.Addr, err)
+	}
+	cn := &conn{nc: nc, r: resp.NewReader(nc), w: resp.NewWriter(nc)}
+
+	if c.opts.Password != "" {
+		if _, err := cn.do(ctx, "AUTH", c.opts.Password); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("redisstore: auth: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do(ctx, "SELECT", c.opts.DB); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("redisstore: select db %d: %w", c.opts.DB, err)
+		}
+	}
+	return cn, nil
+}
+
+type conn struct {
+	nc net.Conn
+	r  *resp.Reader
+	w  *resp.Writer
+	// broken marks a connection left in an unknown session state.
+	broken bool
+}
+
+func (cn *conn) do(ctx context.Context, args ...any) (any, error) {
+	deadline, _ := ctx.Deadline()
+	cn.nc.SetDeadline(deadline)
+	// Unblock I/O when ctx is canceled without a deadline.
+	stop := context.AfterFunc(ctx, func() { cn.nc.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	raw := make([][]byte, len(args))
+	for i, a := range args {
+		b, err := encodeArg(a)
+		if err != nil {
+			return nil, err
+		}
+		raw[i] = b
+	}
+	cn.w.WriteCommand(raw...)
+	if err := cn.w.Flush(); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+
+	reply, err := cn.r.ReadValue()
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func encodeArg(a any) ([]byte, error) {
+	switch a := a.(type) {
+	case string:
+		return []byte(a), nil
+	case []byte:
+		return a, nil
+	case int:
+		return strconv.AppendInt(nil, int64(a), 10), nil
+	case int64:
+		return strconv.AppendInt(nil, a, 10), nil
+	case time.Duration:
+		return strconv.AppendInt(nil, max(a.Milliseconds(), 1), 10), nil
+	}
+	return nil, fmt.Errorf("redisstore: unsupported argument type %T", a)
+}
@@ -0,0 +1,216 @@
+// Package resp reads and writes RESP2, the Redis wire protocol.
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Error is a RESP error reply such as "ERR unknown command".
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+const maxBulkLen = 512 << 20
+
+var ErrProtocol = errors.New("resp: protocol error")
+
+// Reader decodes replies into Go values:
+//
+//	simple string -> string
+//	error         -> Error
+//	integer       -> int64
+//	bulk string   -> []byte, or nil for a null bulk string
+//	array         -> []any, or nil for a null array
+type Reader struct {
+	br *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{br: bufio.NewReader(r)}
+}
+
+func (r *Reader) ReadValue() (any, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, ErrProtocol
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad integer %q", ErrProtocol, line)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n < -1 || n > maxBulkLen {
+			return nil, fmt.Errorf("%w: bad bulk length %q", ErrProtocol, line)
+		}
+		if n == -1 {
+			return []byte(nil), nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r.br, buf); err != nil {
+			return nil, err
+		}
+		if buf[n] != '\r' || buf[n+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated", ErrProtocol)
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n < -1 || n > 1<<20 {
+			return nil, fmt.Errorf("%w: bad array length %q", ErrProtocol, line)
+		}
+		if n == -1 {
+			return []any(nil), nil
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if arr[i], err = r.ReadValue(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected type byte %q", ErrProtocol, line[0])
+}
+
+// ReadCommand reads a client request: an array of bulk strings.
+// Inline commands are not supported.
+func (r *Reader) ReadCommand() ([][]byte, error) {
+	v, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := v.([]any)
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("%w: command must be a non-empty array", ErrProtocol)
+	}
+	args := make([][]byte, len(arr))
+	for i, a := range arr {
+		b, ok := a.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: command arguments must be bulk strings", ErrProtocol)
+		}
+		args[i] = b
+	}
+	return args, nil
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.br.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("%w: line too long", ErrProtocol)
+		}
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("%w: line not terminated by CRLF", ErrProtocol)
+	}
+	return line[:len(line)-2], nil
+}
+
+// Writer encodes commands and replies. Call Flush to send them.
+type Writer struct {
+	bw *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{bw: bufio.NewWriter(w)}
+}
+
+func (w *Writer) WriteCommand(args ...[]byte) {
+	w.WriteArrayHeader(len(args))
+	for _, a := range args {
+		w.WriteBulk(a)
+	}
+}
+
+func (w *Writer) WriteSimple(s string) {
+	w.bw.WriteByte('+')
+	w.bw.WriteString(s)
+	w.bw.WriteString("\r\n")
+}
+
+func (w *Writer) WriteError(msg string) {
+	w.bw.WriteByte('-')
+	w.bw.WriteString(msg)
+	w.bw.WriteString("\r\n")
+}
+
+func (w *Writer) WriteInt(n int64) {
+	w.bw.WriteByte(':')
+	w.bw.WriteString(strconv.FormatInt(n, 10))
+	w.bw.WriteString("\r\n")
+}
+
+func (w *Writer) WriteBulk(b []byte) {
+	w.bw.WriteByte('$')
+	w.bw.WriteString(strconv.Itoa(len(b)))
+	w.bw.WriteString("\r\n")
+	w.bw.Write(b)
+	w.bw.WriteString("\r\n")
+}
+
+func (w *Writer) WriteNull() {
+	w.bw.WriteString("$-1\r\n")
+}
+
+func (w *Writer) WriteNullArray() {
+	w.bw.WriteString("*-1\r\n")
+}
+
+func (w *Writer) WriteArrayHeader(n int) {
+	w.bw.WriteByte('*')
+	w.bw.WriteString(strconv.Itoa(n))
+	w.bw.WriteString("\r\n")
+}
+
+// WriteValue writes v using the mapping documented on Reader.
+func (w *Writer) WriteValue(v any) {
+	switch v := v.(type) {
+	case nil:
+		w.WriteNull()
+	case string:
+		w.WriteSimple(v)
+	case Error:
+		w.WriteError(string(v))
+	case int64:
+		w.WriteInt(v)
+	case []byte:
+		if v == nil {
+			w.WriteNull()
+			return
+		}
+		w.WriteBulk(v)
+	case []any:
+		if v == nil {
+			w.WriteNullArray()
+			return
+		}
+		w.WriteArrayHeader(len(v))
+		for _, e := range v {
+			w.WriteValue(e)
+		}
+	default:
+		panic(fmt.Sprintf("resp: cannot encode %T", v))
+	}
+}
+
+func (w *Writer) Flush() error {
+	return w.bw.Flush()
+}
@@ -0,0 +1,425 @@
+// Package redistest runs a tiny in-process Redis stand-in so that tests of
+// Redis-backed code need no external server. It supports the commands
+// redisstore uses: PING, ECHO, AUTH, SELECT, GET, SET (EX, PX, NX, XX),
+// DEL, EXISTS, PTTL, FLUSHALL, WATCH, UNWATCH, MULTI, EXEC, DISCARD and QUIT.
+// All databases share one keyspace.
+package redistest
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/redisstore/internal/resp"
+)
+
+type item struct {
+	value     []byte
+	expiresAt time.Time // zero means no expiry
+}
+
+// Server is safe for concurrent use by many clients.
+type Server struct {
+	ln       net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string]item
+	versions map[string]uint64 // bumped on every write, for WATCH
+	offset   time.Duration     // added to time.Now by FastForward
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on a random local port and stops it when the test ends.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s, err := Start("127.0.0.1:0", "")
+	if err != nil {
+		tb.Fatalf("redistest: %v", err)
+	}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Start listens on addr. A non-empty password makes AUTH mandatory.
+func Start(addr, password string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		ln:       ln,
+		password: password,
+		data:     make(map[string]item),
+		versions: make(map[string]uint64),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *Server) Addr() string { return s.ln.Addr().String() }
+
+// Close stops accepting, drops all clients and waits for their goroutines.
+func (s *Server) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// FastForward moves the server's clock forward, expiring keys early.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	s.offset += d
+	s.mu.Unlock()
+}
+
+// Keys returns the names of all live keys.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k := range s.data {
+		if _, ok := s.lookup(k); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(c)
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+			c.Close()
+		}()
+	}
+}
+
+// session is the per-connection state.
+type session struct {
+	authed  bool
+	watched map[string]uint64
+	queue   [][][]byte // nil outside MULTI
+	inMulti bool
+	dirty   bool // a command failed to queue; EXEC must abort
+}
+
+func (s *Server) handle(c net.Conn) {
+	r, w := resp.NewReader(c), resp.NewWriter(c)
+	sess := &session{authed: s.password == ""}
+
+	for {
+		args, err := r.ReadCommand()
+		if err != nil {
+			if errors.Is(err, resp.ErrProtocol) {
+				w.WriteError("ERR Protocol error")
+				w.Flush()
+			}
+			return
+		}
+		name := strings.ToUpper(string(args[0]))
+
+		quit := name == "QUIT"
+		w.WriteValue(s.dispatch(sess, name, args[1:]))
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(sess *session, name string, args [][]byte) any {
+	switch {
+	case name == "AUTH":
+		if len(args) != 1 {
+			return errArgs(name)
+		}
+		if s.password == "" {
+			return resp.Error("ERR AUTH <password> called without any password configured")
+		}
+		if string(args[0]) != s.password {
+			return resp.Error("WRONGPASS invalid username-password pair")
+		}
+		sess.authed = true
+		return "OK"
+	case name == "QUIT":
+		return "OK"
+	case !sess.authed:
+		return resp.Error("NOAUTH Authentication required.")
+	}
+
+	if sess.inMulti {
+		switch name {
+		case "EXEC", "DISCARD", "MULTI", "WATCH":
+		default:
+			if _, ok := commands[name]; !ok {
+				sess.dirty = true
+				return unknown(name)
+			}
+			sess.queue = append(sess.queue, append([][]byte{[]byte(name)}, args...))
+			return "QUEUED"
+		}
+	}
+
+	switch name {
+	case "MULTI":
+		if sess.inMulti {
+			return resp.Error("ERR MULTI calls can not be nested")
+		}
+		sess.inMulti, sess.queue, sess.dirty = true, nil, false
+		return "OK"
+	case "DISCARD":
+		if !sess.inMulti {
+			return resp.Error("ERR DISCARD without MULTI")
+		}
+		sess.reset()
+		return "OK"
+	case "EXEC":
+		if !sess.inMulti {
+			return resp.Error("ERR EXEC without MULTI")
+		}
+		return s.exec(sess)
+	case "WATCH":
+		if sess.inMulti {
+			return resp.Error("ERR WATCH inside MULTI is not allowed")
+		}
+		if len(args) == 0 {
+			return errArgs(name)
+		}
+		s.mu.Lock()
+		if sess.watched == nil {
+			sess.watched = make(map[string]uint64)
+		}
+		for _, k := range args {
+			s.lookup(string(k)) // expire first so expiry counts as a change
+			sess.watched[string(k)] = s.versions[string(k)]
+		}
+		s.mu.Unlock()
+		return "OK"
+	case "UNWATCH":
+		sess.watched = nil
+		return "OK"
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return unknown(name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cmd(s, args)
+}
+
+func (sess *session) reset() {
+	sess.inMulti, sess.queue, sess.dirty, sess.watched = false, nil, false, nil
+}
+
+func (s *Server) exec(sess *session) any {
+	defer sess.reset()
+	if sess.dirty {
+		return resp.Error("EXECABORT Transaction discarded because of previous errors.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range sess.watched {
+		s.lookup(k)
+		if s.versions[k] != v {
+			return []any(nil)
+		}
+	}
+	replies := make([]any, len(sess.queue))
+	for i, q := range sess.queue {
+		replies[i] = commands[string(q[0])](s, q[1:])
+	}
+	return replies
+}
+
+// commands run with s.mu held.
+var commands = map[string]func(s *Server, args [][]byte) any{
+	"PING": func(s *Server, args [][]byte) any {
+		if len(args) == 1 {
+			return args[0]
+		}
+		return "PONG"
+	},
+	"ECHO": func(s *Server, args [][]byte) any {
+		if len(args) != 1 {
+			return errArgs("ECHO")
+		}
+		return args[0]
+	},
+	"SELECT": func(s *Server, args [][]byte) any {
+		if len(args) != 1 {
+			return errArgs("SELECT")
+		}
+		if _, err := strconv.Atoi(string(args[0])); err != nil {
+			return resp.Error("ERR value is not an integer or out of range")
+		}
+		return "OK"
+	},
+	"GET": func(s *Server, args [][]byte) any {
+		if len(args) != 1 {
+			return errArgs("GET")
+		}
+		it, ok := s.lookup(string(args[0]))
+		if !ok {
+			return []byte(nil)
+		}
+		return it.value
+	},
+	"SET":      cmdSet,
+	"DEL":      cmdDel,
+	"EXISTS":   cmdExists,
+	"PTTL":     cmdPTTL,
+	"FLUSHALL": cmdFlushAll,
+}
+
+func cmdSet(s *Server, args [][]byte) any {
+	if len(args) < 2 {
+		return errArgs("SET")
+	}
+	key := string(args[0])
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return resp.Error("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(string(args[i+1]), 10, 64)
+			if err != nil || n <= 0 {
+				return resp.Error("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Millisecond
+			if strings.EqualFold(string(args[i]), "EX") {
+				unit = time.Second
+			}
+			ttl = time.Duration(n) * unit
+			i++
+		default:
+			return resp.Error("ERR syntax error")
+		}
+	}
+	if nx && xx {
+		return resp.Error("ERR syntax error")
+	}
+
+	_, exists := s.lookup(key)
+	if nx && exists || xx && !exists {
+		return []byte(nil)
+	}
+
+	it := item{value: append([]byte(nil), args[1]...)}
+	if ttl > 0 {
+		it.expiresAt = s.now().Add(ttl)
+	}
+	s.data[key] = it
+	s.versions[key]++
+	return "OK"
+}
+
+func cmdDel(s *Server, args [][]byte) any {
+	if len(args) == 0 {
+		return errArgs("DEL")
+	}
+	var n int64
+	for _, k := range args {
+		if _, ok := s.lookup(string(k)); ok {
+			delete(s.data, string(k))
+			s.versions[string(k)]++
+			n++
+		}
+	}
+	return n
+}
+
+func cmdExists(s *Server, args [][]byte) any {
+	if len(args) == 0 {
+		return errArgs("EXISTS")
+	}
+	var n int64
+	for _, k := range args {
+		if _, ok := s.lookup(string(k)); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func cmdPTTL(s *Server, args [][]byte) any {
+	if len(args) != 1 {
+		return errArgs("PTTL")
+	}
+	it, ok := s.lookup(string(args[0]))
+	switch {
+	case !ok:
+		return int64(-2)
+	case it.expiresAt.IsZero():
+		return int64(-1)
+	}
+	return it.expiresAt.Sub(s.now()).Milliseconds()
+}
+
+func cmdFlushAll(s *Server, args [][]byte) any {
+	for k := range s.data {
+		s.versions[k]++
+	}
+	clear(s.data)
+	return "OK"
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// lookup returns the live item under key, deleting it if it has expired.
+func (s *Server) lookup(key string) (item, bool) {
+	it, ok := s.data[key]
+	if !ok {
+		return item{}, false
+	}
+	if !it.expiresAt.IsZero() && !s.now().Before(it.expiresAt) {
+		delete(s.data, key)
+		s.versions[key]++
+		return item{}, false
+	}
+	return it, true
+}
+
+func errArgs(name string) resp.Error {
+	return resp.Error("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+}
+
+func unknown(name string) resp.Error {
+	return resp.Error("ERR unknown command '" + name + "'")
+}
@@ -0,0 +1,150 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CacheStore implements cache.Store. Every key is prefixed with prefix,
+// so several applications can share a database.
+type CacheStore struct {
+	client *Client
+	prefix string
+}
+
+func NewCacheStore(client *Client, prefix string) *CacheStore {
+	return &CacheStore{client: client, prefix: prefix}
+}
+
+func (s *CacheStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := s.client.Do(ctx, "GET", s.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, false, unexpected("GET", reply)
+	}
+	return b, b != nil, nil
+}
+
+func (s *CacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []any{"SET", s.prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl)
+	}
+	_, err := s.client.Do(ctx, args...)
+	return err
+}
+
+func (s *CacheStore) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	args := []any{"SET", s.prefix + key, value, "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", ttl)
+	}
+	reply, err := s.client.Do(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+	switch reply := reply.(type) {
+	case string:
+		return true, nil
+	case []byte:
+		if reply == nil {
+			return false, nil
+		}
+	}
+	return false, unexpected("SET", reply)
+}
+
+func (s *CacheStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.Do(ctx, "DEL", s.prefix+key)
+	return err
+}
+
+// RateLimitStore implements ratelimit.Store. CompareAndSwap uses
+// WATCH/MULTI/EXEC, so it is atomic across replicas.
+type RateLimitStore struct {
+	client *Client
+	prefix string
+}
+
+func NewRateLimitStore(client *Client, prefix string) *RateLimitStore {
+	return &RateLimitStore{client: client, prefix: prefix}
+}
+
+func (s *RateLimitStore) Get(ctx context.Context, key string) (int64, bool, error) {
+	reply, err := s.client.Do(ctx, "GET", s.prefix+key)
+	if err != nil {
+		return 0, false, err
+	}
+	return parseInt("GET", reply)
+}
+
+func (s *RateLimitStore) CompareAndSwap(ctx context.Context, key string, old int64, exists bool, new int64, ttl time.Duration) (bool, error) {
+	key = s.prefix + key
+	swapped := false
+
+	err := s.client.withConn(ctx, func(cn *conn) error {
+		// Until EXEC or UNWATCH succeeds the session holds a WATCH
+		// (or an open MULTI), so the connection must not be reused.
+		cn.broken = true
+
+		if _, err := cn.do(ctx, "WATCH", key); err != nil {
+			return err
+		}
+		reply, err := cn.do(ctx, "GET", key)
+		if err != nil {
+			return err
+		}
+		cur, ok, err := parseInt("GET", reply)
+		if err != nil || ok != exists || (ok && cur != old) {
+			if _, uerr := cn.do(ctx, "UNWATCH"); uerr != nil {
+				return uerr
+			}
+			cn.broken = false
+			return err
+		}
+
+		if _, err := cn.do(ctx, "MULTI"); err != nil {
+			return err
+		}
+		if _, err := cn.do(ctx, "SET", key, strconv.FormatInt(new, 10), "PX", ttl); err != nil {
+			return err
+		}
+		reply, err = cn.do(ctx, "EXEC")
+		if err != nil {
+			return err
+		}
+		cn.broken = false
+		// A null reply means the watched key changed: someone else won.
+		arr, _ := reply.([]any)
+		swapped = arr != nil
+		return nil
+	})
+	return swapped, err
+}
+
+func parseInt(cmd string, reply any) (int64, bool, error) {
+	b, ok := reply.([]byte)
+	if !ok {
+		return 0, false, unexpected(cmd, reply)
+	}
+	if b == nil {
+		return 0, false, nil
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("redisstore: %s: value %q is not an integer", cmd, b)
+	}
+	return n, true, nil
+}
+
+var errUnexpectedReply = errors.New("redisstore: unexpected reply")
+
+func unexpected(cmd string, reply any) error {
+	return fmt.Errorf("%w to %s: %T", errUnexpectedReply, cmd, reply)
+}
@@ -0,0 +1,45 @@
+//go:build integration
+
+package redisstore
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRealRedis runs the stores against a real server:
+//
+//	TEST_REDIS_ADDR=localhost:6379 go test -tags integration ./redisstore/
+func TestRealRedis(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	c := New(Options{Addr: addr, Password: os.Getenv("TEST_REDIS_PASSWORD")})
+	defer c.Close()
+	ctx := context.Background()
+	prefix := "myapp-test:" + time.Now().Format("150405.000000") + ":"
+
+	cs := NewCacheStore(c, prefix)
+	require.NoError(t, cs.Set(ctx, "k", []byte("v"), time.Minute))
+	got, ok, err := cs.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("v"), got)
+	require.NoError(t, cs.Delete(ctx, "k"))
+
+	rl := NewRateLimitStore(c, prefix)
+	swapped, err := rl.CompareAndSwap(ctx, "rl", 0, false, 5, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+	swapped, err = rl.CompareAndSwap(ctx, "rl", 4, true, 6, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, swapped)
+	_, err = c.Do(ctx, "DEL", prefix+"rl")
+	require.NoError(t, err)
+}
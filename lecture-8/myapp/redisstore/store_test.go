@@ -0,0 +1,255 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/ratelimit"
+	"ITMO-students/lecture-8/myapp/redisstore/redistest"
+)
+
+func newTestClient(t *testing.T) (*Client, *redistest.Server) {
+	t.Helper()
+	srv := redistest.NewServer(t)
+	c := New(Options{Addr: srv.Addr()})
+	t.Cleanup(func() { c.Close() })
+	return c, srv
+}
+
+func TestClient_Do(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.Ping(ctx))
+
+	reply, err := c.Do(ctx, "ECHO", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), reply)
+
+	_, err = c.Do(ctx, "NOPE")
+	var replyErr Error
+	require.ErrorAs(t, err, &replyErr)
+	assert.Contains(t, replyErr.Error(), "unknown command")
+
+	// The connection survives an error reply.
+	require.NoError(t, c.Ping(ctx))
+	assert.Len(t, c.idle, 1)
+}
+
+func TestClient_Auth(t *testing.T) {
+	srv, err := redistest.Start("127.0.0.1:0", "secret")
+	require.NoError(t, err)
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := New(Options{Addr: srv.Addr()})
+	defer c.Close()
+	err = c.Ping(ctx)
+	assert.ErrorContains(t, err, "NOAUTH")
+
+	c = New(Options{Addr: srv.Addr(), Password: "wrong"})
+	defer c.Close()
+	assert.ErrorContains(t, c.Ping(ctx), "WRONGPASS")
+
+	c = New(Options{Addr: srv.Addr(), Password: "secret", DB: 2})
+	defer c.Close()
+	assert.NoError(t, c.Ping(ctx))
+}
+
+func TestClient_Closed(t *testing.T) {
+	c, _ := newTestClient(t)
+	require.NoError(t, c.Close())
+	assert.ErrorIs(t, c.Ping(context.Background()), ErrClosed)
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, c.Ping(ctx), context.Canceled)
+}
+
+func TestCacheStore(t *testing.T) {
+	c, srv := newTestClient(t)
+	store := NewCacheStore(c, "myapp:")
+	ctx := context.Background()
+
+	_, ok, err := store.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Set(ctx, "k", []byte("v"), time.Minute))
+	require.NoError(t, store.Set(ctx, "forever", []byte("v"), 0))
+	assert.ElementsMatch(t, []string{"myapp:k", "myapp:forever"}, srv.Keys())
+
+	got, ok, err := store.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("v"), got)
+
+	srv.FastForward(time.Minute)
+	_, ok, err = store.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok, "expired")
+	_, ok, _ = store.Get(ctx, "forever")
+	assert.True(t, ok)
+
+	require.NoError(t, store.Delete(ctx, "forever"))
+	_, ok, _ = store.Get(ctx, "forever")
+	assert.False(t, ok)
+
+	added, err := store.Add(ctx, "k", []byte("first"), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.Add(ctx, "k", []byte("second"), time.Minute)
+	require.NoError(t, err)
+	assert.False(t, added, "Add does not replace a present key")
+	got, _, _ = store.Get(ctx, "k")
+	assert.Equal(t, []byte("first"), got)
+}
+
+func TestCacheStore_Shared(t *testing.T) {
+	for name, codec := range map[string]cache.Codec{"json": cache.JSON, "gob": cache.Gob} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := newTestClient(t)
+			users := cache.NewShared[model.User](NewCacheStore(c, "myapp:"), codec, "user:", time.Minute)
+			ctx := context.Background()
+
+			alice := model.User{ID: 1, Name: "Alice", Email: "alice@example.com", Version: 3}
+			require.NoError(t, users.Set(ctx, "1", alice))
+
+			got, ok, err := users.Get(ctx, "1")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, alice, got)
+
+			require.NoError(t, users.Delete(ctx, "1"))
+			_, ok, err = users.Get(ctx, "1")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestRateLimitStore_CompareAndSwap(t *testing.T) {
+	c, _ := newTestClient(t)
+	store := NewRateLimitStore(c, "rl:")
+	ctx := context.Background()
+
+	ok, err := store.CompareAndSwap(ctx, "k", 0, true, 1, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "key must exist")
+
+	ok, err = store.CompareAndSwap(ctx, "k", 0, false, 10, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.CompareAndSwap(ctx, "k", 0, false, 11, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "key must be absent")
+
+	ok, err = store.CompareAndSwap(ctx, "k", 9, true, 11, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "old value differs")
+
+	ok, err = store.CompareAndSwap(ctx, "k", 10, true, 11, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	v, ok, err := store.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(11), v)
+}
+
+func TestRateLimitStore_ConcurrentIncrements(t *testing.T) {
+	c, _ := newTestClient(t)
+	store := NewRateLimitStore(c, "rl:")
+	ctx := context.Background()
+
+	const workers, perWorker = 8, 20
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				for {
+					cur, ok, err := store.Get(ctx, "counter")
+					if !assert.NoError(t, err) {
+						return
+					}
+					swapped, err := store.CompareAndSwap(ctx, "counter", cur, ok, cur+1, time.Minute)
+					if !assert.NoError(t, err) {
+						return
+					}
+					if swapped {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	v, _, err := store.Get(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*perWorker), v)
+}
+
+func TestRateLimitStore_NotAnInteger(t *testing.T) {
+	c, _ := newTestClient(t)
+	ctx := context.Background()
+	_, err := c.Do(ctx, "SET", "rl:k", "abc")
+	require.NoError(t, err)
+
+	store := NewRateLimitStore(c, "rl:")
+	_, _, err = store.Get(ctx, "k")
+	assert.Error(t, err)
+
+	_, err = store.CompareAndSwap(ctx, "k", 0, true, 1, time.Minute)
+	assert.Error(t, err)
+	assert.Empty(t, c.idle, "a connection with a pending WATCH is not reused")
+}
+
+func TestRateLimitStore_Limiter(t *testing.T) {
+	c, _ := newTestClient(t)
+	limiter := ratelimit.New(ratelimit.Config{
+		Default: ratelimit.Quota{Requests: 2, Period: ratelimit.Duration(time.Minute)},
+	}, NewRateLimitStore(c, "rl:"), nil, nil)
+	ctx := context.Background()
+
+	for range 2 {
+		res, err := limiter.Allow(ctx, "GET /users", "ip:1.1.1.1")
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+	res, err := limiter.Allow(ctx, "GET /users", "ip:1.1.1.1")
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	res, err = limiter.Allow(ctx, "GET /users", "ip:2.2.2.2")
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "keys are per client")
+}
+
+func TestServer_Close(t *testing.T) {
+	srv, err := redistest.Start("127.0.0.1:0", "")
+	require.NoError(t, err)
+	c := New(Options{Addr: srv.Addr()})
+	defer c.Close()
+	require.NoError(t, c.Ping(context.Background()))
+
+	srv.Close()
+	err = c.Ping(context.Background())
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrClosed))
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/model"
+)
+
+// CachedUserRepository serves FindByID from an in-process cache and
+// delegates everything else to the wrapped repository. Not-found results
+// are cached briefly; writes through this decorator refresh the cache.
+// Writes that bypass it (another replica, a migration) become visible
+// after the TTL at the latest.
+type CachedUserRepository struct {
+	UserRepository
+	cache *cache.Cache[int64, model.User]
+}
+
+const (
+	DefaultCacheSize        = 10_000
+	DefaultCacheTTL         = time.Minute
+	DefaultCacheNegativeTTL = 5 * time.Second
+)
+
+// NewCached wraps repo. opts.IsNegative is always set to match ErrNotFound;
+// zero size and TTLs take the Default* values above.
+func NewCached(repo UserRepository, opts cache.Options) *CachedUserRepository {
+	if opts.Size <= 0 {
+		opts.Size = DefaultCacheSize
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = DefaultCacheNegativeTTL
+	}
+	opts.IsNegative = func(err error) bool { return errors.Is(err, ErrNotFound) }
+
+	return &CachedUserRepository{
+		UserRepository: repo,
+		cache:          cache.New[int64, model.User](opts),
+	}
+}
+
+func (r *CachedUserRepository) FindByID(ctx context.Context, id int64) (model.User, error) {
+	// A hit must not hide a canceled caller.
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+	return r.cache.GetOrLoad(ctx, id, func(ctx context.Context) (model.User, error) {
+		return r.UserRepository.FindByID(ctx, id)
+	})
+}
+
+func (r *CachedUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := r.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+	// Replaces a cached not-found for the new ID.
+	r.cache.Set(user.ID, *user)
+	return nil
+}
+
+func (r *CachedUserRepository) Update(ctx context.Context, user *model.User) error {
+	err := r.UserRepository.Update(ctx, user)
+	switch {
+	case err == nil:
+		r.cache.Set(user.ID, *user)
+	case errors.Is(err, ErrVersionMismatch), errors.Is(err, ErrNotFound):
+		// The cached copy is what the caller based the update on; it is stale.
+		r.cache.Delete(user.ID)
+	}
+	return err
+}
+
+// Invalidate drops id from the cache, e.g. after the user was deleted
+// or changed by a writer that does not go through this repository.
+func (r *CachedUserRepository) Invalidate(id int64) {
+	r.cache.Delete(id)
+}
+
+func (r *CachedUserRepository) Stats() cache.Stats {
+	return r.cache.Stats()
+}
+
+// SharedCachedUserRepository caches FindByID in a store shared by all
+// replicas, so a write on one replica is seen by the others at once.
+// Store errors are not fatal: reads fall through to the wrapped repository.
+type SharedCachedUserRepository struct {
+	UserRepository
+	shared *cache.Shared[model.User]
+}
+
+func NewSharedCached(repo UserRepository, shared *cache.Shared[model.User]) *SharedCachedUserRepository {
+	return &SharedCachedUserRepository{UserRepository: repo, shared: shared}
+}
+
+func (r *SharedCachedUserRepository) FindByID(ctx context.Context, id int64) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+	key := strconv.FormatInt(id, 10)
+	if user, ok, err := r.shared.Get(ctx, key); err == nil && ok {
+		return user, nil
+	}
+
+	user, err := r.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	// Only fill a missing key: an Update may have stored a newer version
+	// since this read, and Set would put the older one back.
+	r.shared.Add(ctx, key, user)
+	return user, nil
+}
+
+func (r *SharedCachedUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := r.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+	r.shared.Set(ctx, strconv.FormatInt(user.ID, 10), *user)
+	return nil
+}
+
+func (r *SharedCachedUserRepository) Update(ctx context.Context, user *model.User) error {
+	key := strconv.FormatInt(user.ID, 10)
+	err := r.UserRepository.Update(ctx, user)
+	switch {
+	case err == nil:
+		r.shared.Set(ctx, key, *user)
+	case errors.Is(err, ErrVersionMismatch), errors.Is(err, ErrNotFound):
+		r.shared.Delete(ctx, key)
+	}
+	return err
+}
+
+func (r *SharedCachedUserRepository) Invalidate(ctx context.Context, id int64) error {
+	return r.shared.Delete(ctx, strconv.FormatInt(id, 10))
+}
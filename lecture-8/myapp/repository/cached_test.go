@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/redisstore"
+	"ITMO-students/lecture-8/myapp/redisstore/redistest"
+)
+
+// countingRepository counts FindByID calls that reach the backend.
+type countingRepository struct {
+	UserRepository
+	finds atomic.Int32
+}
+
+func (r *countingRepository) FindByID(ctx context.Context, id int64) (model.User, error) {
+	r.finds.Add(1)
+	return r.UserRepository.FindByID(ctx, id)
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingRepository{UserRepository: New()}
+	repo := NewCached(backend, cache.Options{})
+
+	_, err := repo.FindByID(ctx, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.FindByID(ctx, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, int32(1), backend.finds.Load(), "not found is cached")
+
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, &alice))
+
+	got, err := repo.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice, got, "create replaces the negative entry")
+	assert.Equal(t, int32(1), backend.finds.Load())
+
+	alice.Name = "Alicia"
+	require.NoError(t, repo.Update(ctx, &alice))
+	got, err = repo.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+	assert.Equal(t, int64(2), got.Version)
+
+	stale := got
+	stale.Version = 1
+	assert.ErrorIs(t, repo.Update(ctx, &stale), ErrVersionMismatch)
+	_, err = repo.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), backend.finds.Load(), "a version conflict drops the cached copy")
+
+	repo.Invalidate(alice.ID)
+	_, err = repo.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), backend.finds.Load())
+
+	stats := repo.Stats()
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.Equal(t, 0.5, stats.HitRatio())
+}
+
+func TestSharedCachedUserRepository(t *testing.T) {
+	ctx := context.Background()
+	srv := redistest.NewServer(t)
+	client := redisstore.New(redisstore.Options{Addr: srv.Addr()})
+	defer client.Close()
+	shared := cache.NewShared[model.User](redisstore.NewCacheStore(client, "myapp:"), cache.JSON, "user:", time.Minute)
+
+	backend := &countingRepository{UserRepository: New()}
+	replicaA := NewSharedCached(backend, shared)
+	replicaB := NewSharedCached(backend, shared)
+
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, replicaA.Create(ctx, &alice))
+
+	got, err := replicaB.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice, got)
+	assert.Equal(t, int32(0), backend.finds.Load())
+
+	alice.Name = "Alicia"
+	require.NoError(t, replicaB.Update(ctx, &alice))
+	got, err = replicaA.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name, "update on one replica is visible on the other")
+
+	require.NoError(t, replicaA.Invalidate(ctx, alice.ID))
+	_, err = replicaA.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), backend.finds.Load())
+
+	srv.Close()
+	got, err = replicaA.FindByID(ctx, alice.ID)
+	require.NoError(t, err, "store outage falls through to the repository")
+	assert.Equal(t, "Alicia", got.Name)
+}
+
+// raceRepository runs hook once, after a FindByID has read the user.
+type raceRepository struct {
+	UserRepository
+	hook func()
+}
+
+func (r *raceRepository) FindByID(ctx context.Context, id int64) (model.User, error) {
+	u, err := r.UserRepository.FindByID(ctx, id)
+	if h := r.hook; h != nil {
+		r.hook = nil
+		h()
+	}
+	return u, err
+}
+
+func TestSharedCachedUserRepository_ReadDoesNotOverwriteWrite(t *testing.T) {
+	ctx := context.Background()
+	srv := redistest.NewServer(t)
+	client := redisstore.New(redisstore.Options{Addr: srv.Addr()})
+	defer client.Close()
+	shared := cache.NewShared[model.User](redisstore.NewCacheStore(client, "myapp:"), cache.JSON, "user:", time.Minute)
+
+	backend := New()
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, backend.Create(ctx, &alice))
+
+	writer := NewSharedCached(backend, shared)
+	reader := NewSharedCached(&raceRepository{UserRepository: backend, hook: func() {
+		updated := alice
+		updated.Name = "Alicia"
+		require.NoError(t, writer.Update(ctx, &updated))
+	}}, shared)
+
+	stale, err := reader.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", stale.Name)
+
+	got, err := writer.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name, "the reader's fill must not replace the writer's newer copy")
+}
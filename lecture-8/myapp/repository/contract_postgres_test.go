@@ -0,0 +1,28 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"testing"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/repository/repotest"
+)
+
+func newPostgres(t *testing.T) *repository.PostgresUserRepository {
+	return repository.NewPostgres(pgtest.Open(t, pgtest.Options{Migrations: "../migrations/*.sql"}))
+}
+
+func TestPostgresUserRepository_Contract(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+		return newPostgres(t)
+	})
+}
+
+func TestCachedPostgresUserRepository_Contract(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+		return repository.NewCached(newPostgres(t), cache.Options{})
+	})
+}
@@ -0,0 +1,34 @@
+package repository_test
+
+import (
+	"testing"
+
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/redisstore"
+	"ITMO-students/lecture-8/myapp/redisstore/redistest"
+	"ITMO-students/lecture-8/myapp/repository"
+	"ITMO-students/lecture-8/myapp/repository/repotest"
+)
+
+func TestMemoryUserRepository_Contract(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+		return repository.New()
+	})
+}
+
+func TestCachedUserRepository_Contract(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+		return repository.NewCached(repository.New(), cache.Options{})
+	})
+}
+
+func TestSharedCachedUserRepository_Contract(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+		srv := redistest.NewServer(t)
+		rdb := redisstore.New(redisstore.Options{Addr: srv.Addr()})
+		t.Cleanup(func() { rdb.Close() })
+		shared := cache.NewShared[model.User](redisstore.NewCacheStore(rdb, "test:"), cache.JSON, "user:", repository.DefaultCacheTTL)
+		return repository.NewSharedCached(repository.New(), shared)
+	})
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is a stored response for an Idempotency-Key.
+// Until Completed is set the original request is still in flight.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+type IdempotencyRepository interface {
+	// Reserve creates an in-flight record for key. If a live record already
+	// exists it is returned with created == false.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (rec IdempotencyRecord, created bool, err error)
+	// Complete stores the response for a reserved key.
+	Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error
+	// Release forgets a reserved key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyRepository evicts expired records on access and
+// sweeps the whole map at most once per minute.
+type MemoryIdempotencyRepository struct {
+	mu        sync.Mutex
+	records   map[string]IdempotencyRecord
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotency() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]IdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+func (r *MemoryIdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastSweep) >= time.Minute {
+		r.evictExpired(now)
+		r.lastSweep = now
+	}
+
+	if rec, ok := r.records[key]; ok && now.Before(rec.ExpiresAt) {
+		return rec, false, nil
+	}
+
+	rec := IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(ttl),
+	}
+	r.records[key] = rec
+	return rec, true, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[key]
+	if !ok {
+		return ErrNotFound
+	}
+	rec.Completed = true
+	rec.Status = status
+	rec.Header = header.Clone()
+	rec.Body = append([]byte(nil), body...)
+	r.records[key] = rec
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
+
+// DeleteExpired drops every expired record and returns how many there
+// were, for callers that sweep on a schedule rather than on access.
+func (r *MemoryIdempotencyRepository) DeleteExpired(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	n := r.evictExpired(now)
+	r.lastSweep = now
+	return n, nil
+}
+
+func (r *MemoryIdempotencyRepository) evictExpired(now time.Time) int {
+	n := 0
+	for key, rec := range r.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(r.records, key)
+			n++
+		}
+	}
+	return n
+}
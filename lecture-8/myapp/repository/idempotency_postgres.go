@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const idempotencyColumns = `key, fingerprint, completed, status, header, body, expires_at`
+
+// PostgresIdempotencyRepository keeps idempotency keys in the
+// idempotency_keys table, so a retry that reaches another replica still
+// gets the stored response. Expiry uses the database clock.
+type PostgresIdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotency(db *sql.DB) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+func (r *PostgresIdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (IdempotencyRecord, bool, error) {
+	// An expired record is taken over in place; a live one is returned.
+	// It may expire or be released between the two statements, then the
+	// insert is simply tried again.
+	for range 3 {
+		rec, err := scanIdempotency(r.db.QueryRowContext(ctx,
+			`INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+			 VALUES ($1, $2, now() + make_interval(secs => $3))
+			 ON CONFLICT (key) DO UPDATE
+			 SET fingerprint = EXCLUDED.fingerprint, completed = false, status = 0,
+			     header = NULL, body = NULL, expires_at = EXCLUDED.expires_at
+			 WHERE idempotency_keys.expires_at <= now()
+			 RETURNING `+idempotencyColumns,
+			key, fingerprint, ttl.Seconds()))
+		if err == nil {
+			return rec, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return IdempotencyRecord{}, false, err
+		}
+
+		rec, err = scanIdempotency(r.db.QueryRowContext(ctx,
+			`SELECT `+idempotencyColumns+` FROM idempotency_keys
+			 WHERE key = $1 AND expires_at > now()`, key))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		return rec, false, err
+	}
+	return IdempotencyRecord{}, false, errors.New("idempotency: key keeps changing hands")
+}
+
+func (r *PostgresIdempotencyRepository) Complete(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	h, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET completed = true, status = $2, header = $3, body = $4
+		 WHERE key = $1`,
+		key, status, h, body)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key)
+	return err
+}
+
+// DeleteExpired drops every expired record and returns how many there were.
+func (r *PostgresIdempotencyRepository) DeleteExpired(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func scanIdempotency(row *sql.Row) (IdempotencyRecord, error) {
+	var rec IdempotencyRecord
+	var header []byte
+	err := row.Scan(&rec.Key, &rec.Fingerprint, &rec.Completed, &rec.Status, &header, &rec.Body, &rec.ExpiresAt)
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+	if header != nil {
+		if err := json.Unmarshal(header, &rec.Header); err != nil {
+			return IdempotencyRecord{}, err
+		}
+	}
+	return rec, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotency_TTL(t *testing.T) {
+	repo := NewMemoryIdempotency()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, created, err := repo.Reserve(ctx, "k", "fp", time.Hour)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	require.NoError(t, repo.Complete(ctx, "k", http.StatusCreated, http.Header{"Location": {"/users/1"}}, []byte("{}")))
+
+	rec, created, err := repo.Reserve(ctx, "k", "other", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.True(t, rec.Completed)
+	assert.Equal(t, "fp", rec.Fingerprint)
+	assert.Equal(t, http.StatusCreated, rec.Status)
+
+	now = now.Add(time.Hour)
+	_, created, err = repo.Reserve(ctx, "k", "other", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, created)
+}
+
+func TestMemoryIdempotency_CompleteUnknown(t *testing.T) {
+	repo := NewMemoryIdempotency()
+	err := repo.Complete(context.Background(), "missing", http.StatusOK, nil, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryIdempotency_DeleteExpired(t *testing.T) {
+	repo := NewMemoryIdempotency()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for key, ttl := range map[string]time.Duration{"short": time.Minute, "long": time.Hour} {
+		_, _, err := repo.Reserve(ctx, key, "fp", ttl)
+		require.NoError(t, err)
+	}
+
+	now = now.Add(time.Minute)
+	n, err := repo.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, created, err := repo.Reserve(ctx, "long", "fp", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, created)
+}
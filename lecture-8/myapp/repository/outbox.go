@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/outbox"
+)
+
+func userEvent(typ string, user model.User) (outbox.Event, error) {
+	return outbox.NewEvent(typ, strconv.FormatInt(user.ID, 10), user)
+}
+
+// MemoryOutbox is an outbox.Store for MemoryUserRepository.
+type MemoryOutbox struct {
+	mu          sync.Mutex
+	events      []outbox.Event // ordered by ID
+	lastID      int64
+	lockedUntil map[int64]time.Time
+	now         func() time.Time
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{
+		lockedUntil: make(map[int64]time.Time),
+		now:         time.Now,
+	}
+}
+
+func (o *MemoryOutbox) add(e outbox.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.lastID++
+	e.ID = o.lastID
+	e.CreatedAt = o.now()
+	e.Status = outbox.StatusPending
+	e.NextAttemptAt = e.CreatedAt
+	o.events = append(o.events, e)
+}
+
+func (o *MemoryOutbox) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]outbox.Event, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var claimed []outbox.Event
+	for _, e := range o.events {
+		if len(claimed) == limit {
+			break
+		}
+		if e.Status != outbox.StatusPending || e.NextAttemptAt.After(now) || o.lockedUntil[e.ID].After(now) {
+			continue
+		}
+		e.LockedUntil = now.Add(lease)
+		o.lockedUntil[e.ID] = e.LockedUntil
+		claimed = append(claimed, e)
+	}
+	return claimed, nil
+}
+
+func (o *MemoryOutbox) MarkDelivered(ctx context.Context, id int64, lockedUntil time.Time) error {
+	return o.finish(id, lockedUntil, func(e *outbox.Event) {
+		e.Status = outbox.StatusDelivered
+	})
+}
+
+func (o *MemoryOutbox) MarkFailed(ctx context.Context, id int64, lockedUntil time.Time, lastErr string, next time.Time, dead bool) error {
+	return o.finish(id, lockedUntil, func(e *outbox.Event) {
+		e.Attempts++
+		e.LastError = lastErr
+		e.NextAttemptAt = next
+		if dead {
+			e.Status = outbox.StatusDead
+		}
+	})
+}
+
+// Requeue moves a dead event back to pending with a fresh attempt count.
+func (o *MemoryOutbox) Requeue(ctx context.Context, id int64) error {
+	return o.update(id, func(e *outbox.Event) {
+		e.Status = outbox.StatusPending
+		e.Attempts = 0
+		e.NextAttemptAt = o.now()
+	})
+}
+
+// Purge deletes delivered events created before before and returns how
+// many it deleted. Dead events stay for Requeue.
+func (o *MemoryOutbox) Purge(ctx context.Context, before time.Time) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n := len(o.events)
+	o.events = slices.DeleteFunc(o.events, func(e outbox.Event) bool {
+		return e.Status == outbox.StatusDelivered && e.CreatedAt.Before(before)
+	})
+	return n - len(o.events), nil
+}
+
+// Events returns a copy of all events, oldest first.
+func (o *MemoryOutbox) Events() []outbox.Event {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.events)
+}
+
+func (o *MemoryOutbox) update(id int64, fn func(*outbox.Event)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	i, ok := o.find(id)
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&o.events[i])
+	delete(o.lockedUntil, id)
+	return nil
+}
+
+// finish is update guarded by the claim identified by lockedUntil.
+func (o *MemoryOutbox) finish(id int64, lockedUntil time.Time, fn func(*outbox.Event)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	i, ok := o.find(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if o.events[i].Status != outbox.StatusPending || !o.lockedUntil[id].Equal(lockedUntil) {
+		return outbox.ErrLeaseLost
+	}
+	fn(&o.events[i])
+	delete(o.lockedUntil, id)
+	return nil
+}
+
+func (o *MemoryOutbox) find(id int64) (int, bool) {
+	return slices.BinarySearchFunc(o.events, id, func(e outbox.Event, id int64) int {
+		return cmp.Compare(e.ID, id)
+	})
+}
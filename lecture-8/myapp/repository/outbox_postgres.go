@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/outbox"
+)
+
+// PostgresOutbox is an outbox.Store over the outbox table. Claim uses
+// FOR UPDATE SKIP LOCKED, so any number of relays can poll it.
+type PostgresOutbox struct {
+	db *sql.DB
+}
+
+func NewPostgresOutbox(db *sql.DB) *PostgresOutbox {
+	return &PostgresOutbox{db: db}
+}
+
+func (o *PostgresOutbox) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]outbox.Event, error) {
+	rows, err := o.db.QueryContext(ctx,
+		`UPDATE outbox SET locked_until = $2
+		 WHERE id IN (
+		     SELECT id FROM outbox
+		     WHERE status = 'pending' AND next_attempt_at <= $1
+		       AND (locked_until IS NULL OR locked_until <= $1)
+		     ORDER BY id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING id, type, aggregate_id, payload, created_at, status, attempts, next_attempt_at, last_error, locked_until`,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []outbox.Event
+	for rows.Next() {
+		var e outbox.Event
+		var payload []byte
+		err := rows.Scan(&e.ID, &e.Type, &e.AggregateID, &payload, &e.CreatedAt,
+			&e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.LockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the subquery's order.
+	slices.SortFunc(events, func(a, b outbox.Event) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+func (o *PostgresOutbox) MarkDelivered(ctx context.Context, id int64, lockedUntil time.Time) error {
+	return o.finish(ctx, id,
+		`UPDATE outbox SET status = 'delivered', locked_until = NULL
+		 WHERE id = $1 AND status = 'pending' AND locked_until = $2`,
+		id, lockedUntil)
+}
+
+func (o *PostgresOutbox) MarkFailed(ctx context.Context, id int64, lockedUntil time.Time, lastErr string, next time.Time, dead bool) error {
+	status := outbox.StatusPending
+	if dead {
+		status = outbox.StatusDead
+	}
+	return o.finish(ctx, id,
+		`UPDATE outbox
+		 SET status = $3, attempts = attempts + 1, last_error = $4,
+		     next_attempt_at = $5, locked_until = NULL
+		 WHERE id = $1 AND status = 'pending' AND locked_until = $2`,
+		id, lockedUntil, string(status), lastErr, next)
+}
+
+// Requeue moves a dead event back to pending with a fresh attempt count.
+func (o *PostgresOutbox) Requeue(ctx context.Context, id int64) error {
+	return o.exec(ctx,
+		`UPDATE outbox SET status = 'pending', attempts = 0, next_attempt_at = now()
+		 WHERE id = $1`, id)
+}
+
+// Purge deletes delivered events created before before and returns how
+// many it deleted. Dead events stay for Requeue.
+func (o *PostgresOutbox) Purge(ctx context.Context, before time.Time) (int, error) {
+	res, err := o.db.ExecContext(ctx,
+		`DELETE FROM outbox WHERE status = 'delivered' AND created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// finish runs an update guarded by the claim and tells a lost lease from a
+// missing event.
+func (o *PostgresOutbox) finish(ctx context.Context, id int64, query string, args ...any) error {
+	err := o.exec(ctx, query, args...)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	var exists bool
+	err = o.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM outbox WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return outbox.ErrLeaseLost
+}
+
+func (o *PostgresOutbox) exec(ctx context.Context, query string, args ...any) error {
+	res, err := o.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/outbox"
+)
+
+func TestMemoryUserRepository_Events(t *testing.T) {
+	repo := New()
+	ctx := context.Background()
+
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, &alice))
+	alice.Name = "Alicia"
+	require.NoError(t, repo.Update(ctx, &alice))
+
+	stale := alice
+	stale.Version = 1
+	require.ErrorIs(t, repo.Update(ctx, &stale), ErrVersionMismatch)
+	dup := model.User{Name: "A", Email: "ALICE@example.com"}
+	require.ErrorIs(t, repo.Create(ctx, &dup), ErrEmailTaken)
+
+	events := repo.Outbox().Events()
+	require.Len(t, events, 2, "failed writes record no events")
+	assert.Equal(t, model.EventUserCreated, events[0].Type)
+	assert.Equal(t, model.EventUserUpdated, events[1].Type)
+	assert.Equal(t, "1", events[1].AggregateID)
+
+	var payload model.User
+	require.NoError(t, json.Unmarshal(events[1].Payload, &payload))
+	assert.Equal(t, alice, payload)
+}
+
+type relayFixture struct {
+	repo    *MemoryUserRepository
+	relay   *outbox.Relay
+	now     time.Time
+	publish func(outbox.Event) error
+}
+
+func newRelayFixture() *relayFixture {
+	f := &relayFixture{
+		repo: New(),
+		now:  time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.repo.outbox.now = func() time.Time { return f.now }
+	pub := outbox.PublisherFunc(func(ctx context.Context, e outbox.Event) error { return f.publish(e) })
+	f.relay = outbox.NewRelay(f.repo.Outbox(), pub, outbox.RelayOptions{
+		MaxAttempts: 3,
+		Backoff:     func(attempt int) time.Duration { return time.Duration(attempt) * time.Minute },
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Now:         func() time.Time { return f.now },
+	})
+	return f
+}
+
+func TestRelay_DeliversInOrder(t *testing.T) {
+	f := newRelayFixture()
+	ctx := context.Background()
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: email}))
+	}
+
+	var got []string
+	f.publish = func(e outbox.Event) error {
+		got = append(got, e.AggregateID)
+		return nil
+	}
+
+	n, err := f.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"1", "2"}, got)
+
+	n, err = f.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "delivered events are not sent again")
+	for _, e := range f.repo.Outbox().Events() {
+		assert.Equal(t, outbox.StatusDelivered, e.Status)
+	}
+}
+
+func TestRelay_RetryAndDeadLetter(t *testing.T) {
+	f := newRelayFixture()
+	ctx := context.Background()
+	require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: "a@example.com"}))
+
+	calls := 0
+	f.publish = func(outbox.Event) error {
+		calls++
+		return errors.New("downstream unavailable")
+	}
+
+	_, err := f.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	e := f.repo.Outbox().Events()[0]
+	assert.Equal(t, outbox.StatusPending, e.Status)
+	assert.Equal(t, 1, e.Attempts)
+	assert.Equal(t, "downstream unavailable", e.LastError)
+	assert.Equal(t, f.now.Add(time.Minute), e.NextAttemptAt)
+
+	n, _ := f.relay.ProcessBatch(ctx)
+	assert.Zero(t, n, "not due before the backoff elapses")
+
+	f.now = f.now.Add(time.Minute)
+	f.relay.ProcessBatch(ctx)
+	f.now = f.now.Add(2 * time.Minute)
+	f.relay.ProcessBatch(ctx)
+
+	e = f.repo.Outbox().Events()[0]
+	assert.Equal(t, outbox.StatusDead, e.Status)
+	assert.Equal(t, 3, e.Attempts)
+	assert.Equal(t, 3, calls)
+
+	f.now = f.now.Add(time.Hour)
+	n, _ = f.relay.ProcessBatch(ctx)
+	assert.Zero(t, n, "dead events are not retried")
+
+	require.NoError(t, f.repo.Outbox().Requeue(ctx, e.ID))
+	f.publish = func(outbox.Event) error { return nil }
+	n, err = f.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, outbox.StatusDelivered, f.repo.Outbox().Events()[0].Status)
+}
+
+func TestRelay_PermanentError(t *testing.T) {
+	f := newRelayFixture()
+	ctx := context.Background()
+	require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: "a@example.com"}))
+
+	f.publish = func(outbox.Event) error { return outbox.Permanent(errors.New("rejected")) }
+	_, err := f.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+
+	e := f.repo.Outbox().Events()[0]
+	assert.Equal(t, outbox.StatusDead, e.Status)
+	assert.Equal(t, 1, e.Attempts)
+}
+
+func TestRelay_LeaseExpiry(t *testing.T) {
+	f := newRelayFixture()
+	ctx := context.Background()
+	require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: "a@example.com"}))
+
+	// A relay that claimed the event and crashed before acknowledging it.
+	claimed, err := f.repo.Outbox().Claim(ctx, f.now, 10, 30*time.Second)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	delivered := 0
+	f.publish = func(outbox.Event) error {
+		delivered++
+		return nil
+	}
+	f.relay.ProcessBatch(ctx)
+	assert.Zero(t, delivered, "still leased")
+
+	f.now = f.now.Add(30 * time.Second)
+	f.relay.ProcessBatch(ctx)
+	assert.Equal(t, 1, delivered, "redelivered after the lease expires")
+}
+
+func TestRelay_Run(t *testing.T) {
+	repo := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, repo.Create(ctx, &model.User{Name: "x", Email: "a@example.com"}))
+
+	published := make(chan outbox.Event, 1)
+	relay := outbox.NewRelay(repo.Outbox(), outbox.PublisherFunc(func(ctx context.Context, e outbox.Event) error {
+		published <- e
+		return nil
+	}), outbox.RelayOptions{PollInterval: time.Millisecond, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+
+	select {
+	case e := <-published:
+		assert.Equal(t, model.EventUserCreated, e.Type)
+	case <-time.After(time.Second):
+		t.Fatal("event was not published")
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestRelay_LeaseLost(t *testing.T) {
+	f := newRelayFixture()
+	ctx := context.Background()
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: email}))
+	}
+
+	// The first publish outlives the lease and another relay claims both
+	// events in the meantime.
+	var taken []outbox.Event
+	var got []string
+	f.publish = func(e outbox.Event) error {
+		got = append(got, e.AggregateID)
+		f.now = f.now.Add(time.Minute)
+		var err error
+		taken, err = f.repo.Outbox().Claim(ctx, f.now, 10, time.Minute)
+		require.NoError(t, err)
+		return nil
+	}
+
+	n, err := f.relay.ProcessBatch(ctx)
+	require.NoError(t, err, "a lost lease is not a batch error")
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"1"}, got, "an expired claim is not published")
+	for _, e := range f.repo.Outbox().Events() {
+		assert.Equal(t, outbox.StatusPending, e.Status, "the new claim decides")
+	}
+
+	ob := f.repo.Outbox()
+	require.Len(t, taken, 2)
+	require.NoError(t, ob.MarkDelivered(ctx, taken[0].ID, taken[0].LockedUntil))
+	assert.ErrorIs(t, ob.MarkFailed(ctx, taken[0].ID, taken[0].LockedUntil, "late", f.now, true), outbox.ErrLeaseLost,
+		"a delivered event stays delivered")
+	assert.Equal(t, outbox.StatusDelivered, ob.Events()[0].Status)
+}
+
+func TestMemoryOutbox_Purge(t *testing.T) {
+	f := newRelayFixture()
+	ctx := context.Background()
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: email}))
+		f.now = f.now.Add(time.Minute)
+	}
+	ob := f.repo.Outbox()
+	events, err := ob.Claim(ctx, f.now, 10, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, ob.MarkDelivered(ctx, events[0].ID, events[0].LockedUntil))
+	require.NoError(t, ob.MarkFailed(ctx, events[1].ID, events[1].LockedUntil, "rejected", f.now, true))
+	require.NoError(t, ob.MarkDelivered(ctx, events[2].ID, events[2].LockedUntil))
+
+	n, err := ob.Purge(ctx, events[2].CreatedAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only delivered events older than the cutoff go")
+
+	left := ob.Events()
+	require.Len(t, left, 2)
+	assert.Equal(t, events[1].ID, left[0].ID)
+	assert.Equal(t, events[2].ID, left[1].ID)
+
+	// IDs are not reused and the rest stay addressable.
+	require.NoError(t, ob.Requeue(ctx, events[1].ID))
+	assert.ErrorIs(t, ob.MarkDelivered(ctx, events[0].ID, events[0].LockedUntil), ErrNotFound)
+	require.NoError(t, f.repo.Create(ctx, &model.User{Name: "x", Email: "d@example.com"}))
+	assert.Equal(t, events[2].ID+1, ob.Events()[2].ID)
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"ITMO-students/lecture-8/myapp/model"
+)
+
+const uniqueViolation = "23505"
+
+// PostgresUserRepository works with the users and outbox tables from
+// migrations/. Create and Update insert the matching event into the outbox
+// in the same transaction as the change.
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+func (r *PostgresUserRepository) FindByID(ctx context.Context, id int64) (model.User, error) {
+	var u model.User
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, email, version FROM users WHERE id = $1`, id).
+		Scan(&u.ID, &u.Name, &u.Email, &u.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, ErrNotFound
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	return u, nil
+}
+
+func (r *PostgresUserRepository) Create(ctx context.Context, user *model.User) error {
+	created := *user
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, version`,
+			created.Name, created.Email).
+			Scan(&created.ID, &created.Version)
+		if err != nil {
+			return mapError(err)
+		}
+		return insertUserEvent(ctx, tx, model.EventUserCreated, created)
+	})
+	if err != nil {
+		return err
+	}
+
+	*user = created
+	return nil
+}
+
+func (r *PostgresUserRepository) Update(ctx context.Context, user *model.User) error {
+	updated := *user
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`UPDATE users SET name = $1, email = $2, version = version + 1
+			 WHERE id = $3 AND version = $4
+			 RETURNING version`,
+			updated.Name, updated.Email, updated.ID, updated.Version).
+			Scan(&updated.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Nothing updated: either there is no such user or the version moved on.
+			var exists bool
+			err = tx.QueryRowContext(ctx,
+				`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, updated.ID).
+				Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrNotFound
+			}
+			return ErrVersionMismatch
+		}
+		if err != nil {
+			return mapError(err)
+		}
+		return insertUserEvent(ctx, tx, model.EventUserUpdated, updated)
+	})
+	if err != nil {
+		return err
+	}
+
+	*user = updated
+	return nil
+}
+
+func (r *PostgresUserRepository) List(ctx context.Context, afterID int64, limit int) ([]model.User, error) {
+	if limit <= 0 {
+		// A negative LIMIT is an error in Postgres.
+		return []model.User{}, nil
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, email, version FROM users WHERE id > $1 ORDER BY id LIMIT $2`,
+		afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0, limit)
+	for rows.Next() {
+		var u model.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Version); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgresUserRepository) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertUserEvent(ctx context.Context, tx *sql.Tx, typ string, user model.User) error {
+	e, err := userEvent(typ, user)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (type, aggregate_id, payload) VALUES ($1, $2, $3)`,
+		e.Type, e.AggregateID, []byte(e.Payload))
+	return err
+}
+
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrEmailTaken
+	}
+	return err
+}
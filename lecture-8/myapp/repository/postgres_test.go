@@ -0,0 +1,160 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/outbox"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func testDB(t *testing.T) *sql.DB {
+	return pgtest.Open(t, pgtest.Options{Migrations: "../migrations/*.sql"})
+}
+
+func TestPostgresUserRepository_Update(t *testing.T) {
+	repo := NewPostgres(testDB(t))
+	ctx := context.Background()
+
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, &alice))
+	assert.Equal(t, int64(1), alice.Version)
+
+	dup := model.User{Name: "Alice 2", Email: "ALICE@example.com"}
+	assert.ErrorIs(t, repo.Create(ctx, &dup), ErrEmailTaken)
+
+	stale := alice
+	alice.Name = "Alicia"
+	require.NoError(t, repo.Update(ctx, &alice))
+	assert.Equal(t, int64(2), alice.Version)
+
+	stale.Name = "Al"
+	assert.ErrorIs(t, repo.Update(ctx, &stale), ErrVersionMismatch)
+
+	missing := model.User{ID: 42, Version: 1}
+	assert.ErrorIs(t, repo.Update(ctx, &missing), ErrNotFound)
+
+	_, err := repo.FindByID(ctx, 42)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPostgresOutbox(t *testing.T) {
+	db := testDB(t)
+	repo := NewPostgres(db)
+	ob := NewPostgresOutbox(db)
+	ctx := context.Background()
+
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, &alice))
+	alice.Name = "Alicia"
+	require.NoError(t, repo.Update(ctx, &alice))
+
+	dup := model.User{Name: "Alice 2", Email: "alice@example.com"}
+	require.ErrorIs(t, repo.Create(ctx, &dup), ErrEmailTaken)
+
+	now := time.Now()
+	events, err := ob.Claim(ctx, now, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, events, 2, "a rolled back change leaves no event")
+	assert.Equal(t, model.EventUserCreated, events[0].Type)
+	assert.Equal(t, model.EventUserUpdated, events[1].Type)
+	assert.Equal(t, "1", events[1].AggregateID)
+
+	var payload model.User
+	require.NoError(t, json.Unmarshal(events[1].Payload, &payload))
+	assert.Equal(t, alice, payload)
+
+	again, err := ob.Claim(ctx, now, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again, "claimed events are leased")
+
+	require.NoError(t, ob.MarkDelivered(ctx, events[0].ID, events[0].LockedUntil))
+	assert.ErrorIs(t, ob.MarkDelivered(ctx, events[0].ID, events[0].LockedUntil), outbox.ErrLeaseLost)
+	require.NoError(t, ob.MarkFailed(ctx, events[1].ID, events[1].LockedUntil, "boom", now.Add(time.Second), false))
+
+	retry, err := ob.Claim(ctx, now.Add(time.Second), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, retry, 1)
+	assert.Equal(t, 1, retry[0].Attempts)
+	assert.Equal(t, "boom", retry[0].LastError)
+
+	// The first claim ran out and a second relay took the event over.
+	taken, err := ob.Claim(ctx, now.Add(2*time.Minute), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, taken, 1)
+	assert.ErrorIs(t, ob.MarkFailed(ctx, retry[0].ID, retry[0].LockedUntil, "boom", now, true), outbox.ErrLeaseLost)
+
+	require.NoError(t, ob.MarkFailed(ctx, taken[0].ID, taken[0].LockedUntil, "boom", now, true))
+	dead, err := ob.Claim(ctx, now.Add(time.Hour), 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	require.NoError(t, ob.Requeue(ctx, retry[0].ID))
+	requeued, err := ob.Claim(ctx, time.Now().Add(time.Second), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, requeued, 1)
+	assert.Equal(t, 0, requeued[0].Attempts)
+
+	assert.ErrorIs(t, ob.MarkDelivered(ctx, 999, now), ErrNotFound)
+
+	n, err := ob.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the delivered event is purged")
+	assert.ErrorIs(t, ob.MarkDelivered(ctx, events[0].ID, events[0].LockedUntil), ErrNotFound)
+}
+
+func TestPostgresIdempotency(t *testing.T) {
+	repo := NewPostgresIdempotency(testDB(t))
+	ctx := context.Background()
+
+	_, created, err := repo.Reserve(ctx, "k", "fp", time.Hour)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	rec, created, err := repo.Reserve(ctx, "k", "other", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.False(t, rec.Completed, "the first request is still in flight")
+
+	require.NoError(t, repo.Complete(ctx, "k", http.StatusCreated, http.Header{"Location": {"/users/1"}}, []byte("{}")))
+	rec, created, err = repo.Reserve(ctx, "k", "other", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.True(t, rec.Completed)
+	assert.Equal(t, "fp", rec.Fingerprint)
+	assert.Equal(t, http.StatusCreated, rec.Status)
+	assert.Equal(t, "/users/1", rec.Header.Get("Location"))
+	assert.Equal(t, []byte("{}"), rec.Body)
+
+	require.NoError(t, repo.Release(ctx, "k"))
+	_, created, err = repo.Reserve(ctx, "k", "other", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, created, "a released key can be reserved again")
+
+	time.Sleep(10 * time.Millisecond)
+	_, created, err = repo.Reserve(ctx, "k", "fp", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, created, "an expired key is taken over")
+
+	_, _, err = repo.Reserve(ctx, "short", "fp", time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	n, err := repo.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.ErrorIs(t, repo.Complete(ctx, "missing", http.StatusOK, nil, nil), ErrNotFound)
+}
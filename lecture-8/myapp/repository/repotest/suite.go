@@ -0,0 +1,165 @@
+// Package repotest holds the behavior every repository.UserRepository has
+// to share, as a test suite that each implementation runs against itself.
+//
+// The mocks and stubs of lecture-16/3-mocks-stubs implement that lecture's
+// narrower user.UserRepository, which has no List, so this suite cannot run
+// them. Their contract_test.go holds the same not-found and duplicate-email
+// checks and runs them against the manual mock and the in-memory store.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/model"
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+// Factory returns an empty repository for one subtest.
+type Factory func(t *testing.T) repository.UserRepository
+
+// RunUserRepositorySuite checks the repository.UserRepository contract:
+// not-found and conflict errors, optimistic locking, ID order and
+// pagination of List, and failing fast on a canceled context.
+func RunUserRepositorySuite(t *testing.T, newRepo Factory) {
+	t.Run("FindByID of a missing user", func(t *testing.T) {
+		_, err := newRepo(t).FindByID(context.Background(), 404)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Create assigns ID and version", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		alice := create(t, repo, "Alice", "alice@example.com")
+		bob := create(t, repo, "Bob", "bob@example.com")
+		assert.Positive(t, alice.ID)
+		assert.Greater(t, bob.ID, alice.ID, "IDs grow")
+		assert.Equal(t, int64(1), alice.Version)
+
+		got, err := repo.FindByID(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, alice, got)
+	})
+
+	t.Run("Create with a taken email", func(t *testing.T) {
+		repo := newRepo(t)
+		alice := create(t, repo, "Alice", "alice@example.com")
+
+		dup := model.User{Name: "Impostor", Email: "ALICE@example.com"}
+		assert.ErrorIs(t, repo.Create(context.Background(), &dup), repository.ErrEmailTaken,
+			"emails are compared case-insensitively")
+		assert.Zero(t, dup.ID, "a failed Create leaves the user untouched")
+
+		users, err := repo.List(context.Background(), 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []model.User{alice}, users)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		alice := create(t, repo, "Alice", "alice@example.com")
+		create(t, repo, "Bob", "bob@example.com")
+
+		stale := alice
+		alice.Name = "Alicia"
+		alice.Email = "Alice@Example.com"
+		require.NoError(t, repo.Update(ctx, &alice), "changing the case of one's own email is allowed")
+		assert.Equal(t, int64(2), alice.Version)
+
+		got, err := repo.FindByID(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, alice, got)
+
+		stale.Name = "Al"
+		assert.ErrorIs(t, repo.Update(ctx, &stale), repository.ErrVersionMismatch)
+		assert.Equal(t, int64(1), stale.Version, "a failed Update leaves the user untouched")
+
+		taken := alice
+		taken.Email = "bob@example.com"
+		assert.ErrorIs(t, repo.Update(ctx, &taken), repository.ErrEmailTaken)
+
+		missing := model.User{ID: alice.ID + 100, Name: "Ghost", Email: "ghost@example.com", Version: 1}
+		assert.ErrorIs(t, repo.Update(ctx, &missing), repository.ErrNotFound)
+
+		got, err = repo.FindByID(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, alice, got, "failed updates change nothing")
+	})
+
+	t.Run("List orders by ID and pages", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		empty, err := repo.List(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, empty)
+
+		var want []model.User
+		for i := range 5 {
+			want = append(want, create(t, repo, fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i)))
+		}
+
+		var got []model.User
+		afterID := int64(0)
+		for page := 0; ; page++ {
+			require.Less(t, page, 5, "pagination does not end")
+			users, err := repo.List(ctx, afterID, 2)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(users), 2)
+			if len(users) == 0 {
+				break
+			}
+			got = append(got, users...)
+			afterID = users[len(users)-1].ID
+		}
+		assert.Equal(t, want, got)
+
+		rest, err := repo.List(ctx, want[2].ID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, want[3:], rest, "afterID is exclusive")
+
+		for _, limit := range []int{0, -1} {
+			none, err := repo.List(ctx, 0, limit)
+			require.NoError(t, err)
+			assert.Empty(t, none, "limit %d", limit)
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		repo := newRepo(t)
+		alice := create(t, repo, "Alice", "alice@example.com")
+		// Warm caches, so a decorator cannot answer without asking ctx.
+		_, err := repo.FindByID(context.Background(), alice.ID)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = repo.FindByID(ctx, alice.ID)
+		assert.ErrorIs(t, err, context.Canceled, "FindByID")
+		bob := model.User{Name: "Bob", Email: "bob@example.com"}
+		assert.ErrorIs(t, repo.Create(ctx, &bob), context.Canceled, "Create")
+		alice.Name = "Alicia"
+		assert.ErrorIs(t, repo.Update(ctx, &alice), context.Canceled, "Update")
+		_, err = repo.List(ctx, 0, 10)
+		assert.ErrorIs(t, err, context.Canceled, "List")
+
+		users, err := repo.List(context.Background(), 0, 10)
+		require.NoError(t, err)
+		require.Len(t, users, 1, "canceled calls change nothing")
+		assert.Equal(t, "Alice", users[0].Name)
+	})
+}
+
+func create(t *testing.T, repo repository.UserRepository, name, email string) model.User {
+	t.Helper()
+	u := model.User{Name: name, Email: email}
+	require.NoError(t, repo.Create(context.Background(), &u))
+	return u
+}
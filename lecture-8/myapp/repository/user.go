@@ -1,11 +1,161 @@
 package repository
 
-type UserRepository struct{}
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
 
-func New() *UserRepository {
-	return &UserRepository{}
+	"ITMO-students/lecture-8/myapp/model"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrEmailTaken      = errors.New("email already taken")
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
+type UserRepository interface {
+	FindByID(ctx context.Context, id int64) (model.User, error)
+	// Create assigns ID and sets Version to 1.
+	Create(ctx context.Context, user *model.User) error
+	// Update saves user only if the stored version equals user.Version,
+	// otherwise it returns ErrVersionMismatch. On success user.Version is incremented.
+	Update(ctx context.Context, user *model.User) error
+	// List returns up to limit users with ID greater than afterID, ordered by ID.
+	// A limit of zero or less returns no users.
+	List(ctx context.Context, afterID int64, limit int) ([]model.User, error)
+}
+
+// MemoryUserRepository keeps users in a map; email uniqueness is case-insensitive.
+// Every successful Create and Update records an event in Outbox().
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[int64]model.User
+	byEmail map[string]int64
+	nextID  int64
+	outbox  *MemoryOutbox
+}
+
+func New() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:   make(map[int64]model.User),
+		byEmail: make(map[string]int64),
+		nextID:  1,
+		outbox:  NewMemoryOutbox(),
+	}
+}
+
+func (r *MemoryUserRepository) Outbox() *MemoryOutbox {
+	return r.outbox
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id int64) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return model.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email := strings.ToLower(user.Email)
+	if _, ok := r.byEmail[email]; ok {
+		return ErrEmailTaken
+	}
+
+	created := *user
+	created.ID, created.Version = r.nextID, 1
+	event, err := userEvent(model.EventUserCreated, created)
+	if err != nil {
+		return err
+	}
+
+	*user = created
+	r.nextID++
+	r.users[user.ID] = *user
+	r.byEmail[email] = user.ID
+	// Under r.mu, so the event and the change are observed together.
+	r.outbox.add(event)
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != user.Version {
+		return ErrVersionMismatch
+	}
+
+	oldEmail, newEmail := strings.ToLower(stored.Email), strings.ToLower(user.Email)
+	if oldEmail != newEmail {
+		if _, ok := r.byEmail[newEmail]; ok {
+			return ErrEmailTaken
+		}
+	}
+
+	updated := *user
+	updated.Version++
+	event, err := userEvent(model.EventUserUpdated, updated)
+	if err != nil {
+		return err
+	}
+
+	if oldEmail != newEmail {
+		delete(r.byEmail, oldEmail)
+		r.byEmail[newEmail] = user.ID
+	}
+	*user = updated
+	r.users[user.ID] = *user
+	r.outbox.add(event)
+	return nil
 }
 
-func (r *UserRepository) FindByID(id string) (string, error) {
-	return "John Doe", nil
+func (r *MemoryUserRepository) List(ctx context.Context, afterID int64, limit int) ([]model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return []model.User{}, nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.users))
+	for id := range r.users {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	users := make([]model.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, r.users[id])
+	}
+	return users, nil
 }
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/model"
+)
+
+func TestMemoryUserRepository_Update(t *testing.T) {
+	repo := New()
+	ctx := context.Background()
+
+	alice := model.User{Name: "Alice", Email: "alice@example.com"}
+	require.NoError(t, repo.Create(ctx, &alice))
+	assert.Equal(t, int64(1), alice.Version)
+
+	bob := model.User{Name: "Bob", Email: "bob@example.com"}
+	require.NoError(t, repo.Create(ctx, &bob))
+
+	stale := alice
+	alice.Name = "Alicia"
+	require.NoError(t, repo.Update(ctx, &alice))
+	assert.Equal(t, int64(2), alice.Version)
+
+	stale.Name = "Al"
+	assert.ErrorIs(t, repo.Update(ctx, &stale), ErrVersionMismatch)
+
+	alice.Email = "BOB@example.com"
+	assert.ErrorIs(t, repo.Update(ctx, &alice), ErrEmailTaken)
+
+	missing := model.User{ID: 42, Version: 1}
+	assert.ErrorIs(t, repo.Update(ctx, &missing), ErrNotFound)
+
+	got, err := repo.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+	assert.Equal(t, "alice@example.com", got.Email)
+	assert.Equal(t, int64(2), got.Version)
+}
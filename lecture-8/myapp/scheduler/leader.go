@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+)
+
+// Leader tells a scheduler whether this replica should run jobs.
+type Leader interface {
+	IsLeader(ctx context.Context) (bool, error)
+}
+
+// AdvisoryLock elects a leader with a Postgres session-level advisory
+// lock. The replica that takes the lock keeps one connection open to hold
+// it; if that connection dies, Postgres frees the lock and another replica
+// takes over on its next check.
+type AdvisoryLock struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn // non-nil while the lock is held
+}
+
+// NewAdvisoryLock uses key as the lock id; replicas of one service must
+// agree on it and other users of advisory locks must not.
+func NewAdvisoryLock(db *sql.DB, key int64) *AdvisoryLock {
+	return &AdvisoryLock{db: db, key: key}
+}
+
+// IsLeader reports whether the lock is held, trying to take it if not.
+func (l *AdvisoryLock) IsLeader(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// The session may be dead or merely slow to answer ctx. Either way
+		// it must not go back to the pool still holding the lock.
+		discard(l.conn)
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&locked)
+	if err != nil {
+		// The lock may have been taken before the error.
+		discard(conn)
+		return false, err
+	}
+	if !locked {
+		conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Release gives up leadership, if held.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	if err != nil {
+		discard(l.conn)
+	} else {
+		err = l.conn.Close()
+	}
+	l.conn = nil
+	return err
+}
+
+// discard closes conn's session instead of returning it to the pool, so
+// Postgres frees the advisory locks it holds.
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
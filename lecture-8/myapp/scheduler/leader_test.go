@@ -0,0 +1,75 @@
+//go:build integration
+
+package scheduler
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func TestAdvisoryLock(t *testing.T) {
+	db := pgtest.Open(t, pgtest.Options{})
+	ctx := context.Background()
+	const key = 424242
+
+	a := NewAdvisoryLock(db, key)
+	b := NewAdvisoryLock(db, key)
+	t.Cleanup(func() {
+		a.Release(ctx)
+		b.Release(ctx)
+	})
+
+	leader, err := a.IsLeader(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader)
+	leader, err = a.IsLeader(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader, "the holder stays leader")
+
+	leader, err = b.IsLeader(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	require.NoError(t, a.Release(ctx))
+	leader, err = b.IsLeader(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader, "another replica takes over after Release")
+}
+
+func TestAdvisoryLock_FailedPingFreesLock(t *testing.T) {
+	db := pgtest.Open(t, pgtest.Options{})
+	ctx := context.Background()
+	const key = 434343
+
+	a := NewAdvisoryLock(db, key)
+	b := NewAdvisoryLock(db, key)
+	t.Cleanup(func() {
+		a.Release(ctx)
+		b.Release(ctx)
+	})
+
+	leader, err := a.IsLeader(ctx)
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	// The ping fails on the caller's ctx while the session is alive; the
+	// session must be closed, not pooled with the lock still held.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = a.IsLeader(canceled)
+	require.Error(t, err)
+
+	assert.Eventually(t, func() bool {
+		leader, err := b.IsLeader(ctx)
+		return err == nil && leader
+	}, 5*time.Second, 50*time.Millisecond, "another replica takes over")
+}
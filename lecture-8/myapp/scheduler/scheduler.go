@@ -0,0 +1,280 @@
+// Package scheduler runs periodic tasks on cron expressions or fixed
+// intervals. A run that is still going when the next one is due makes the
+// next one skip; with a Leader, only the replica that holds leadership
+// runs anything. Time comes from a clock.Clock, so tests drive the
+// scheduler with a clock.Fake.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+// Task is one run of a job. Its context is canceled when the scheduler
+// stops or the job's Timeout passes; context.Cause tells the latter by
+// context.DeadlineExceeded.
+type Task func(ctx context.Context) error
+
+type Job struct {
+	// Name identifies the job in logs and Stats; it must be unique.
+	Name     string
+	Schedule Schedule
+	Task     Task
+	// Jitter delays each run by a random duration below it, so replicas
+	// and jobs with the same schedule do not all fire at once.
+	Jitter time.Duration
+	// Timeout bounds one run; zero means no bound.
+	Timeout time.Duration
+	// Local jobs work on state of their own replica, such as an in-memory
+	// cache, and run everywhere regardless of Options.Leader.
+	Local bool
+}
+
+// Options configures a Scheduler. Zero fields take the defaults noted below.
+type Options struct {
+	// Clock defaults to clock.Real().
+	Clock  clock.Clock
+	Logger *slog.Logger
+	// Leader, if set, is asked before every run, and the run is skipped
+	// unless this replica leads. Nil means every replica runs every job.
+	Leader Leader
+	// Rand returns values in [0, 1) for jitter. Default math/rand/v2.
+	Rand func() float64
+}
+
+// JobStats are the counters of one job.
+type JobStats struct {
+	Name string `json:"name"`
+	// Runs counts started runs; Failures the ones that returned an error
+	// or panicked, Panics the latter only.
+	Runs     uint64 `json:"runs"`
+	Failures uint64 `json:"failures"`
+	Panics   uint64 `json:"panics"`
+	// Skipped counts runs dropped because the previous one was still
+	// going, NotLeader the ones dropped on a replica that did not lead.
+	Skipped      uint64        `json:"skipped"`
+	NotLeader    uint64        `json:"not_leader"`
+	Running      bool          `json:"running"`
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration_ns"`
+	LastError    string        `json:"last_error,omitempty"`
+	NextRun      time.Time     `json:"next_run"`
+}
+
+type Scheduler struct {
+	opts Options
+
+	mu      sync.Mutex
+	jobs    []*job
+	started bool
+}
+
+type job struct {
+	Job
+	running atomic.Bool
+
+	mu    sync.Mutex
+	stats JobStats
+}
+
+func New(opts Options) *Scheduler {
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.Float64
+	}
+	return &Scheduler{opts: opts}
+}
+
+// Add registers j. Jobs must be added before Run.
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" || j.Schedule == nil || j.Task == nil {
+		return errors.New("scheduler: job needs a name, a schedule and a task")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return errors.New("scheduler: Add after Run")
+	}
+	for _, other := range s.jobs {
+		if other.Name == j.Name {
+			return fmt.Errorf("scheduler: duplicate job %q", j.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{Job: j, stats: JobStats{Name: j.Name}})
+	return nil
+}
+
+// Run schedules the jobs until ctx is canceled, then waits for running
+// tasks, whose contexts are canceled too, and returns ctx.Err().
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.started = true
+	jobs := s.jobs
+	s.mu.Unlock()
+
+	var runs sync.WaitGroup
+	defer runs.Wait()
+
+	var loops sync.WaitGroup
+	for _, j := range jobs {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			s.loop(ctx, j, &runs)
+		}()
+	}
+	loops.Wait()
+	return ctx.Err()
+}
+
+// Stats returns the counters of every job, ordered by name.
+func (s *Scheduler) Stats() []JobStats {
+	s.mu.Lock()
+	jobs := slices.Clone(s.jobs)
+	s.mu.Unlock()
+
+	stats := make([]JobStats, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		st := j.stats
+		j.mu.Unlock()
+		st.Running = j.running.Load()
+		stats = append(stats, st)
+	}
+	slices.SortFunc(stats, func(a, b JobStats) int { return strings.Compare(a.Name, b.Name) })
+	return stats
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job, runs *sync.WaitGroup) {
+	c := s.opts.Clock
+	prev := c.Now()
+	for {
+		now := c.Now()
+		next := j.Schedule.Next(prev)
+		if !next.IsZero() && !next.After(now) {
+			// Runs missed while the process was stalled are not made up.
+			next = j.Schedule.Next(now)
+		}
+		if next.IsZero() {
+			s.opts.Logger.Error("scheduler: schedule has no next run", "job", j.Name)
+			return
+		}
+
+		fire := next
+		if j.Jitter > 0 {
+			fire = fire.Add(time.Duration(s.opts.Rand() * float64(j.Jitter)))
+		}
+		j.update(func(st *JobStats) { st.NextRun = fire })
+
+		t := c.NewTimer(fire.Sub(now))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C():
+		}
+		prev = next
+		s.trigger(ctx, j, runs)
+	}
+}
+
+func (s *Scheduler) trigger(ctx context.Context, j *job, runs *sync.WaitGroup) {
+	log := s.opts.Logger.With("job", j.Name)
+	if !j.running.CompareAndSwap(false, true) {
+		j.update(func(st *JobStats) { st.Skipped++ })
+		log.Warn("scheduler: previous run still going, skipping")
+		return
+	}
+
+	if s.opts.Leader != nil && !j.Local {
+		leader, err := s.opts.Leader.IsLeader(ctx)
+		if err != nil {
+			log.Error("scheduler: leader check", "error", err)
+		}
+		if !leader {
+			j.running.Store(false)
+			j.update(func(st *JobStats) { st.NotLeader++ })
+			return
+		}
+	}
+
+	runs.Add(1)
+	go func() {
+		defer runs.Done()
+		defer j.running.Store(false)
+		s.run(ctx, j, log)
+	}()
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job, log *slog.Logger) {
+	if j.Timeout > 0 {
+		// Timed on Options.Clock, unlike context.WithTimeout, so a
+		// clock.Fake drives it too.
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		timer := s.opts.Clock.AfterFunc(j.Timeout, func() { cancel(context.DeadlineExceeded) })
+		defer timer.Stop()
+		defer cancel(nil)
+	}
+
+	start := s.opts.Clock.Now()
+	j.update(func(st *JobStats) {
+		st.Runs++
+		st.LastRun = start
+	})
+	log.Info("scheduler: run started")
+
+	panicked, err := safeRun(ctx, j.Task)
+	if err != nil && errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", j.Timeout, err)
+	}
+	took := s.opts.Clock.Since(start)
+
+	j.update(func(st *JobStats) {
+		st.LastDuration = took
+		st.LastError = ""
+		if err != nil {
+			st.Failures++
+			st.LastError = err.Error()
+		}
+		if panicked {
+			st.Panics++
+		}
+	})
+	if err != nil {
+		log.Error("scheduler: run failed", "duration", took, "error", err)
+		return
+	}
+	log.Info("scheduler: run finished", "duration", took)
+}
+
+func safeRun(ctx context.Context, task Task) (panicked bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked, err = true, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return false, task(ctx)
+}
+
+func (j *job) update(f func(*JobStats)) {
+	j.mu.Lock()
+	f(&j.stats)
+	j.mu.Unlock()
+}
@@ -0,0 +1,295 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/clock"
+)
+
+var start = time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+type fixture struct {
+	clock *clock.Fake
+	sched *Scheduler
+	stop  func()
+}
+
+func newFixture(t *testing.T, opts Options, jobs ...Job) *fixture {
+	t.Helper()
+	f := &fixture{clock: clock.NewFake(start)}
+	opts.Clock = f.clock
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	f.sched = New(opts)
+	for _, j := range jobs {
+		require.NoError(t, f.sched.Add(j))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- f.sched.Run(ctx) }()
+	f.stop = func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	}
+	t.Cleanup(func() {
+		if ctx.Err() == nil {
+			f.stop()
+		}
+	})
+	// Every job loop is parked on its timer.
+	f.clock.BlockUntil(len(jobs))
+	return f
+}
+
+// tick advances to the next timer once the given number of loops wait on one.
+func (f *fixture) tick(d time.Duration, waiters int) {
+	f.clock.BlockUntil(waiters)
+	f.clock.Advance(d)
+}
+
+func (f *fixture) stats(t *testing.T, name string) JobStats {
+	t.Helper()
+	for _, st := range f.sched.Stats() {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("no job %q", name)
+	return JobStats{}
+}
+
+func TestScheduler_Interval(t *testing.T) {
+	ran := make(chan time.Time, 10)
+	var f *fixture
+	f = newFixture(t, Options{}, Job{
+		Name:     "refresh",
+		Schedule: Every(time.Minute),
+		Task: func(ctx context.Context) error {
+			ran <- f.clock.Now()
+			return nil
+		},
+	})
+	assert.Equal(t, start.Add(time.Minute), f.stats(t, "refresh").NextRun)
+
+	f.clock.Advance(59 * time.Second)
+	select {
+	case <-ran:
+		t.Fatal("ran early")
+	default:
+	}
+
+	f.clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Minute), <-ran)
+	f.tick(time.Minute, 1)
+	assert.Equal(t, start.Add(2*time.Minute), <-ran)
+
+	f.stop()
+	st := f.stats(t, "refresh")
+	assert.Equal(t, uint64(2), st.Runs)
+	assert.Equal(t, start.Add(2*time.Minute), st.LastRun)
+}
+
+func TestScheduler_Cron(t *testing.T) {
+	ran := make(chan time.Time, 10)
+	var f *fixture
+	f = newFixture(t, Options{}, Job{
+		Name:     "nightly",
+		Schedule: MustParseCron("30 3 * * *"),
+		Task: func(ctx context.Context) error {
+			ran <- f.clock.Now()
+			return nil
+		},
+	})
+
+	f.clock.Advance(17*time.Hour + 30*time.Minute)
+	assert.Equal(t, time.Date(2025, 1, 16, 3, 30, 0, 0, time.UTC), <-ran)
+	f.clock.BlockUntil(1)
+	assert.Equal(t, time.Date(2025, 1, 17, 3, 30, 0, 0, time.UTC), f.stats(t, "nightly").NextRun)
+}
+
+func TestScheduler_SkipsWhileRunning(t *testing.T) {
+	release := make(chan struct{})
+	var started atomic.Int32
+	f := newFixture(t, Options{}, Job{
+		Name:     "slow",
+		Schedule: Every(time.Minute),
+		Task: func(ctx context.Context) error {
+			started.Add(1)
+			<-release
+			return nil
+		},
+	})
+
+	f.clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return f.stats(t, "slow").Running }, time.Second, time.Millisecond)
+	f.tick(time.Minute, 1)
+	f.tick(time.Minute, 1)
+	f.clock.BlockUntil(1)
+
+	st := f.stats(t, "slow")
+	assert.Equal(t, uint64(2), st.Skipped)
+	assert.Equal(t, int32(1), started.Load())
+
+	close(release)
+	require.Eventually(t, func() bool { return !f.stats(t, "slow").Running }, time.Second, time.Millisecond)
+	f.tick(time.Minute, 1)
+	require.Eventually(t, func() bool { return started.Load() == 2 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_FailuresAndPanics(t *testing.T) {
+	var calls atomic.Int32
+	f := newFixture(t, Options{}, Job{
+		Name:     "flaky",
+		Schedule: Every(time.Minute),
+		Task: func(ctx context.Context) error {
+			switch calls.Add(1) {
+			case 1:
+				return errors.New("db down")
+			case 2:
+				panic("nil map")
+			}
+			return nil
+		},
+	})
+
+	for i := int32(1); i <= 3; i++ {
+		f.tick(time.Minute, 1)
+		require.Eventually(t, func() bool {
+			st := f.stats(t, "flaky")
+			return st.Runs == uint64(i) && !st.Running
+		}, time.Second, time.Millisecond)
+
+		st := f.stats(t, "flaky")
+		switch i {
+		case 1:
+			assert.Equal(t, "db down", st.LastError)
+		case 2:
+			assert.Contains(t, st.LastError, "panic: nil map")
+		case 3:
+			assert.Empty(t, st.LastError)
+		}
+	}
+
+	st := f.stats(t, "flaky")
+	assert.Equal(t, uint64(2), st.Failures)
+	assert.Equal(t, uint64(1), st.Panics)
+}
+
+func TestScheduler_Jitter(t *testing.T) {
+	f := newFixture(t, Options{Rand: func() float64 { return 0.5 }}, Job{
+		Name:     "spread",
+		Schedule: Every(time.Minute),
+		Jitter:   10 * time.Second,
+		Task:     func(ctx context.Context) error { return nil },
+	})
+	assert.Equal(t, start.Add(time.Minute+5*time.Second), f.stats(t, "spread").NextRun)
+
+	f.clock.Advance(time.Minute + 5*time.Second)
+	f.clock.BlockUntil(1)
+	// The next run is jittered from the schedule, not from the jittered run.
+	assert.Equal(t, start.Add(2*time.Minute+5*time.Second), f.stats(t, "spread").NextRun)
+}
+
+type fakeLeader struct{ leader atomic.Bool }
+
+func (l *fakeLeader) IsLeader(ctx context.Context) (bool, error) { return l.leader.Load(), nil }
+
+func TestScheduler_Leader(t *testing.T) {
+	leader := &fakeLeader{}
+	var runs atomic.Int32
+	f := newFixture(t, Options{Leader: leader}, Job{
+		Name:     "cleanup",
+		Schedule: Every(time.Minute),
+		Task: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	f.tick(time.Minute, 1)
+	f.clock.BlockUntil(1)
+	assert.Equal(t, uint64(1), f.stats(t, "cleanup").NotLeader)
+
+	leader.leader.Store(true)
+	f.tick(time.Minute, 1)
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_LocalIgnoresLeader(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	f := newFixture(t, Options{Leader: &fakeLeader{}}, Job{
+		Name:     "evict",
+		Schedule: Every(time.Minute),
+		Local:    true,
+		Task: func(ctx context.Context) error {
+			ran <- struct{}{}
+			return nil
+		},
+	})
+
+	f.clock.Advance(time.Minute)
+	<-ran
+	assert.Zero(t, f.stats(t, "evict").NotLeader)
+}
+
+func TestScheduler_StopCancelsRuns(t *testing.T) {
+	canceled := make(chan struct{})
+	f := newFixture(t, Options{}, Job{
+		Name:     "long",
+		Schedule: Every(time.Minute),
+		Task: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(canceled)
+			return ctx.Err()
+		},
+	})
+
+	f.clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return f.stats(t, "long").Running }, time.Second, time.Millisecond)
+	f.stop()
+	<-canceled
+	assert.False(t, f.stats(t, "long").Running, "Run waits for running tasks")
+}
+
+func TestScheduler_Timeout(t *testing.T) {
+	f := newFixture(t, Options{}, Job{
+		Name:     "stuck",
+		Schedule: Every(time.Minute),
+		Timeout:  30 * time.Second,
+		Task: func(ctx context.Context) error {
+			<-ctx.Done()
+			assert.ErrorIs(t, context.Cause(ctx), context.DeadlineExceeded)
+			return ctx.Err()
+		},
+	})
+
+	f.clock.Advance(time.Minute)
+	// The job loop waits for the next run and the run for its timeout.
+	f.clock.BlockUntil(2)
+	assert.True(t, f.stats(t, "stuck").Running, "real time does not end the run")
+
+	f.clock.Advance(30 * time.Second)
+	require.Eventually(t, func() bool { return !f.stats(t, "stuck").Running }, time.Second, time.Millisecond)
+	st := f.stats(t, "stuck")
+	assert.Equal(t, uint64(1), st.Failures)
+	assert.Equal(t, "timed out after 30s: context canceled", st.LastError)
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := New(Options{})
+	task := func(context.Context) error { return nil }
+
+	require.NoError(t, s.Add(Job{Name: "a", Schedule: Every(time.Second), Task: task}))
+	assert.Error(t, s.Add(Job{Name: "a", Schedule: Every(time.Second), Task: task}))
+	assert.Error(t, s.Add(Job{Name: "b", Task: task}))
+	assert.Error(t, s.Add(Job{Schedule: Every(time.Second), Task: task}))
+}
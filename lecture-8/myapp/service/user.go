@@ -1,17 +1,134 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"ITMO-students/lecture-8/myapp/model"
 	"ITMO-students/lecture-8/myapp/repository"
 )
 
+var (
+	ErrInvalidUser   = errors.New("invalid user")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
 type UserService struct {
 	repo repository.UserRepository
+	// current is what UpdateUser reads the user it changes from; it
+	// bypasses any cache in repo.
+	current repository.UserRepository
 }
 
 func New(r repository.UserRepository) *UserService {
-	return &UserService{repo: r}
+	return &UserService{repo: r, current: r}
+}
+
+// NewCached serves reads from cached, a caching decorator around repo.
+// UpdateUser reads the user it changes from repo, so a stale cached copy
+// can neither fail a correct If-Match nor overwrite newer fields; the write
+// goes through cached to keep it current.
+func NewCached(repo, cached repository.UserRepository) *UserService {
+	return &UserService{repo: cached, current: repo}
+}
+
+func (s *UserService) GetUser(ctx context.Context, id int64) (model.User, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// ListUsers returns a page of users ordered by ID. cursor is the NextCursor
+// of the previous page or empty for the first one; limit is clamped to MaxPageSize.
+func (s *UserService) ListUsers(ctx context.Context, cursor string, limit int) (model.UserPage, error) {
+	var afterID int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id < 0 {
+			return model.UserPage{}, ErrInvalidCursor
+		}
+		afterID = id
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	// One extra row tells whether there is a next page.
+	users, err := s.repo.List(ctx, afterID, limit+1)
+	if err != nil {
+		return model.UserPage{}, err
+	}
+
+	page := model.UserPage{Items: users}
+	if len(users) > limit {
+		page.Items = users[:limit]
+		page.NextCursor = strconv.FormatInt(page.Items[limit-1].ID, 10)
+	}
+	return page, nil
+}
+
+func (s *UserService) CreateUser(ctx context.Context, name, email string) (model.User, error) {
+	user := model.User{
+		Name:  strings.TrimSpace(name),
+		Email: strings.TrimSpace(email),
+	}
+	if err := validate(user); err != nil {
+		return model.User{}, err
+	}
+
+	if err := s.repo.Create(ctx, &user); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// UserPatch holds fields to change; nil means "leave as is".
+type UserPatch struct {
+	Name  *string
+	Email *string
+}
+
+// UpdateUser applies patch to the user if its current version is version.
+// It returns repository.ErrVersionMismatch if the user was changed meanwhile.
+func (s *UserService) UpdateUser(ctx context.Context, id, version int64, patch UserPatch) (model.User, error) {
+	user, err := s.current.FindByID(ctx, id)
+	if err != nil {
+		return model.User{}, err
+	}
+	// The version-checked Update decides; a mismatch there also drops
+	// the cached copy.
+	user.Version = version
+
+	if patch.Name != nil {
+		user.Name = strings.TrimSpace(*patch.Name)
+	}
+	if patch.Email != nil {
+		user.Email = strings.TrimSpace(*patch.Email)
+	}
+	if err := validate(user); err != nil {
+		return model.User{}, err
+	}
+
+	if err := s.repo.Update(ctx, &user); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
 }
 
-func (s *UserService) GetUser(id string) (string, error) {
-	return s.repo.FindByID(id)
+func validate(u model.User) error {
+	if u.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidUser)
+	}
+	if _, err := mail.ParseAddress(u.Email); err != nil {
+		return fmt.Errorf("%w: bad email", ErrInvalidUser)
+	}
+	return nil
 }
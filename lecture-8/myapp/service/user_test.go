@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/cache"
+	"ITMO-students/lecture-8/myapp/repository"
+)
+
+func TestUpdateUser_StaleCache(t *testing.T) {
+	base := repository.New()
+	svc := NewCached(base, repository.NewCached(base, cache.Options{}))
+	ctx := context.Background()
+
+	alice, err := svc.CreateUser(ctx, "Alice", "alice@example.com")
+	require.NoError(t, err)
+
+	// Another replica changes the user; the cached copy is now stale.
+	other, err := base.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	other.Name = "Alicia"
+	require.NoError(t, base.Update(ctx, &other))
+
+	email := "alicia@example.com"
+	updated, err := svc.UpdateUser(ctx, alice.ID, other.Version, UserPatch{Email: &email})
+	require.NoError(t, err, "the If-Match of the current version is accepted")
+	assert.Equal(t, "Alicia", updated.Name, "fields changed elsewhere are kept")
+	assert.Equal(t, email, updated.Email)
+
+	_, err = svc.UpdateUser(ctx, alice.ID, other.Version, UserPatch{Email: &email})
+	assert.ErrorIs(t, err, repository.ErrVersionMismatch)
+	got, err := svc.GetUser(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated, got)
+}
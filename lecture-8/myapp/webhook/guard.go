@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// Special-purpose ranges that netip has no predicate for.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved and broadcast
+}
+
+// forbidden reports whether deliveries must not be sent to ip: loopback,
+// link-local (cloud metadata lives at 169.254.169.254), private and
+// other non-public addresses.
+func forbidden(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return true
+	}
+	for _, p := range forbiddenPrefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost rejects hosts that are, or resolve to, forbidden addresses. A
+// failed lookup is not an error: DNS may change after this check anyway,
+// so the delivery client re-checks every connection it makes.
+func (s *Service) checkHost(ctx context.Context, host string) error {
+	if s.allowInternal {
+		return nil
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if forbidden(ip) {
+			return fmt.Errorf("%w: url points at an internal address", ErrInvalidSubscription)
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	ips, err := s.lookup(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if forbidden(ip) {
+			return fmt.Errorf("%w: url points at an internal address", ErrInvalidSubscription)
+		}
+	}
+	return nil
+}
+
+// guardConn runs after DNS resolution, right before connect, so it also
+// covers redirects and hosts whose records changed after subscribing.
+func guardConn(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if forbidden(ap.Addr()) {
+		return fmt.Errorf("webhook: refusing to connect to internal address %s", ap.Addr())
+	}
+	return nil
+}
+
+// newClient returns the default delivery client. It does not use a proxy:
+// the guard would then check the proxy address rather than the endpoint.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: guardConn}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store for a single replica.
+type MemoryStore struct {
+	mu          sync.Mutex
+	subs        map[int64]Subscription
+	deliveries  map[int64]Delivery
+	lockedUntil map[int64]time.Time
+	seen        map[[2]int64]bool // {subscription, event} of non-replay deliveries
+	nextSubID   int64
+	nextDelID   int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		subs:        make(map[int64]Subscription),
+		deliveries:  make(map[int64]Delivery),
+		lockedUntil: make(map[int64]time.Time),
+		seen:        make(map[[2]int64]bool),
+	}
+}
+
+func (m *MemoryStore) CreateSubscription(ctx context.Context, s *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextSubID++
+	s.ID = m.nextSubID
+	m.subs[s.ID] = cloneSubscription(*s)
+	return nil
+}
+
+func (m *MemoryStore) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.subs[id]
+	if !ok {
+		return Subscription{}, ErrNotFound
+	}
+	return cloneSubscription(s), nil
+}
+
+func (m *MemoryStore) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subs := make([]Subscription, 0, len(m.subs))
+	for _, s := range m.subs {
+		subs = append(subs, cloneSubscription(s))
+	}
+	slices.SortFunc(subs, func(a, b Subscription) int { return cmp.Compare(a.ID, b.ID) })
+	return subs, nil
+}
+
+func (m *MemoryStore) UpdateSubscription(ctx context.Context, s *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subs[s.ID]; !ok {
+		return ErrNotFound
+	}
+	m.subs[s.ID] = cloneSubscription(*s)
+	return nil
+}
+
+// DeleteSubscription also drops the subscription's delivery log.
+func (m *MemoryStore) DeleteSubscription(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.subs, id)
+	for did, d := range m.deliveries {
+		if d.SubscriptionID == id {
+			delete(m.deliveries, did)
+			delete(m.lockedUntil, did)
+			delete(m.seen, [2]int64{id, d.EventID})
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) CreateDelivery(ctx context.Context, d *Delivery) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subs[d.SubscriptionID]; !ok {
+		return false, ErrNotFound
+	}
+	key := [2]int64{d.SubscriptionID, d.EventID}
+	if d.ReplayOf == 0 {
+		if m.seen[key] {
+			return false, nil
+		}
+		m.seen[key] = true
+	}
+
+	m.nextDelID++
+	d.ID = m.nextDelID
+	m.deliveries[d.ID] = cloneDelivery(*d)
+	return true, nil
+}
+
+func (m *MemoryStore) GetDelivery(ctx context.Context, id int64) (Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[id]
+	if !ok {
+		return Delivery{}, ErrNotFound
+	}
+	return cloneDelivery(d), nil
+}
+
+func (m *MemoryStore) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []Delivery
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			list = append(list, cloneDelivery(d))
+		}
+	}
+	slices.SortFunc(list, func(a, b Delivery) int { return cmp.Compare(b.ID, a.ID) })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (m *MemoryStore) ClaimDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []Delivery
+	for id, d := range m.deliveries {
+		if d.Status == DeliveryPending && !d.NextAttemptAt.After(now) && !m.lockedUntil[id].After(now) {
+			due = append(due, d)
+		}
+	}
+	slices.SortFunc(due, func(a, b Delivery) int { return cmp.Compare(a.ID, b.ID) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i, d := range due {
+		m.lockedUntil[d.ID] = now.Add(lease)
+		due[i] = cloneDelivery(d)
+	}
+	return due, nil
+}
+
+func (m *MemoryStore) RecordAttempt(ctx context.Context, deliveryID int64, a Attempt, status DeliveryStatus, next time.Time, disableAfter int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[deliveryID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	d.Attempts = append(slices.Clip(d.Attempts), a)
+	d.Status = status
+	d.NextAttemptAt = next
+	m.deliveries[deliveryID] = d
+	delete(m.lockedUntil, deliveryID)
+
+	s, ok := m.subs[d.SubscriptionID]
+	if !ok {
+		return false, nil
+	}
+	if status == DeliverySucceeded {
+		s.ConsecutiveFailures = 0
+		m.subs[s.ID] = s
+		return false, nil
+	}
+
+	s.ConsecutiveFailures++
+	disabled := false
+	if s.Active && disableAfter > 0 && s.ConsecutiveFailures >= disableAfter {
+		s.Active = false
+		at := a.At
+		s.DisabledAt = &at
+		disabled = true
+	}
+	m.subs[s.ID] = s
+	return disabled, nil
+}
+
+func cloneSubscription(s Subscription) Subscription {
+	s.Events = slices.Clone(s.Events)
+	if s.DisabledAt != nil {
+		at := *s.DisabledAt
+		s.DisabledAt = &at
+	}
+	return s
+}
+
+func cloneDelivery(d Delivery) Delivery {
+	d.Attempts = append([]Attempt{}, d.Attempts...)
+	return d
+}
@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PoolOptions configures a Pool. Zero fields take the defaults noted below.
+type PoolOptions struct {
+	// Workers is the number of concurrent deliveries. Default 4.
+	Workers int
+	// PollInterval is the pause after a poll that found nothing. Default 1s.
+	PollInterval time.Duration
+	// MaxAttempts per delivery before it is marked failed. Default 8.
+	MaxAttempts int
+	// Backoff returns the delay after the given failed attempt (1-based).
+	// Default: 10s doubling up to 1h.
+	Backoff func(attempt int) time.Duration
+	// DisableAfter consecutive failed attempts disable the subscription. Default 20.
+	DisableAfter int
+	// Client sends the requests. Default: a client with a 10s timeout that
+	// refuses to connect to loopback, link-local and private addresses.
+	Client *http.Client
+	Logger *slog.Logger
+	Now    func() time.Time
+}
+
+// Pool sends pending deliveries with a fixed number of workers.
+type Pool struct {
+	store Store
+	opts  PoolOptions
+	lease time.Duration
+}
+
+func NewPool(store Store, opts PoolOptions) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.DisableAfter <= 0 {
+		opts.DisableAfter = 20
+	}
+	if opts.Client == nil {
+		opts.Client = newClient()
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	// A claimed delivery must not be handed out again while it may still
+	// be in flight.
+	lease := time.Minute
+	if t := opts.Client.Timeout; t > 0 && 2*t > lease {
+		lease = 2 * t
+	}
+	return &Pool{store: store, opts: opts, lease: lease}
+}
+
+// DefaultBackoff is 10s, 20s, 40s, ... capped at one hour.
+func DefaultBackoff(attempt int) time.Duration {
+	d := 10 * time.Second << min(attempt-1, 12)
+	return min(d, time.Hour)
+}
+
+// Run delivers until ctx is canceled, then waits for in-flight deliveries
+// to finish (they are bounded by the client timeout) and returns ctx.Err().
+func (p *Pool) Run(ctx context.Context) error {
+	jobs := make(chan Delivery)
+	var wg sync.WaitGroup
+	for range p.opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range jobs {
+				// Not ctx: a delivery that started is finished and recorded.
+				p.Deliver(context.WithoutCancel(ctx), d)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(jobs)
+
+	for {
+		due, err := p.store.ClaimDeliveries(ctx, p.opts.Now(), p.opts.Workers, p.lease)
+		if err != nil && ctx.Err() == nil {
+			p.opts.Logger.Error("webhook: claim deliveries", "error", err)
+		}
+		for _, d := range due {
+			select {
+			case jobs <- d:
+			case <-ctx.Done():
+				// Unsent claims are picked up again when the lease expires.
+				return ctx.Err()
+			}
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		t := time.NewTimer(p.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Deliver makes one attempt and records it.
+func (p *Pool) Deliver(ctx context.Context, d Delivery) error {
+	sub, err := p.store.GetSubscription(ctx, d.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	start := p.opts.Now()
+	attempt := Attempt{At: start}
+	if !sub.Active {
+		attempt.Error = "subscription is disabled"
+		_, err := p.store.RecordAttempt(ctx, d.ID, attempt, DeliveryFailed, start, 0)
+		return err
+	}
+
+	attempt.StatusCode, err = p.send(ctx, sub, d)
+	attempt.Duration = p.opts.Now().Sub(start)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	status, next := DeliverySucceeded, time.Time{}
+	if err != nil {
+		n := len(d.Attempts) + 1
+		status, next = DeliveryPending, start.Add(p.opts.Backoff(n))
+		if n >= p.opts.MaxAttempts {
+			status = DeliveryFailed
+		}
+	}
+
+	disabled, recErr := p.store.RecordAttempt(ctx, d.ID, attempt, status, next, p.opts.DisableAfter)
+	if disabled {
+		p.opts.Logger.Warn("webhook: subscription disabled after repeated failures",
+			"subscription_id", sub.ID, "url", sub.URL)
+	}
+	if err != nil {
+		p.opts.Logger.Info("webhook: delivery failed", "delivery_id", d.ID,
+			"subscription_id", sub.ID, "status", status, "error", err)
+	}
+	return recErr
+}
+
+func (p *Pool) send(ctx context.Context, sub Subscription, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+	ts := p.opts.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "myapp-webhooks/1")
+	req.Header.Set(IDHeader, strconv.FormatInt(d.EventID, 10))
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, ts, d.Body))
+
+	resp, err := p.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
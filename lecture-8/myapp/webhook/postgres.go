@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+const (
+	subscriptionColumns = `id, url, events, secret, active, consecutive_failures, disabled_at, created_at`
+	deliveryColumns     = `id, subscription_id, event_id, event_type, body, status, next_attempt_at,
+	replay_of, attempts, created_at`
+)
+
+// PostgresStore is a Store over the webhook tables from migrations/, shared
+// by all replicas. ClaimDeliveries uses FOR UPDATE SKIP LOCKED, so any
+// number of pools can poll it.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (p *PostgresStore) CreateSubscription(ctx context.Context, s *Subscription) error {
+	events, err := json.Marshal(s.Events)
+	if err != nil {
+		return err
+	}
+	return p.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_subscriptions (url, events, secret, active, consecutive_failures, disabled_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id`,
+		s.URL, events, s.Secret, s.Active, s.ConsecutiveFailures, s.DisabledAt, s.CreatedAt).Scan(&s.ID)
+}
+
+func (p *PostgresStore) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
+	s, err := scanSubscription(p.db.QueryRowContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Subscription{}, ErrNotFound
+	}
+	return s, err
+}
+
+func (p *PostgresStore) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+func (p *PostgresStore) UpdateSubscription(ctx context.Context, s *Subscription) error {
+	events, err := json.Marshal(s.Events)
+	if err != nil {
+		return err
+	}
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE webhook_subscriptions
+		 SET url = $2, events = $3, secret = $4, active = $5, consecutive_failures = $6, disabled_at = $7
+		 WHERE id = $1`,
+		s.ID, s.URL, events, s.Secret, s.Active, s.ConsecutiveFailures, s.DisabledAt)
+	return expectOne(res, err)
+}
+
+// DeleteSubscription also drops the subscription's delivery log.
+func (p *PostgresStore) DeleteSubscription(ctx context.Context, id int64) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	return expectOne(res, err)
+}
+
+func (p *PostgresStore) CreateDelivery(ctx context.Context, d *Delivery) (bool, error) {
+	attempts, err := json.Marshal(d.Attempts)
+	if err != nil {
+		return false, err
+	}
+	replayOf := sql.NullInt64{Int64: d.ReplayOf, Valid: d.ReplayOf != 0}
+	err = p.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_deliveries
+		     (subscription_id, event_id, event_type, body, status, next_attempt_at, replay_of, attempts, created_at)
+		 SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9
+		 WHERE EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1)
+		 ON CONFLICT (subscription_id, event_id) WHERE replay_of IS NULL DO NOTHING
+		 RETURNING id`,
+		d.SubscriptionID, d.EventID, d.EventType, []byte(d.Body), string(d.Status), d.NextAttemptAt,
+		replayOf, attempts, d.CreatedAt).Scan(&d.ID)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	// Either the subscription is gone or the event was already delivered.
+	if _, err := p.GetSubscription(ctx, d.SubscriptionID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (p *PostgresStore) GetDelivery(ctx context.Context, id int64) (Delivery, error) {
+	d, err := scanDelivery(p.db.QueryRowContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Delivery{}, ErrNotFound
+	}
+	return d, err
+}
+
+func (p *PostgresStore) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE subscription_id = $1
+		 ORDER BY id DESC
+		 LIMIT $2`,
+		subscriptionID, max(limit, 0))
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+func (p *PostgresStore) ClaimDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Delivery, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`UPDATE webhook_deliveries SET locked_until = $2
+		 WHERE id IN (
+		     SELECT id FROM webhook_deliveries
+		     WHERE status = 'pending' AND next_attempt_at <= $1
+		       AND (locked_until IS NULL OR locked_until <= $1)
+		     ORDER BY id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING `+deliveryColumns,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	due, err := collectDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the subquery's order.
+	slices.SortFunc(due, func(a, b Delivery) int { return cmp.Compare(a.ID, b.ID) })
+	return due, nil
+}
+
+func (p *PostgresStore) RecordAttempt(ctx context.Context, deliveryID int64, a Attempt, status DeliveryStatus, next time.Time, disableAfter int) (bool, error) {
+	attempt, err := json.Marshal([]Attempt{a})
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var subID int64
+	err = tx.QueryRowContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET attempts = attempts || $2::jsonb, status = $3, next_attempt_at = $4, locked_until = NULL
+		 WHERE id = $1
+		 RETURNING subscription_id`,
+		deliveryID, attempt, string(status), next).Scan(&subID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var active bool
+	var failures int
+	err = tx.QueryRowContext(ctx,
+		`SELECT active, consecutive_failures FROM webhook_subscriptions WHERE id = $1 FOR UPDATE`,
+		subID).Scan(&active, &failures)
+	if err != nil {
+		return false, err
+	}
+
+	disabled := false
+	if status == DeliverySucceeded {
+		failures = 0
+	} else {
+		failures++
+		disabled = active && disableAfter > 0 && failures >= disableAfter
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE webhook_subscriptions
+		 SET consecutive_failures = $2,
+		     active = active AND NOT $3,
+		     disabled_at = CASE WHEN $3 THEN $4::timestamptz ELSE disabled_at END
+		 WHERE id = $1`,
+		subID, failures, disabled, a.At)
+	if err != nil {
+		return false, err
+	}
+	return disabled, tx.Commit()
+}
+
+func expectOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row scanner) (Subscription, error) {
+	var s Subscription
+	var events []byte
+	err := row.Scan(&s.ID, &s.URL, &events, &s.Secret, &s.Active, &s.ConsecutiveFailures,
+		&s.DisabledAt, &s.CreatedAt)
+	if err != nil {
+		return Subscription{}, err
+	}
+	return s, json.Unmarshal(events, &s.Events)
+}
+
+func scanDelivery(row scanner) (Delivery, error) {
+	var d Delivery
+	var body, attempts []byte
+	var replayOf sql.NullInt64
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &body, &d.Status,
+		&d.NextAttemptAt, &replayOf, &attempts, &d.CreatedAt)
+	if err != nil {
+		return Delivery{}, err
+	}
+	d.Body = body
+	d.ReplayOf = replayOf.Int64
+	return d, json.Unmarshal(attempts, &d.Attempts)
+}
+
+func collectDeliveries(rows *sql.Rows) ([]Delivery, error) {
+	defer rows.Close()
+	list := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-16/1-intro-to-tests/2-integration-tests/pgtest"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func TestPostgresStore(t *testing.T) {
+	store := NewPostgresStore(pgtest.Open(t, pgtest.Options{Migrations: "../migrations/*.sql"}))
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	sub := Subscription{URL: "https://example.com/hook", Events: []string{"user.*"}, Secret: testSecret, Active: true, CreatedAt: now}
+	require.NoError(t, store.CreateSubscription(ctx, &sub))
+	got, err := store.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sub.Events, got.Events)
+	_, err = store.GetSubscription(ctx, sub.ID+1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	body := json.RawMessage(`{"id": 1,  "type":"user.created"}`)
+	d := Delivery{SubscriptionID: sub.ID, EventID: 7, EventType: "user.created", Body: body,
+		Status: DeliveryPending, NextAttemptAt: now, Attempts: []Attempt{}, CreatedAt: now}
+	created, err := store.CreateDelivery(ctx, &d)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	again := d
+	created, err = store.CreateDelivery(ctx, &again)
+	require.NoError(t, err)
+	assert.False(t, created, "the same event is delivered once")
+
+	replay := d
+	replay.ReplayOf = d.ID
+	created, err = store.CreateDelivery(ctx, &replay)
+	require.NoError(t, err)
+	assert.True(t, created, "replays are not deduplicated")
+
+	missing := d
+	missing.SubscriptionID = sub.ID + 1
+	_, err = store.CreateDelivery(ctx, &missing)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	claimed, err := store.ClaimDeliveries(ctx, now, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, []int64{d.ID, replay.ID}, []int64{claimed[0].ID, claimed[1].ID})
+	assert.Equal(t, string(body), string(claimed[0].Body), "the body is kept byte for byte")
+	leased, err := store.ClaimDeliveries(ctx, now, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, leased)
+
+	disabled, err := store.RecordAttempt(ctx, d.ID, Attempt{At: now, StatusCode: 500, Error: "endpoint returned 500"},
+		DeliveryPending, now.Add(time.Second), 2)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+	disabled, err = store.RecordAttempt(ctx, replay.ID, Attempt{At: now, Error: "timeout"}, DeliveryFailed, now, 2)
+	require.NoError(t, err)
+	assert.True(t, disabled)
+
+	got, err = store.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Active)
+	assert.Equal(t, 2, got.ConsecutiveFailures)
+	require.NotNil(t, got.DisabledAt)
+
+	log, err := store.ListDeliveries(ctx, sub.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, replay.ID, log[0].ID, "newest first")
+	require.Len(t, log[1].Attempts, 1)
+	assert.Equal(t, 500, log[1].Attempts[0].StatusCode)
+
+	_, err = store.RecordAttempt(ctx, 999, Attempt{At: now}, DeliverySucceeded, now, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.DeleteSubscription(ctx, sub.ID))
+	_, err = store.GetDelivery(ctx, d.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "the delivery log goes with the subscription")
+	assert.ErrorIs(t, store.DeleteSubscription(ctx, sub.ID), ErrNotFound)
+}
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"ITMO-students/lecture-8/myapp/outbox"
+)
+
+const (
+	DefaultDeliveryLogLimit = 50
+	minSecretLength         = 16
+)
+
+type Service struct {
+	store  Store
+	now    func() time.Time
+	lookup func(ctx context.Context, host string) ([]netip.Addr, error)
+	// allowInternal lets tests subscribe httptest servers on loopback.
+	allowInternal bool
+}
+
+func NewService(store Store) *Service {
+	return &Service{store: store, now: time.Now, lookup: lookupHost}
+}
+
+func lookupHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+type SubscriptionInput struct {
+	URL    string
+	Events []string
+	// Secret is generated if empty.
+	Secret string
+}
+
+// SubscriptionPatch changes only non-nil fields. Setting Active to true
+// re-enables a disabled subscription and resets its failure count.
+type SubscriptionPatch struct {
+	URL    *string
+	Events []string
+	Active *bool
+}
+
+// CreateSubscription returns the subscription with its secret;
+// this is the only time the secret is shown.
+func (s *Service) CreateSubscription(ctx context.Context, in SubscriptionInput) (Subscription, error) {
+	if in.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return Subscription{}, err
+		}
+		in.Secret = secret
+	}
+	sub := Subscription{
+		URL:       in.URL,
+		Events:    in.Events,
+		Secret:    in.Secret,
+		Active:    true,
+		CreatedAt: s.now(),
+	}
+	if err := s.validate(ctx, sub); err != nil {
+		return Subscription{}, err
+	}
+	if err := s.store.CreateSubscription(ctx, &sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Service) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
+	sub, err := s.store.GetSubscription(ctx, id)
+	sub.Secret = ""
+	return sub, err
+}
+
+func (s *Service) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	subs, err := s.store.ListSubscriptions(ctx)
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, err
+}
+
+func (s *Service) UpdateSubscription(ctx context.Context, id int64, patch SubscriptionPatch) (Subscription, error) {
+	sub, err := s.store.GetSubscription(ctx, id)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	if patch.URL != nil {
+		sub.URL = *patch.URL
+	}
+	if patch.Events != nil {
+		sub.Events = patch.Events
+	}
+	if patch.Active != nil {
+		if *patch.Active && !sub.Active {
+			sub.ConsecutiveFailures = 0
+			sub.DisabledAt = nil
+		}
+		sub.Active = *patch.Active
+	}
+	if err := s.validate(ctx, sub); err != nil {
+		return Subscription{}, err
+	}
+	if err := s.store.UpdateSubscription(ctx, &sub); err != nil {
+		return Subscription{}, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *Service) DeleteSubscription(ctx context.Context, id int64) error {
+	return s.store.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries returns the newest deliveries of a subscription.
+func (s *Service) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error) {
+	if _, err := s.store.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > DefaultDeliveryLogLimit {
+		limit = DefaultDeliveryLogLimit
+	}
+	return s.store.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// Replay queues a new delivery with the same body as deliveryID,
+// whatever the outcome of the original was.
+func (s *Service) Replay(ctx context.Context, subscriptionID, deliveryID int64) (Delivery, error) {
+	orig, err := s.store.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if orig.SubscriptionID != subscriptionID {
+		return Delivery{}, ErrNotFound
+	}
+
+	now := s.now()
+	d := Delivery{
+		SubscriptionID: orig.SubscriptionID,
+		EventID:        orig.EventID,
+		EventType:      orig.EventType,
+		Body:           orig.Body,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		ReplayOf:       orig.ID,
+		Attempts:       []Attempt{},
+		CreatedAt:      now,
+	}
+	if _, err := s.store.CreateDelivery(ctx, &d); err != nil {
+		return Delivery{}, err
+	}
+	return d, nil
+}
+
+// Publish implements outbox.Publisher: it queues a delivery of e for every
+// active subscription that matches its type. Sending is left to a Pool, so
+// one slow partner does not hold up the outbox.
+func (s *Service) Publish(ctx context.Context, e outbox.Event) error {
+	subs, err := s.store.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return outbox.Permanent(err)
+	}
+
+	now := s.now()
+	for _, sub := range subs {
+		if !sub.Active || !sub.Matches(e.Type) {
+			continue
+		}
+		d := Delivery{
+			SubscriptionID: sub.ID,
+			EventID:        e.ID,
+			EventType:      e.Type,
+			Body:           body,
+			Status:         DeliveryPending,
+			NextAttemptAt:  now,
+			Attempts:       []Attempt{},
+			CreatedAt:      now,
+		}
+		if _, err := s.store.CreateDelivery(ctx, &d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) validate(ctx context.Context, sub Subscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidSubscription)
+	}
+	if err := s.checkHost(ctx, u.Hostname()); err != nil {
+		return err
+	}
+	if len(sub.Events) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidSubscription)
+	}
+	for _, e := range sub.Events {
+		if strings.TrimSpace(e) == "" {
+			return fmt.Errorf("%w: empty event type", ErrInvalidSubscription)
+		}
+	}
+	if len(sub.Secret) < minSecretLength {
+		return fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidSubscription, minSecretLength)
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,102 @@
+// Package webhook delivers user events to partner endpoints. Subscriptions
+// pick events by type; every matching event becomes a Delivery that a Pool
+// of workers POSTs, signed with the subscription's secret, retrying with
+// exponential backoff. Subscriptions that keep failing are disabled.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrInvalidSubscription = errors.New("invalid subscription")
+)
+
+// Subscription.Secret is only returned when the subscription is created.
+type Subscription struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Events are event types such as "user.created"; "*" matches every
+	// type and "user.*" every type starting with "user.".
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+	Active bool     `json:"active"`
+	// ConsecutiveFailures is reset by any successful attempt.
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// Matches reports whether events of type typ go to this subscription.
+func (s Subscription) Matches(typ string) bool {
+	for _, pattern := range s.Events {
+		switch {
+		case pattern == "*", pattern == typ:
+			return true
+		case strings.HasSuffix(pattern, ".*") && strings.HasPrefix(typ, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one subscription, with the log of attempts.
+type Delivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	EventID        int64  `json:"event_id"`
+	EventType      string `json:"event_type"`
+	// Body is the exact request body, so a replay sends the same bytes.
+	Body          json.RawMessage `json:"body"`
+	Status        DeliveryStatus  `json:"status"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	// ReplayOf is the delivery this one was replayed from.
+	ReplayOf  int64     `json:"replay_of,omitempty"`
+	Attempts  []Attempt `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Attempt struct {
+	At         time.Time     `json:"at"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration_ns"`
+}
+
+type Store interface {
+	// CreateSubscription assigns ID.
+	CreateSubscription(ctx context.Context, s *Subscription) error
+	GetSubscription(ctx context.Context, id int64) (Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	UpdateSubscription(ctx context.Context, s *Subscription) error
+	DeleteSubscription(ctx context.Context, id int64) error
+
+	// CreateDelivery assigns ID. A second delivery of the same event to the
+	// same subscription is ignored (created == false) unless it is a replay,
+	// so an outbox redelivery does not notify the partner twice.
+	CreateDelivery(ctx context.Context, d *Delivery) (created bool, err error)
+	GetDelivery(ctx context.Context, id int64) (Delivery, error)
+	// ListDeliveries returns up to limit deliveries of a subscription, newest first.
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]Delivery, error)
+	// ClaimDeliveries returns pending deliveries due at now, oldest first,
+	// hiding them from other workers until now+lease.
+	ClaimDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Delivery, error)
+	// RecordAttempt appends a to the delivery log and sets its status and
+	// next attempt time. It also updates the subscription's failure count
+	// and disables it once the count reaches disableAfter, returning
+	// disabled == true when that happens.
+	RecordAttempt(ctx context.Context, deliveryID int64, a Attempt, status DeliveryStatus, next time.Time, disableAfter int) (disabled bool, err error)
+}
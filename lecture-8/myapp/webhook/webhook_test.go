@@ -0,0 +1,359 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ITMO-students/lecture-8/myapp/clock"
+	"ITMO-students/lecture-8/myapp/outbox"
+)
+
+const testSecret = "0123456789abcdef-secret"
+
+type fixture struct {
+	store *MemoryStore
+	svc   *Service
+	pool  *Pool
+	clock *clock.Fake
+}
+
+func newFixture(t *testing.T, opts PoolOptions) *fixture {
+	t.Helper()
+	f := &fixture{
+		store: NewMemoryStore(),
+		clock: clock.NewFake(time.Now().Truncate(time.Second)),
+	}
+	f.svc = NewService(f.store)
+	f.svc.now = f.clock.Now
+	f.svc.lookup = publicLookup
+	f.svc.allowInternal = true
+	opts.Now = f.clock.Now
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	f.pool = NewPool(f.store, opts)
+	return f
+}
+
+// deliverDue makes one attempt for every delivery due now.
+func (f *fixture) deliverDue(t *testing.T) int {
+	t.Helper()
+	ctx := context.Background()
+	due, err := f.store.ClaimDeliveries(ctx, f.clock.Now(), 100, time.Minute)
+	require.NoError(t, err)
+	for _, d := range due {
+		require.NoError(t, f.pool.Deliver(ctx, d))
+	}
+	return len(due)
+}
+
+func publicLookup(context.Context, string) ([]netip.Addr, error) {
+	return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+}
+
+func userCreated(id int64) outbox.Event {
+	return outbox.Event{ID: id, Type: "user.created", AggregateID: "1", Payload: json.RawMessage(`{"id":1}`)}
+}
+
+func TestSubscription_Matches(t *testing.T) {
+	s := Subscription{Events: []string{"user.updated", "order.*"}}
+	assert.True(t, s.Matches("user.updated"))
+	assert.False(t, s.Matches("user.created"))
+	assert.True(t, s.Matches("order.paid"))
+	assert.False(t, s.Matches("orders.paid"))
+	assert.True(t, Subscription{Events: []string{"*"}}.Matches("anything"))
+}
+
+func TestService_Subscriptions(t *testing.T) {
+	f := newFixture(t, PoolOptions{})
+	ctx := context.Background()
+
+	_, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: "ftp://x", Events: []string{"*"}})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = f.svc.CreateSubscription(ctx, SubscriptionInput{URL: "https://example.com/hook"})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+	_, err = f.svc.CreateSubscription(ctx, SubscriptionInput{URL: "https://example.com/hook", Events: []string{"*"}, Secret: "short"})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+
+	sub, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: "https://example.com/hook", Events: []string{"user.*"}})
+	require.NoError(t, err)
+	assert.True(t, sub.Active)
+	assert.Regexp(t, `^whsec_[0-9a-f]{48}$`, sub.Secret)
+
+	got, err := f.svc.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Secret, "the secret is shown only on create")
+
+	list, err := f.svc.ListSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Empty(t, list[0].Secret)
+
+	url := "https://example.com/other"
+	updated, err := f.svc.UpdateSubscription(ctx, sub.ID, SubscriptionPatch{URL: &url})
+	require.NoError(t, err)
+	assert.Equal(t, url, updated.URL)
+	assert.Equal(t, []string{"user.*"}, updated.Events)
+
+	require.NoError(t, f.svc.DeleteSubscription(ctx, sub.ID))
+	_, err = f.svc.GetSubscription(ctx, sub.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_RejectsInternalURLs(t *testing.T) {
+	svc := NewService(NewMemoryStore())
+	svc.lookup = func(_ context.Context, host string) ([]netip.Addr, error) {
+		if host == "internal.example.com" {
+			return []netip.Addr{netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("10.0.0.5")}, nil
+		}
+		return publicLookup(context.Background(), host)
+	}
+	ctx := context.Background()
+
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.1/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+		"https://internal.example.com/hook",
+	} {
+		_, err := svc.CreateSubscription(ctx, SubscriptionInput{URL: u, Events: []string{"*"}})
+		assert.ErrorIs(t, err, ErrInvalidSubscription, u)
+	}
+
+	sub, err := svc.CreateSubscription(ctx, SubscriptionInput{URL: "https://example.com/hook", Events: []string{"*"}})
+	require.NoError(t, err)
+	internal := "http://127.0.0.1/hook"
+	_, err = svc.UpdateSubscription(ctx, sub.ID, SubscriptionPatch{URL: &internal})
+	assert.ErrorIs(t, err, ErrInvalidSubscription)
+}
+
+func TestService_Publish(t *testing.T) {
+	f := newFixture(t, PoolOptions{})
+	ctx := context.Background()
+
+	users, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: "https://a.example.com", Events: []string{"user.created"}})
+	require.NoError(t, err)
+	_, err = f.svc.CreateSubscription(ctx, SubscriptionInput{URL: "https://b.example.com", Events: []string{"order.*"}})
+	require.NoError(t, err)
+
+	require.NoError(t, f.svc.Publish(ctx, userCreated(1)))
+	// The outbox may deliver the same event again.
+	require.NoError(t, f.svc.Publish(ctx, userCreated(1)))
+
+	deliveries, err := f.svc.ListDeliveries(ctx, users.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, int64(1), deliveries[0].EventID)
+	assert.Equal(t, DeliveryPending, deliveries[0].Status)
+
+	var body outbox.Event
+	require.NoError(t, json.Unmarshal(deliveries[0].Body, &body))
+	assert.Equal(t, "user.created", body.Type)
+}
+
+func TestPool_SignedDelivery(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	f := newFixture(t, PoolOptions{Client: srv.Client()})
+	ctx := context.Background()
+	sub, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: srv.URL, Events: []string{"*"}, Secret: testSecret})
+	require.NoError(t, err)
+	require.NoError(t, f.svc.Publish(ctx, userCreated(42)))
+
+	assert.Equal(t, 1, f.deliverDue(t))
+
+	require.NotNil(t, got)
+	assert.Equal(t, "42", got.Header.Get(IDHeader))
+	assert.Equal(t, "user.created", got.Header.Get(EventHeader))
+	assert.NoError(t, Verify(testSecret, got.Header.Get(TimestampHeader), got.Header.Get(SignatureHeader),
+		gotBody, f.clock.Now(), DefaultTolerance))
+
+	deliveries, err := f.svc.ListDeliveries(ctx, sub.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries[0].Attempts, 1)
+	assert.Equal(t, DeliverySucceeded, deliveries[0].Status)
+	assert.Equal(t, http.StatusNoContent, deliveries[0].Attempts[0].StatusCode)
+}
+
+func TestPool_DefaultClientRefusesInternal(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	// The subscription got past validation, e.g. its DNS record changed later.
+	f := newFixture(t, PoolOptions{MaxAttempts: 1})
+	ctx := context.Background()
+	sub, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: srv.URL, Events: []string{"*"}, Secret: testSecret})
+	require.NoError(t, err)
+	require.NoError(t, f.svc.Publish(ctx, userCreated(1)))
+
+	assert.Equal(t, 1, f.deliverDue(t))
+	assert.Zero(t, calls.Load())
+
+	deliveries, err := f.svc.ListDeliveries(ctx, sub.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries[0].Attempts, 1)
+	assert.Contains(t, deliveries[0].Attempts[0].Error, "internal address")
+}
+
+func TestPool_RetryBackoffAndFailure(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	f := newFixture(t, PoolOptions{Client: srv.Client(), MaxAttempts: 3, DisableAfter: 100})
+	ctx := context.Background()
+	sub, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: srv.URL, Events: []string{"*"}, Secret: testSecret})
+	require.NoError(t, err)
+	require.NoError(t, f.svc.Publish(ctx, userCreated(1)))
+
+	assert.Equal(t, 1, f.deliverDue(t))
+	assert.Equal(t, 0, f.deliverDue(t), "not due before backoff")
+
+	f.clock.Advance(10 * time.Second)
+	assert.Equal(t, 1, f.deliverDue(t))
+	f.clock.Advance(19 * time.Second)
+	assert.Equal(t, 0, f.deliverDue(t), "backoff doubles")
+	f.clock.Advance(time.Second)
+	assert.Equal(t, 1, f.deliverDue(t))
+
+	f.clock.Advance(time.Hour)
+	assert.Equal(t, 0, f.deliverDue(t), "failed after MaxAttempts")
+	assert.Equal(t, int32(3), calls.Load())
+
+	deliveries, err := f.svc.ListDeliveries(ctx, sub.ID, 0)
+	require.NoError(t, err)
+	d := deliveries[0]
+	assert.Equal(t, DeliveryFailed, d.Status)
+	require.Len(t, d.Attempts, 3)
+	assert.Equal(t, "endpoint returned 500", d.Attempts[2].Error)
+
+	got, err := f.svc.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.ConsecutiveFailures)
+	assert.True(t, got.Active)
+}
+
+func TestPool_DisablesFailingSubscription(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	f := newFixture(t, PoolOptions{Client: srv.Client(), MaxAttempts: 1, DisableAfter: 2})
+	ctx := context.Background()
+	sub, err := f.svc.CreateSubscription(ctx, SubscriptionInput{URL: srv.URL, Events: []string{"*"}, Secret: testSecret})
+	require.NoError(t, err)
+
+	require.NoError(t, f.svc.Publish(ctx, userCreated(1)))
+	require.NoError(t, f.svc.Publish(ctx, userCreated(2)))
+	assert.Equal(t, 2, f.deliverDue(t))
+
+	got, err := f.svc.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Active)
+	require.NotNil(t, got.DisabledAt)
+
+	require.NoError(t, f.svc.Publish(ctx, userCreated(3)))
+	deliveries, _ := f.svc.ListDeliveries(ctx, sub.ID, 0)
+	assert.Len(t, deliveries, 2, "disabled subscriptions get no new deliveries")
+
+	// Re-enabling resets the counter; a replay then goes through.
+	fail.Store(false)
+	active := true
+	got, err = f.svc.UpdateSubscription(ctx, sub.ID, SubscriptionPatch{Active: &active})
+	require.NoError(t, err)
+	assert.Zero(t, got.ConsecutiveFailures)
+	assert.Nil(t, got.DisabledAt)
+
+	replay, err := f.svc.Replay(ctx, sub.ID, deliveries[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, deliveries[1].ID, replay.ReplayOf)
+	assert.Equal(t, 1, f.deliverDue(t))
+
+	d, err := f.store.GetDelivery(ctx, replay.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DeliverySucceeded, d.Status)
+	assert.Equal(t, deliveries[1].Body, d.Body)
+
+	_, err = f.svc.Replay(ctx, sub.ID+1, deliveries[1].ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPool_Run(t *testing.T) {
+	received := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(IDHeader)
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	svc := NewService(store)
+	svc.allowInternal = true
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := svc.CreateSubscription(ctx, SubscriptionInput{URL: srv.URL, Events: []string{"*"}, Secret: testSecret})
+	require.NoError(t, err)
+	for i := range int64(5) {
+		require.NoError(t, svc.Publish(ctx, userCreated(i+1)))
+	}
+
+	pool := NewPool(store, PoolOptions{Workers: 2, PollInterval: time.Millisecond, Client: srv.Client(),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	done := make(chan error)
+	go func() { done <- pool.Run(ctx) }()
+
+	seen := map[string]bool{}
+	for len(seen) < 5 {
+		select {
+		case id := <-received:
+			seen[id] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received only %d deliveries", len(seen))
+		}
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":1}`)
+	sig := Sign(testSecret, now, body)
+	ts := "1700000000"
+
+	assert.NoError(t, Verify(testSecret, ts, sig, body, now, DefaultTolerance))
+	assert.NoError(t, Verify(testSecret, ts, "v1=00,"+sig, body, now, DefaultTolerance), "rotation: any signature may match")
+	assert.ErrorIs(t, Verify("other-secret-value", ts, sig, body, now, DefaultTolerance), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, ts, sig, []byte(`{"id":2}`), now, DefaultTolerance), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(testSecret, ts, sig, body, now.Add(10*time.Minute), DefaultTolerance), ErrStaleTimestamp)
+	assert.ErrorIs(t, Verify(testSecret, "abc", sig, body, now, DefaultTolerance), ErrInvalidSignature)
+}